@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"time"
+
+	"aleo-prover-monitor/apiclient"
+	"aleo-prover-monitor/config"
+	"aleo-prover-monitor/prometh"
+)
+
+func loadAddresses(filename string) ([]string, error) {
+	lines, err := readLinesFromFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		addr := apiclient.NormalizeAddress(line)
+		if addr == "" {
+			continue
+		}
+		if addr != line {
+			log.Printf("address on line %d normalized from %q to %q", i+1, line, addr)
+		}
+		if seen[addr] {
+			log.Printf("duplicate address %s on line %d ignored", addr, i+1)
+			continue
+		}
+		seen[addr] = true
+		addresses = append(addresses, addr)
+	}
+	return addresses, nil
+}
+
+// loadAddressFiles merges the lists of files, an address listed in several
+// files stays with the first. It also returns the label of the file every
+// address came from.
+func loadAddressFiles(files config.AddrFiles) ([]string, map[string]string, error) {
+	var addresses []string
+	sources := make(map[string]string)
+	for _, f := range files {
+		list, err := loadAddresses(f.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, addr := range list {
+			if source, ok := sources[addr]; ok {
+				log.Printf("address %s of %s already listed in %s, ignored", addr, f.Path, source)
+				continue
+			}
+			sources[addr] = f.Label()
+			addresses = append(addresses, addr)
+		}
+	}
+	return addresses, sources, nil
+}
+
+// withSources labels the per-address series of gw with the source file of
+// every address, if cfg.AddrFiles asks for it. It returns nil for the
+// labels otherwise.
+func withSources(gw prometh.Gateway, sources map[string]string) (prometh.Gateway, *prometh.AddressLabels) {
+	if !cfg.AddrFiles.Labeled() {
+		return gw, nil
+	}
+	labeled := &prometh.AddressLabels{Next: gw, Names: []string{"source"}}
+	labeled.Set(sourceLabels(sources))
+	return labeled, labeled
+}
+
+func sourceLabels(sources map[string]string) map[string]map[string]string {
+	labels := make(map[string]map[string]string, len(sources))
+	for addr, source := range sources {
+		labels[addr] = map[string]string{"source": source}
+	}
+	return labels
+}
+
+// mergeLabels returns the labels of an address from both lookups, the ones
+// of primary win. Either may be nil.
+func mergeLabels(primary func(string) map[string]string, secondary func(string) map[string]string) func(string) map[string]string {
+	if primary == nil {
+		return secondary
+	}
+	if secondary == nil {
+		return primary
+	}
+	return func(addr string) map[string]string {
+		labels := make(map[string]string)
+		for name, value := range secondary(addr) {
+			labels[name] = value
+		}
+		for name, value := range primary(addr) {
+			labels[name] = value
+		}
+		return labels
+	}
+}
+
+func diffAddresses(old []string, current []string) (added []string, removed []string) {
+	before := make(map[string]bool, len(old))
+	for _, a := range old {
+		before[a] = true
+	}
+	after := make(map[string]bool, len(current))
+	for _, a := range current {
+		after[a] = true
+		if !before[a] {
+			added = append(added, a)
+		}
+	}
+	for _, a := range old {
+		if !after[a] {
+			removed = append(removed, a)
+		}
+	}
+	return added, removed
+}
+
+// reloadAddresses re-reads the address files into m, a broken file keeps the
+// current list.
+func reloadAddresses(m *monitor) {
+	addresses, sources, err := loadAddressFiles(cfg.AddrFiles)
+	if err != nil {
+		log.Printf("reload addresses failed, keeping current list:%s", err)
+		return
+	}
+	if len(addresses) == 0 {
+		log.Printf("reload addresses: %s is empty, keeping current list", cfg.AddrFiles.String())
+		return
+	}
+
+	if m.sources != nil {
+		m.sources.Set(sourceLabels(sources))
+	}
+	added, removed := m.setAddresses(addresses)
+	log.Printf("reloaded %d addresses, added %v, removed %v", len(addresses), added, removed)
+	if m.enrich != nil && len(added) > 0 {
+		go m.enrich(added)
+	}
+}
+
+// addressFileStat is what the address file looks like on disk, so hosts can
+// be checked to run the same list.
+type addressFileStat struct {
+	modified time.Time
+	lines    int
+	sha256   string
+}
+
+func statAddressFile(filename string) (addressFileStat, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return addressFileStat{}, err
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return addressFileStat{}, err
+	}
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	sum := sha256.Sum256(data)
+	return addressFileStat{modified: info.ModTime(), lines: lines, sha256: hex.EncodeToString(sum[:])}, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aleo-prover-monitor/alert"
+	"aleo-prover-monitor/apiclient"
+	"aleo-prover-monitor/store"
+)
+
+func startAdmin(addr string, history *alert.History, alerts *alert.Engine, silences *alert.Silences, points *store.Store) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/alerts/history", func(w http.ResponseWriter, r *http.Request) {
+		events := history.Events()
+		if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(events) {
+			events = events[len(events)-limit:]
+		}
+		writeJSON(w, events)
+	})
+	mux.HandleFunc("/alerts/thresholds", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, alerts.Rules())
+		case http.MethodPost, http.MethodPut:
+			if !authorized(r) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			var change struct {
+				Rule      string   `json:"rule"`
+				Threshold *float64 `json:"threshold"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&change); err != nil || change.Threshold == nil {
+				http.Error(w, `want {"rule": name, "threshold": value}`, http.StatusBadRequest)
+				return
+			}
+			if err := alerts.SetThreshold(change.Rule, *change.Threshold); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, alerts.Rules())
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/silences", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, silences.List())
+		case http.MethodPost:
+			if !authorized(r) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			var sil alert.Silence
+			if err := json.NewDecoder(r.Body).Decode(&sil); err != nil {
+				http.Error(w, "bad silence: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			for i, a := range sil.Addresses {
+				sil.Addresses[i] = apiclient.NormalizeAddress(a)
+			}
+			if sil.Start.IsZero() {
+				sil.Start = time.Now()
+			}
+			added, err := silences.Add(sil)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			writeJSON(w, added)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/silences/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !silences.Remove(strings.TrimPrefix(r.URL.Path, "/silences/")) {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/addresses/", func(w http.ResponseWriter, r *http.Request) {
+		address, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/addresses/"), "/history")
+		if !ok || address == "" || strings.Contains(address, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		window := 24 * time.Hour
+		if v := r.URL.Query().Get("window"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				http.Error(w, "bad window: "+v, http.StatusBadRequest)
+				return
+			}
+			window = d
+		}
+		n := 100
+		if v, err := strconv.Atoi(r.URL.Query().Get("points")); err == nil && v > 0 {
+			n = v
+		}
+		writeJSON(w, points.Query(apiclient.NormalizeAddress(address), window, n, time.Now()))
+	})
+
+	go func() {
+		log.Printf("admin listening on %s", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Fatalf("admin listen %s failed: %v", addr, err)
+		}
+	}()
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write response failed:%s", err)
+	}
+}
+
+// authorized checks the bearer token of a modifying request, without a
+// configured token every change is refused.
+func authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if cfg.AdminToken == "" || !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(cfg.AdminToken)) == 1
+}
@@ -0,0 +1,245 @@
+package alert
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	Firing   State = "firing"
+	Resolved State = "resolved"
+	// Report events carry a Summary instead of an alert.
+	Report State = "report"
+)
+
+type Event struct {
+	Rule      string    `json:"rule"`
+	Addr      string    `json:"addr,omitempty"`
+	State     State     `json:"state"`
+	Severity  string    `json:"severity,omitempty"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+	Summary   *Summary  `json:"summary,omitempty"`
+}
+
+// Rule fires when the observed value is at or below the threshold, or at or
+// above it when Below is false. A firing rule with Clear set only resolves
+// once the value is past Clear, so values hovering around the threshold
+// don't toggle it.
+type Rule struct {
+	Name      string   `json:"name"`
+	Severity  string   `json:"severity"`
+	Threshold float64  `json:"threshold"`
+	Clear     *float64 `json:"clear,omitempty"`
+	Below     bool     `json:"below"`
+}
+
+func (r Rule) firing(value float64, wasFiring bool) bool {
+	threshold := r.Threshold
+	if wasFiring && r.Clear != nil {
+		threshold = *r.Clear
+	}
+	if r.Below {
+		return value <= threshold
+	}
+	return value >= threshold
+}
+
+type Engine struct {
+	// Notify, if set, is called with every state change and must not block.
+	Notify func(Event)
+	// Cooldown holds back the notification of an alert that fires again
+	// within this long of its last notification, and of its resolve. One
+	// still firing once the cooldown ran out or of a higher severity is
+	// sent anyway.
+	Cooldown time.Duration
+	// Silences, if set, holds back the notifications of silenced alerts, one
+	// still firing when its silence ends is sent then.
+	Silences *Silences
+
+	mu        sync.Mutex
+	rules     map[string]Rule
+	overrides []Override
+	labels    func(addr string) map[string]string
+	active    map[string]Event
+	notified  map[string]Event
+	muted     map[string]bool
+	history   *History
+}
+
+var severityRank = map[string]int{"info": 1, "warning": 2, "critical": 3}
+
+func NewEngine(history *History, rules ...Rule) *Engine {
+	e := &Engine{
+		rules:    make(map[string]Rule),
+		active:   make(map[string]Event),
+		notified: make(map[string]Event),
+		muted:    make(map[string]bool),
+		history:  history,
+	}
+	for _, r := range rules {
+		e.rules[r.Name] = r
+	}
+	return e
+}
+
+// Evaluate feeds one observation of rule for addr and returns the event when
+// the alert changed state.
+func (e *Engine) Evaluate(rule string, addr string, value float64, at time.Time) (Event, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r, ok := e.rules[rule]
+	if !ok {
+		return Event{}, false
+	}
+	r = e.ruleFor(r, addr)
+
+	key := rule + "/" + addr
+	prev, wasFiring := e.active[key]
+	firing := r.firing(value, wasFiring)
+	if firing == wasFiring {
+		if !firing || !e.muted[key] || at.Sub(e.notified[key].Time) < e.Cooldown {
+			return Event{}, false
+		}
+		if _, silenced := e.silence(prev, at); !silenced {
+			log.Printf("alert %s still firing after cooldown or silence", key)
+			delete(e.muted, key)
+			ev := prev
+			ev.Time = at
+			e.notify(key, ev)
+		}
+		return Event{}, false
+	}
+
+	ev := Event{Rule: rule, Addr: addr, Severity: r.Severity, Value: value, Threshold: r.Threshold, Time: at}
+	if firing {
+		ev.State = Firing
+		ev.Message = fmt.Sprintf("%s %s: value %g crossed threshold %g", rule, addr, value, r.Threshold)
+		e.active[key] = ev
+	} else {
+		ev.State = Resolved
+		ev.Message = fmt.Sprintf("%s %s: recovered to %g, firing since %s", rule, addr, value, prev.Time.Format(time.RFC3339))
+		delete(e.active, key)
+	}
+
+	log.Printf("alert %s: %s", ev.State, ev.Message)
+	if e.history != nil {
+		e.history.Add(ev)
+	}
+	silence, silenced := e.silence(ev, at)
+	switch {
+	case ev.State == Resolved && e.muted[key]:
+		delete(e.muted, key)
+		log.Printf("alert %s resolve not notified, its firing was held back", key)
+	case ev.State == Firing && silenced:
+		e.muted[key] = true
+		log.Printf("alert %s silenced by %s", key, silence)
+	case ev.State == Firing && e.cooling(key, ev):
+		e.muted[key] = true
+		log.Printf("alert %s notified at %s, holding back for the %s cooldown", key, e.notified[key].Time.Format(time.RFC3339), e.Cooldown)
+	default:
+		e.notify(key, ev)
+	}
+	return ev, true
+}
+
+// cooling reports whether the firing ev comes within the cooldown of the last
+// firing notification of key without escalating its severity.
+func (e *Engine) cooling(key string, ev Event) bool {
+	last, ok := e.notified[key]
+	if e.Cooldown <= 0 || !ok || ev.Time.Sub(last.Time) >= e.Cooldown {
+		return false
+	}
+	return severityRank[ev.Severity] <= severityRank[last.Severity]
+}
+
+// silence returns the ID of the silence covering ev at at.
+func (e *Engine) silence(ev Event, at time.Time) (string, bool) {
+	if e.Silences == nil {
+		return "", false
+	}
+	return e.Silences.Silenced(ev, at)
+}
+
+func (e *Engine) notify(key string, ev Event) {
+	if ev.State == Firing {
+		e.notified[key] = ev
+	}
+	if e.Notify != nil {
+		e.Notify(ev)
+	}
+}
+
+// Rules returns the configured rules sorted by name.
+func (e *Engine) Rules() []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := make([]Rule, 0, len(e.rules))
+	for _, r := range e.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// SetThreshold changes the threshold of rule at runtime, active alerts keep
+// their state until the next evaluation.
+func (e *Engine) SetThreshold(rule string, threshold float64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r, ok := e.rules[rule]
+	if !ok {
+		return fmt.Errorf("unknown rule %s", rule)
+	}
+	log.Printf("alert rule %s threshold changed from %g to %g", rule, r.Threshold, threshold)
+	r.Threshold = threshold
+	e.rules[rule] = r
+	return nil
+}
+
+func (e *Engine) Active() []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	events := make([]Event, 0, len(e.active))
+	for _, ev := range e.active {
+		events = append(events, ev)
+	}
+	return events
+}
+
+// Forget drops the active alerts of addr without resolving them, for
+// addresses that are no longer watched.
+func (e *Engine) Forget(addr string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, ev := range e.active {
+		if ev.Addr != addr {
+			continue
+		}
+		log.Printf("alert %s dropped, %s is retiring", key, addr)
+		delete(e.active, key)
+		delete(e.muted, key)
+		delete(e.notified, key)
+	}
+}
+
+// Firing reports whether rule is firing for addr.
+func (e *Engine) Firing(rule string, addr string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, ok := e.active[rule+"/"+addr]
+	return ok
+}
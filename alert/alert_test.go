@@ -0,0 +1,118 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+// sent returns an engine over rules that records the notified events as
+// rule/state strings.
+func sent(rules ...Rule) (*Engine, *[]string) {
+	var notified []string
+	e := NewEngine(nil, rules...)
+	e.Notify = func(ev Event) { notified = append(notified, ev.Rule+"/"+string(ev.State)) }
+	return e, &notified
+}
+
+func equal(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCooldown(t *testing.T) {
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	offline := Rule{Name: "offline", Severity: "warning", Threshold: 0, Below: true}
+	tests := []struct {
+		name   string
+		values []float64
+		every  time.Duration
+		want   []string
+	}{
+		{"repeat inside the cooldown held back", []float64{0, 10, 0, 10}, time.Minute, []string{"offline/firing", "offline/resolved"}},
+		{"repeat after the cooldown sent", []float64{0, 10, 0}, 10 * time.Minute, []string{"offline/firing", "offline/resolved", "offline/firing"}},
+		{"held back repeat sent once the cooldown ran out", []float64{0, 10, 0, 0, 0}, 2 * time.Minute, []string{"offline/firing", "offline/resolved", "offline/firing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, notified := sent(offline)
+			e.Cooldown = 5 * time.Minute
+			for i, v := range tt.values {
+				e.Evaluate("offline", "aleo1", v, start.Add(time.Duration(i)*tt.every))
+			}
+			if !equal(*notified, tt.want) {
+				t.Errorf("notified %v, want %v", *notified, tt.want)
+			}
+		})
+	}
+}
+
+func TestCooldownResolveGoesOut(t *testing.T) {
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	e, notified := sent(Rule{Name: "offline", Severity: "warning", Threshold: 0, Below: true})
+	e.Cooldown = time.Hour
+
+	e.Evaluate("offline", "aleo1", 0, start)
+	ev, changed := e.Evaluate("offline", "aleo1", 10, start.Add(time.Second))
+	if !changed || ev.State != Resolved {
+		t.Fatalf("evaluate = %+v, %v, want a resolve", ev, changed)
+	}
+	if want := []string{"offline/firing", "offline/resolved"}; !equal(*notified, want) {
+		t.Errorf("notified %v, want %v", *notified, want)
+	}
+}
+
+func TestCooldownEscalationSent(t *testing.T) {
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	e, notified := sent(Rule{Name: "offline", Severity: "warning", Threshold: 0, Below: true})
+	e.Cooldown = time.Hour
+
+	e.Evaluate("offline", "aleo1", 0, start)
+	e.Evaluate("offline", "aleo1", 10, start.Add(time.Minute))
+	e.rules["offline"] = Rule{Name: "offline", Severity: "critical", Threshold: 0, Below: true}
+	e.Evaluate("offline", "aleo1", 0, start.Add(2*time.Minute))
+	if want := []string{"offline/firing", "offline/resolved", "offline/firing"}; !equal(*notified, want) {
+		t.Errorf("notified %v, want %v", *notified, want)
+	}
+}
+
+func TestHysteresis(t *testing.T) {
+	slowClear, lagClear := 50.0, 5.0
+	slow := Rule{Name: "slow", Severity: "warning", Threshold: 40, Clear: &slowClear, Below: true}
+	lag := Rule{Name: "lag", Severity: "warning", Threshold: 10, Clear: &lagClear}
+	tests := []struct {
+		rule   Rule
+		values []float64
+		want   []string
+	}{
+		{slow, []float64{40}, []string{"slow/firing"}},
+		{slow, []float64{41}, nil},
+		{slow, []float64{30, 45}, []string{"slow/firing"}},
+		{slow, []float64{30, 50}, []string{"slow/firing"}},
+		{slow, []float64{30, 50.5}, []string{"slow/firing", "slow/resolved"}},
+		{slow, []float64{30, 51, 45}, []string{"slow/firing", "slow/resolved"}},
+		{lag, []float64{10, 7}, []string{"lag/firing"}},
+		{lag, []float64{10, 5}, []string{"lag/firing"}},
+		{lag, []float64{10, 4}, []string{"lag/firing", "lag/resolved"}},
+	}
+	for _, tt := range tests {
+		e, notified := sent(tt.rule)
+		at := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+		for _, v := range tt.values {
+			e.Evaluate(tt.rule.Name, "aleo1", v, at)
+			at = at.Add(time.Minute)
+		}
+		if !equal(*notified, tt.want) {
+			t.Errorf("%s %v: notified %v, want %v", tt.rule.Name, tt.values, *notified, tt.want)
+		}
+		if want := len(tt.want) == 1; e.Firing(tt.rule.Name, "aleo1") != want {
+			t.Errorf("%s %v: firing = %v, want %v", tt.rule.Name, tt.values, !want, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alertmanager posts events to the Alertmanager v2 API. Alertmanager resolves
+// alerts it hasn't heard of for a while, so Run re-sends the firing ones.
+type Alertmanager struct {
+	URL string
+	// Labels and Annotations are added to every alert.
+	Labels      map[string]string
+	Annotations map[string]string
+	Client      *http.Client
+
+	mu     sync.Mutex
+	firing map[string]Event
+}
+
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    string            `json:"startsAt,omitempty"`
+	EndsAt      string            `json:"endsAt,omitempty"`
+}
+
+func (a *Alertmanager) Notify(ctx context.Context, ev Event) error {
+	if ev.State == Report {
+		return nil
+	}
+	a.mu.Lock()
+	if a.firing == nil {
+		a.firing = make(map[string]Event)
+	}
+	if ev.State == Firing {
+		a.firing[ev.Rule+"/"+ev.Addr] = ev
+	} else {
+		delete(a.firing, ev.Rule+"/"+ev.Addr)
+	}
+	a.mu.Unlock()
+
+	return a.post(ctx, []alertmanagerAlert{a.alert(ev)})
+}
+
+// Run re-sends the firing alerts every interval until ctx is done.
+func (a *Alertmanager) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		a.mu.Lock()
+		alerts := make([]alertmanagerAlert, 0, len(a.firing))
+		for _, ev := range a.firing {
+			alerts = append(alerts, a.alert(ev))
+		}
+		a.mu.Unlock()
+		if len(alerts) == 0 {
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, interval)
+		if err := a.post(sendCtx, alerts); err != nil {
+			log.Printf("resend alerts to alertmanager failed:%s", err)
+		}
+		cancel()
+	}
+}
+
+func (a *Alertmanager) alert(ev Event) alertmanagerAlert {
+	labels := map[string]string{"alertname": ev.Rule}
+	for k, v := range a.Labels {
+		labels[k] = v
+	}
+	if ev.Severity != "" {
+		labels["severity"] = ev.Severity
+	}
+	if ev.Addr != "" {
+		labels["addr"] = ev.Addr
+	}
+
+	annotations := map[string]string{
+		"summary":   ev.Message,
+		"value":     strconv.FormatFloat(ev.Value, 'g', -1, 64),
+		"threshold": strconv.FormatFloat(ev.Threshold, 'g', -1, 64),
+	}
+	for k, v := range a.Annotations {
+		annotations[k] = v
+	}
+
+	alert := alertmanagerAlert{Labels: labels, Annotations: annotations}
+	if ev.State == Firing {
+		alert.StartsAt = ev.Time.UTC().Format(time.RFC3339)
+	} else {
+		alert.EndsAt = ev.Time.UTC().Format(time.RFC3339)
+	}
+
+	return alert
+}
+
+func (a *Alertmanager) post(ctx context.Context, alerts []alertmanagerAlert) error {
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, a.Client, strings.TrimRight(a.URL, "/")+"/api/v2/alerts", body)
+}
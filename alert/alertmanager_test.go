@@ -0,0 +1,82 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAlertmanagerNotify(t *testing.T) {
+	e := newEndpoint(t, 200, "")
+	a := &Alertmanager{URL: e.URL + "/", Labels: map[string]string{"team": "ops", "severity": "page"}, Annotations: map[string]string{"runbook": "https://wiki/offline"}}
+
+	if err := a.Notify(context.Background(), firing); err != nil {
+		t.Fatal(err)
+	}
+	resolved := firing
+	resolved.State = Resolved
+	resolved.Value = 12.5
+	resolved.Time = firing.Time.Add(time.Hour)
+	if err := a.Notify(context.Background(), resolved); err != nil {
+		t.Fatal(err)
+	}
+	a.Notify(context.Background(), report)
+
+	requests := e.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want firing and resolved only", len(requests))
+	}
+	if r := requests[0]; r.Path != "/api/v2/alerts" || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("%s with %q", r.Path, r.Header.Get("Content-Type"))
+	}
+	fired := `[{"labels":{"alertname":"prover_offline","severity":"critical","addr":"aleo1abc","team":"ops"},
+		"annotations":{"summary":"prover_offline aleo1abc: value 0 crossed threshold 0","value":"0","threshold":"0","runbook":"https://wiki/offline"},
+		"startsAt":"2026-10-14T12:00:00Z"}]`
+	if !sameJSON(t, requests[0].Body, fired) {
+		t.Errorf("firing %s", requests[0].Body)
+	}
+	ended := `[{"labels":{"alertname":"prover_offline","severity":"critical","addr":"aleo1abc","team":"ops"},
+		"annotations":{"summary":"prover_offline aleo1abc: value 0 crossed threshold 0","value":"12.5","threshold":"0","runbook":"https://wiki/offline"},
+		"endsAt":"2026-10-14T13:00:00Z"}]`
+	if !sameJSON(t, requests[1].Body, ended) {
+		t.Errorf("resolved %s", requests[1].Body)
+	}
+}
+
+func TestAlertmanagerResend(t *testing.T) {
+	e := newEndpoint(t, 200, "")
+	a := &Alertmanager{URL: e.URL}
+	other := firing
+	other.Addr = "aleo1def"
+	resolved := firing
+	resolved.State = Resolved
+	a.Notify(context.Background(), firing)
+	a.Notify(context.Background(), other)
+	a.Notify(context.Background(), resolved)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx, 20*time.Millisecond)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(e.Requests()) < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	requests := e.Requests()
+	if len(requests) < 5 {
+		t.Fatalf("%d requests, want two resends", len(requests))
+	}
+	want := `[{"labels":{"alertname":"prover_offline","severity":"critical","addr":"aleo1def"},
+		"annotations":{"summary":"prover_offline aleo1abc: value 0 crossed threshold 0","value":"0","threshold":"0"},
+		"startsAt":"2026-10-14T12:00:00Z"}]`
+	for _, r := range requests[3:] {
+		if !sameJSON(t, r.Body, want) {
+			t.Errorf("resent %s, want the one still firing", r.Body)
+		}
+	}
+}
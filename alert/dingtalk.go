@@ -0,0 +1,81 @@
+package alert
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DingTalk sends events to a group robot webhook, signed with Secret when
+// the robot has signing enabled.
+type DingTalk struct {
+	WebhookURL string
+	Secret     string
+	Client     *http.Client
+}
+
+// robotResult is the answer of the DingTalk and WeCom robots, their errors
+// come back with status 200.
+type robotResult struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (r robotResult) err() error {
+	if r.ErrCode != 0 {
+		return fmt.Errorf("errcode %d: %s", r.ErrCode, r.ErrMsg)
+	}
+	return nil
+}
+
+func (d *DingTalk) Notify(ctx context.Context, ev Event) error {
+	title := Title(ev)
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": title,
+			"text":  fmt.Sprintf("### %s\n\n%s\n\n%s", title, ev.Message, ev.Time.Format(time.RFC3339)),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	webhook := d.WebhookURL
+	if d.Secret != "" {
+		webhook, err = d.sign(webhook, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	var result robotResult
+	if err := postJSONDecode(ctx, d.Client, webhook, body, &result); err != nil {
+		return err
+	}
+	return result.err()
+}
+
+// sign adds timestamp and sign as described by the DingTalk robot security
+// settings: base64 HMAC-SHA256 of "timestamp\nsecret" keyed with the secret.
+func (d *DingTalk) sign(webhook string, now time.Time) (string, error) {
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return "", err
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(d.Secret))
+	mac.Write([]byte(timestamp + "\n" + d.Secret))
+
+	q := u.Query()
+	q.Set("timestamp", timestamp)
+	q.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,66 @@
+package alert
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDingTalkSign(t *testing.T) {
+	d := &DingTalk{Secret: "SEC0123456789"}
+	signed, err := d.sign("https://oapi.dingtalk.com/robot/send?access_token=tok", time.UnixMilli(1700000000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(signed)
+	q := u.Query()
+	if q.Get("access_token") != "tok" || q.Get("timestamp") != "1700000000000" {
+		t.Errorf("query %v", q)
+	}
+	if got := q.Get("sign"); got != "VloEIlTtJU6a/AGf2pud1WypXdicIlyQAOpspu6OP6s=" {
+		t.Errorf("sign = %s", got)
+	}
+}
+
+func TestDingTalkNotify(t *testing.T) {
+	e := newEndpoint(t, 200, `{"errcode":0,"errmsg":"ok"}`)
+	d := &DingTalk{WebhookURL: e.URL + "/robot/send?access_token=tok", Secret: "SEC0123456789"}
+	before := time.Now()
+	if err := d.Notify(context.Background(), firing); err != nil {
+		t.Fatal(err)
+	}
+
+	r := e.Requests()[0]
+	if r.Path != "/robot/send" || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("%s with %q", r.Path, r.Header.Get("Content-Type"))
+	}
+	q, _ := url.ParseQuery(r.Query)
+	ms, _ := strconv.ParseInt(q.Get("timestamp"), 10, 64)
+	if ts := time.UnixMilli(ms); ts.Before(before.Truncate(time.Millisecond)) || ts.After(time.Now()) {
+		t.Errorf("timestamp %s, want the time of the request", q.Get("timestamp"))
+	}
+	want, _ := d.sign("http://x", time.UnixMilli(ms))
+	if wq, _ := url.Parse(want); q.Get("sign") != wq.Query().Get("sign") || q.Get("access_token") != "tok" {
+		t.Errorf("query %v", q)
+	}
+	body := `{"msgtype":"markdown","markdown":{"title":"[FIRING] critical prover_offline aleo1abc",
+		"text":"### [FIRING] critical prover_offline aleo1abc\n\nprover_offline aleo1abc: value 0 crossed threshold 0\n\n` + firing.Time.Format(time.RFC3339) + `"}}`
+	if !sameJSON(t, r.Body, body) {
+		t.Errorf("body %s", r.Body)
+	}
+}
+
+func TestDingTalkUnsignedAndFailed(t *testing.T) {
+	e := newEndpoint(t, 200, `{"errcode":310000,"errmsg":"sign not match"}`)
+	d := &DingTalk{WebhookURL: e.URL + "/robot/send?access_token=tok"}
+	err := d.Notify(context.Background(), firing)
+	if err == nil || !strings.Contains(err.Error(), "errcode 310000: sign not match") {
+		t.Errorf("error = %v, want the robot's errcode", err)
+	}
+	if q := e.Requests()[0].Query; q != "access_token=tok" {
+		t.Errorf("query %s, want it unsigned", q)
+	}
+}
@@ -0,0 +1,61 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	discordRed   = 0xE74C3C
+	discordGreen = 0x2ECC71
+)
+
+// Discord posts events to a webhook as embeds, red while firing and green
+// once resolved.
+type Discord struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields"`
+	Timestamp   string         `json:"timestamp"`
+}
+
+func (d *Discord) Notify(ctx context.Context, ev Event) error {
+	color := discordRed
+	if ev.State == Resolved {
+		color = discordGreen
+	}
+	embed := discordEmbed{
+		Title:       Title(ev),
+		Description: ev.Message,
+		Color:       color,
+		Fields: []discordField{
+			{Name: "Value", Value: strconv.FormatFloat(ev.Value, 'g', -1, 64), Inline: true},
+			{Name: "Threshold", Value: strconv.FormatFloat(ev.Threshold, 'g', -1, 64), Inline: true},
+		},
+		Timestamp: ev.Time.UTC().Format(time.RFC3339),
+	}
+	if ev.Addr != "" {
+		embed.Fields = append([]discordField{{Name: "Address", Value: ev.Addr}}, embed.Fields...)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"embeds": []discordEmbed{embed}})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, d.Client, d.WebhookURL, body)
+}
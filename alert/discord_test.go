@@ -0,0 +1,42 @@
+package alert
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiscordNotify(t *testing.T) {
+	resolved := firing
+	resolved.State = Resolved
+	resolved.Value = 12.5
+	fleet := firing
+	fleet.Addr = ""
+	tests := []struct {
+		ev   Event
+		want string
+	}{
+		{firing, `{"embeds":[{"title":"[FIRING] critical prover_offline aleo1abc","description":"prover_offline aleo1abc: value 0 crossed threshold 0","color":15158332,
+			"fields":[{"name":"Address","value":"aleo1abc","inline":false},{"name":"Value","value":"0","inline":true},{"name":"Threshold","value":"0","inline":true}],
+			"timestamp":"2026-10-14T12:00:00Z"}]}`},
+		{resolved, `{"embeds":[{"title":"[RESOLVED] critical prover_offline aleo1abc","description":"prover_offline aleo1abc: value 0 crossed threshold 0","color":3066993,
+			"fields":[{"name":"Address","value":"aleo1abc","inline":false},{"name":"Value","value":"12.5","inline":true},{"name":"Threshold","value":"0","inline":true}],
+			"timestamp":"2026-10-14T12:00:00Z"}]}`},
+		{fleet, `{"embeds":[{"title":"[FIRING] critical prover_offline","description":"prover_offline aleo1abc: value 0 crossed threshold 0","color":15158332,
+			"fields":[{"name":"Value","value":"0","inline":true},{"name":"Threshold","value":"0","inline":true}],
+			"timestamp":"2026-10-14T12:00:00Z"}]}`},
+	}
+	for _, tt := range tests {
+		e := newEndpoint(t, 204, "")
+		d := &Discord{WebhookURL: e.URL + "/api/webhooks/1/x"}
+		if err := d.Notify(context.Background(), tt.ev); err != nil {
+			t.Fatal(err)
+		}
+		r := e.Requests()[0]
+		if r.Path != "/api/webhooks/1/x" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s with %q", r.Path, r.Header.Get("Content-Type"))
+		}
+		if !sameJSON(t, r.Body, tt.want) {
+			t.Errorf("%s %s: body %s", tt.ev.State, tt.ev.Addr, r.Body)
+		}
+	}
+}
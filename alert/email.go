@@ -0,0 +1,109 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email sends events and daily summaries over SMTP. TLS is "tls" for
+// implicit TLS, "starttls" to require STARTTLS, "none" for plain text, and
+// otherwise implicit TLS on port 465 and STARTTLS when the server offers it.
+type Email struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+	TLS      string
+}
+
+func (e *Email) Notify(ctx context.Context, ev Event) error {
+	return e.send(ctx, Title(ev), Text(ev))
+}
+
+// Summary sends s as one report mail.
+func (e *Email) Summary(ctx context.Context, s Summary) error {
+	return e.send(ctx, s.title(), s.Text())
+}
+
+func (e *Email) send(ctx context.Context, subject string, text string) error {
+	host, port, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return err
+	}
+	implicit := e.TLS == "tls" || ((e.TLS == "" || e.TLS == "auto") && port == "465")
+	tlsConfig := &tls.Config{ServerName: host}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", e.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if implicit {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !implicit && e.TLS != "none" {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if e.TLS == "starttls" {
+			return fmt.Errorf("%s does not offer STARTTLS", e.Addr)
+		}
+	}
+	if e.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.message(subject, text)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e *Email) message(subject string, text string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return msg.Bytes()
+}
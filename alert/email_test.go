@@ -0,0 +1,176 @@
+package alert
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpServer is a fake SMTP server recording the commands and the message
+// of one session. It offers STARTTLS when starttls is set but can't
+// complete the handshake, it hangs up instead.
+type smtpServer struct {
+	addr     string
+	mu       sync.Mutex
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func newSMTPServer(t *testing.T, starttls bool) *smtpServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpServer{addr: ln.Addr().String(), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		s.serve(conn, starttls)
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn, starttls bool) {
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mu.Unlock()
+		switch strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]) {
+		case "EHLO":
+			reply("250-fake")
+			if starttls {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 go ahead")
+			return
+		case "AUTH":
+			reply("235 ok")
+		case "MAIL", "RCPT":
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown")
+		}
+	}
+}
+
+func (s *smtpServer) session() ([]string, string) {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands, s.data
+}
+
+func TestEmailPlain(t *testing.T) {
+	srv := newSMTPServer(t, false)
+	e := &Email{Addr: srv.addr, Username: "monitor", Password: "pw", From: "monitor@example.com", To: []string{"a@example.com", "b@example.com"}, TLS: "none"}
+	if err := e.Notify(context.Background(), firing); err != nil {
+		t.Fatal(err)
+	}
+
+	commands, data := srv.session()
+	want := []string{"EHLO localhost", "AUTH PLAIN AG1vbml0b3IAcHc=", "MAIL FROM:<monitor@example.com>", "RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>", "DATA", "QUIT"}
+	if len(commands) != len(want) {
+		t.Fatalf("commands %q, want %q", commands, want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(commands[i], want[i]) {
+			t.Fatalf("commands %q, want %q", commands, want)
+		}
+	}
+
+	header, body, ok := strings.Cut(data, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("message %q has no header", data)
+	}
+	for _, line := range []string{"From: monitor@example.com", "To: a@example.com, b@example.com", "Subject: [FIRING] critical prover_offline aleo1abc", "MIME-Version: 1.0", "Content-Type: text/plain; charset=utf-8"} {
+		if !strings.Contains(header+"\r\n", line+"\r\n") {
+			t.Errorf("header %q lacks %q", header, line)
+		}
+	}
+	if !strings.Contains(header, "\r\nDate: ") {
+		t.Errorf("header %q lacks the date", header)
+	}
+	wantBody := strings.ReplaceAll(Text(firing), "\n", "\r\n") + "\r\n"
+	if body != wantBody {
+		t.Errorf("body %q, want %q", body, wantBody)
+	}
+}
+
+func TestEmailStartTLS(t *testing.T) {
+	tests := []struct {
+		tls      string
+		offered  bool
+		commands []string
+		err      string
+	}{
+		{"starttls", true, []string{"EHLO localhost", "STARTTLS"}, ""},
+		{"auto", true, []string{"EHLO localhost", "STARTTLS"}, ""},
+		{"starttls", false, []string{"EHLO localhost"}, "does not offer STARTTLS"},
+		{"auto", false, []string{"EHLO localhost", "AUTH", "MAIL", "RCPT", "DATA", "QUIT"}, ""},
+	}
+	for _, tt := range tests {
+		srv := newSMTPServer(t, tt.offered)
+		e := &Email{Addr: srv.addr, Username: "monitor", Password: "pw", From: "monitor@example.com", To: []string{"a@example.com"}, TLS: tt.tls}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := e.Notify(ctx, firing)
+		cancel()
+
+		commands, _ := srv.session()
+		if len(commands) != len(tt.commands) {
+			t.Errorf("%s, offered %v: commands %q, want %q", tt.tls, tt.offered, commands, tt.commands)
+			continue
+		}
+		for i := range tt.commands {
+			if !strings.HasPrefix(commands[i], tt.commands[i]) {
+				t.Errorf("%s, offered %v: commands %q, want %q", tt.tls, tt.offered, commands, tt.commands)
+				break
+			}
+		}
+		switch {
+		case tt.offered && err == nil:
+			t.Errorf("%s: sent without completing the TLS handshake", tt.tls)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s, offered %v: error = %v, want %q", tt.tls, tt.offered, err, tt.err)
+		case !tt.offered && tt.err == "" && err != nil:
+			t.Errorf("%s, offered %v: %v", tt.tls, tt.offered, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package alert
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feishu sends events and daily summaries to a Feishu (Lark) custom bot
+// webhook as interactive cards, signed with Secret when the bot has
+// signature verification enabled.
+type Feishu struct {
+	WebhookURL string
+	Secret     string
+	Client     *http.Client
+}
+
+// feishuResult is the answer of the bot, errors come back with status 200.
+type feishuResult struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (f *Feishu) Notify(ctx context.Context, ev Event) error {
+	color := "red"
+	if ev.State == Resolved {
+		color = "green"
+	}
+	text := fmt.Sprintf("%s\n**Severity:** %s\n**Time:** %s", ev.Message, ev.Severity, ev.Time.Format(time.RFC3339))
+	return f.send(ctx, Title(ev), color, text)
+}
+
+// Summary sends s as one card.
+func (f *Feishu) Summary(ctx context.Context, s Summary) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Period:** %s - %s\n", s.From.Format(time.RFC3339), s.To.Format(time.RFC3339))
+	fmt.Fprintf(&b, "**Addresses:** %d\n", s.Addresses)
+	fmt.Fprintf(&b, "**Average fleet speed:** %.2f\n", s.Speed)
+	fmt.Fprintf(&b, "**Reward earned:** %.6f\n", s.Reward)
+	fmt.Fprintf(&b, "**Alerts:** %d fired, %d resolved", s.Fired, s.Resolved)
+
+	active := s.sortedActive()
+	if len(active) > 0 {
+		fmt.Fprintf(&b, "\n\n**Still firing:**")
+		for _, ev := range active {
+			fmt.Fprintf(&b, "\n- %s since %s", Title(ev), ev.Time.Format(time.RFC3339))
+		}
+	}
+	if len(s.PerAddress) > 0 {
+		fmt.Fprintf(&b, "\n\n**Per address:**")
+		for _, a := range s.PerAddress {
+			fmt.Fprintf(&b, "\n- %s: speed %.2f, reward %.6f, down %.0f min", a.Addr, a.Speed, a.Reward, a.Downtime)
+		}
+	}
+
+	color := "blue"
+	if len(active) > 0 {
+		color = "orange"
+	}
+	return f.send(ctx, s.title(), color, b.String())
+}
+
+func (f *Feishu) send(ctx context.Context, title, color, text string) error {
+	msg := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]bool{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"title":    map[string]string{"tag": "plain_text", "content": title},
+				"template": color,
+			},
+			"elements": []interface{}{
+				map[string]interface{}{
+					"tag":  "div",
+					"text": map[string]string{"tag": "lark_md", "content": text},
+				},
+			},
+		},
+	}
+	if f.Secret != "" {
+		timestamp, sign := f.sign(time.Now())
+		msg["timestamp"] = timestamp
+		msg["sign"] = sign
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	var result feishuResult
+	if err := postJSONDecode(ctx, f.Client, f.WebhookURL, body, &result); err != nil {
+		return err
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("code %d: %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// sign computes the signature of the bot security settings: base64
+// HMAC-SHA256 of an empty message keyed with "timestamp\nsecret".
+func (f *Feishu) sign(now time.Time) (string, string) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+f.Secret))
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,98 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeishuSign(t *testing.T) {
+	f := &Feishu{Secret: "secretXYZ"}
+	timestamp, sign := f.sign(time.Unix(1700000000, 0))
+	if timestamp != "1700000000" || sign != "R9uYs+HWf4URfBhlsUEBZZFt0t1u1WPyNwyZGdq3SkY=" {
+		t.Errorf("sign = %s, %s", timestamp, sign)
+	}
+}
+
+func card(title, color, text string) string {
+	msg := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]bool{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"title":    map[string]string{"tag": "plain_text", "content": title},
+				"template": color,
+			},
+			"elements": []interface{}{map[string]interface{}{"tag": "div", "text": map[string]string{"tag": "lark_md", "content": text}}},
+		},
+	}
+	data, _ := json.Marshal(msg)
+	return string(data)
+}
+
+func TestFeishuNotify(t *testing.T) {
+	e := newEndpoint(t, 200, `{"code":0,"msg":"success"}`)
+	f := &Feishu{WebhookURL: e.URL + "/open-apis/bot/v2/hook/abc", Secret: "secretXYZ"}
+	before := time.Now().Unix()
+	if err := f.Notify(context.Background(), firing); err != nil {
+		t.Fatal(err)
+	}
+
+	r := e.Requests()[0]
+	if r.Path != "/open-apis/bot/v2/hook/abc" || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("%s with %q", r.Path, r.Header.Get("Content-Type"))
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(r.Body), &msg); err != nil {
+		t.Fatal(err)
+	}
+	timestamp, _ := msg["timestamp"].(string)
+	ts, _ := strconv.ParseInt(timestamp, 10, 64)
+	if ts < before || ts > time.Now().Unix() {
+		t.Errorf("timestamp %q, want the time of the request", timestamp)
+	}
+	if _, want := f.sign(time.Unix(ts, 0)); msg["sign"] != want {
+		t.Errorf("sign %v, want %s", msg["sign"], want)
+	}
+	delete(msg, "timestamp")
+	delete(msg, "sign")
+	unsigned, _ := json.Marshal(msg)
+	text := firing.Message + "\n**Severity:** critical\n**Time:** " + firing.Time.Format(time.RFC3339)
+	if !sameJSON(t, string(unsigned), card("[FIRING] critical prover_offline aleo1abc", "red", text)) {
+		t.Errorf("body %s", r.Body)
+	}
+}
+
+func TestFeishuSummary(t *testing.T) {
+	e := newEndpoint(t, 200, `{"code":0}`)
+	f := &Feishu{WebhookURL: e.URL}
+	to := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	s := Summary{From: to.Add(-24 * time.Hour), To: to, Addresses: 2, Speed: 12.5, Reward: 1.25, Fired: 3, Resolved: 2,
+		Active:     []Event{firing},
+		PerAddress: []AddressSummary{{Addr: "aleo1abc", Speed: 10, Reward: 1, Downtime: 30}}}
+	if err := f.Summary(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+
+	r := e.Requests()[0]
+	if strings.Contains(r.Body, `"sign"`) {
+		t.Errorf("unsigned bot got a signature: %s", r.Body)
+	}
+	text := "**Period:** 2026-10-13T00:00:00Z - 2026-10-14T00:00:00Z\n**Addresses:** 2\n**Average fleet speed:** 12.50\n**Reward earned:** 1.250000\n" +
+		"**Alerts:** 3 fired, 2 resolved\n\n**Still firing:**\n- [FIRING] critical prover_offline aleo1abc since " + firing.Time.Format(time.RFC3339) +
+		"\n\n**Per address:**\n- aleo1abc: speed 10.00, reward 1.000000, down 30 min"
+	if !sameJSON(t, r.Body, card("Daily summary 2026-10-14", "orange", text)) {
+		t.Errorf("body %s", r.Body)
+	}
+}
+
+func TestFeishuError(t *testing.T) {
+	e := newEndpoint(t, 200, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`)
+	f := &Feishu{WebhookURL: e.URL, Secret: "wrong"}
+	if err := f.Notify(context.Background(), firing); err == nil || !strings.Contains(err.Error(), "code 19021") {
+		t.Errorf("error = %v, want the bot's code", err)
+	}
+}
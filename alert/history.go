@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+)
+
+// History is a ring buffer of the last alert events, optionally persisted to
+// a JSON file so it survives restarts.
+type History struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	full   bool
+	path   string
+}
+
+func NewHistory(size int, path string) (*History, error) {
+	if size <= 0 {
+		size = 1
+	}
+	h := &History{events: make([]Event, size), path: path}
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved []Event
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	for _, ev := range saved {
+		h.add(ev)
+	}
+	return h, nil
+}
+
+func (h *History) Add(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.add(ev)
+	if h.path != "" {
+		if err := h.save(); err != nil {
+			log.Printf("save alert history %s failed:%s", h.path, err)
+		}
+	}
+}
+
+func (h *History) add(ev Event) {
+	h.events[h.next] = ev
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Events returns the buffered events, oldest first.
+func (h *History) Events() []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.list()
+}
+
+func (h *History) list() []Event {
+	if !h.full {
+		return append([]Event(nil), h.events[:h.next]...)
+	}
+	return append(append([]Event(nil), h.events[h.next:]...), h.events[:h.next]...)
+}
+
+func (h *History) save() error {
+	data, err := json.Marshal(h.list())
+	if err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
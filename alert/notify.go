@@ -0,0 +1,145 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Notifier delivers an event to an external service.
+type Notifier interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+// Dispatcher hands events to notifiers in order on its own goroutine, so a
+// slow or unreachable service doesn't hold up the cycle.
+type Dispatcher struct {
+	// ReportTo, if set, limits the notifiers Report reaches.
+	ReportTo  map[string]bool
+	notifiers map[string]Notifier
+	timeout   time.Duration
+	events    chan Event
+}
+
+func NewDispatcher(timeout time.Duration) *Dispatcher {
+	d := &Dispatcher{
+		notifiers: make(map[string]Notifier),
+		timeout:   timeout,
+		events:    make(chan Event, 256),
+	}
+	go d.run()
+	return d
+}
+
+// Add registers n under name, used in logs. Add all notifiers before the
+// first event.
+func (d *Dispatcher) Add(name string, n Notifier) {
+	d.notifiers[name] = n
+}
+
+func (d *Dispatcher) Has(name string) bool {
+	_, ok := d.notifiers[name]
+	return ok
+}
+
+func (d *Dispatcher) Len() int {
+	return len(d.notifiers)
+}
+
+// Notify queues ev, dropping it when the queue is full.
+func (d *Dispatcher) Notify(ev Event) {
+	select {
+	case d.events <- ev:
+	default:
+		log.Printf("notification queue full, dropped %s %s", ev.Rule, ev.State)
+	}
+}
+
+// Report queues s for every notifier in ReportTo, notifiers implementing
+// Reporter send it in their own layout.
+func (d *Dispatcher) Report(ctx context.Context, s Summary) error {
+	select {
+	case d.events <- reportEvent(s):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run() {
+	for ev := range d.events {
+		for name, n := range d.notifiers {
+			if ev.State == Report && len(d.ReportTo) > 0 && !d.ReportTo[name] {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+			var err error
+			if r, ok := n.(Reporter); ok && ev.State == Report {
+				err = r.Summary(ctx, *ev.Summary)
+			} else {
+				err = n.Notify(ctx, ev)
+			}
+			if err != nil {
+				log.Printf("notify %s failed:%s", name, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Title is a one-line summary of ev for chat messages.
+func Title(ev Event) string {
+	var parts []string
+	parts = append(parts, "["+strings.ToUpper(string(ev.State))+"]")
+	if ev.Severity != "" {
+		parts = append(parts, ev.Severity)
+	}
+	parts = append(parts, ev.Rule)
+	if ev.Addr != "" {
+		parts = append(parts, ev.Addr)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Text is the full plain text of ev for chat messages.
+func Text(ev Event) string {
+	return fmt.Sprintf("%s\n%s\n%s", Title(ev), ev.Message, ev.Time.Format(time.RFC3339))
+}
+
+// postJSON posts body and treats any non-2xx answer as an error.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) error {
+	return postJSONDecode(ctx, client, url, body, nil)
+}
+
+// postJSONDecode is postJSON decoding the answer into out, for services that
+// report errors in the body of a 200 answer.
+func postJSONDecode(ctx context.Context, client *http.Client, url string, body []byte, out interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,75 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   string
+}
+
+// endpoint records the requests it gets and answers them with status and
+// reply.
+type endpoint struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []request
+}
+
+func newEndpoint(t *testing.T, status int, reply string) *endpoint {
+	e := &endpoint{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		e.mu.Lock()
+		e.requests = append(e.requests, request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Header: r.Header, Body: string(body)})
+		e.mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+func (e *endpoint) Requests() []request {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]request{}, e.requests...)
+}
+
+// firing is the event the notifier tests send.
+var firing = Event{
+	Rule:      "prover_offline",
+	Addr:      "aleo1abc",
+	State:     Firing,
+	Severity:  "critical",
+	Value:     0,
+	Threshold: 0,
+	Message:   "prover_offline aleo1abc: value 0 crossed threshold 0",
+	Time:      time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+}
+
+var report = Event{Rule: "daily_digest", State: Report, Severity: "info", Summary: &Summary{}}
+
+// sameJSON reports whether got and want are the same JSON document.
+func sameJSON(t *testing.T, got, want string) bool {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("body %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(g, w)
+}
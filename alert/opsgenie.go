@@ -0,0 +1,124 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const opsgenieAPIURL = "https://api.opsgenie.com"
+
+// Opsgenie creates an alert through the Alert API when a rule fires and
+// closes it again on recovery, deduplicated by rule and address through the
+// alias.
+type Opsgenie struct {
+	APIKey string
+	// Source names the monitor in the alert, e.g. the instance.
+	Source string
+	// Severities limits the events sent, empty sends every event.
+	Severities map[string]bool
+	Client     *http.Client
+	// URL overrides the API base URL, e.g. https://api.eu.opsgenie.com.
+	URL string
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note"`
+}
+
+func (o *Opsgenie) Notify(ctx context.Context, ev Event) error {
+	if ev.State == Report || len(o.Severities) > 0 && !o.Severities[ev.Severity] {
+		return nil
+	}
+
+	alias := ev.Rule + "/" + ev.Addr
+	if ev.State == Resolved {
+		path := "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		return o.post(ctx, path, opsgenieClose{Source: o.Source, Note: ev.Message})
+	}
+
+	message := Title(ev)
+	if r := []rune(message); len(r) > 130 {
+		message = string(r[:130])
+	}
+	details := map[string]string{
+		"rule":      ev.Rule,
+		"severity":  ev.Severity,
+		"value":     strconv.FormatFloat(ev.Value, 'f', -1, 64),
+		"threshold": strconv.FormatFloat(ev.Threshold, 'f', -1, 64),
+	}
+	if ev.Addr != "" {
+		details["addr"] = ev.Addr
+	}
+	return o.post(ctx, "/v2/alerts", opsgenieAlert{
+		Message:     message,
+		Alias:       alias,
+		Description: ev.Message,
+		Priority:    opsgeniePriority(ev.Severity),
+		Source:      o.Source,
+		Tags:        []string{"aleo-prover-monitor", ev.Rule},
+		Details:     details,
+	})
+}
+
+func (o *Opsgenie) post(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	base := o.URL
+	if base == "" {
+		base = opsgenieAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(base, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.APIKey)
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// opsgeniePriority maps a rule severity onto the P1 to P5 priorities.
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case "critical":
+		return "P1"
+	case "warning":
+		return "P3"
+	case "info":
+		return "P5"
+	}
+	return "P3"
+}
@@ -0,0 +1,73 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestOpsgenieNotify(t *testing.T) {
+	e := newEndpoint(t, 202, `{"result":"Request will be processed"}`)
+	o := &Opsgenie{APIKey: "k3y", Source: "vm1", URL: e.URL + "/"}
+	if err := o.Notify(context.Background(), firing); err != nil {
+		t.Fatal(err)
+	}
+	resolved := firing
+	resolved.State = Resolved
+	resolved.Message = "prover_offline aleo1abc: recovered to 12"
+	if err := o.Notify(context.Background(), resolved); err != nil {
+		t.Fatal(err)
+	}
+	o.Notify(context.Background(), report)
+
+	requests := e.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want create and close only", len(requests))
+	}
+	for _, r := range requests {
+		if r.Header.Get("Authorization") != "GenieKey k3y" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("headers %v", r.Header)
+		}
+	}
+	create := requests[0]
+	if create.Path != "/v2/alerts" || create.Query != "" {
+		t.Errorf("create %s?%s", create.Path, create.Query)
+	}
+	want := `{"message":"[FIRING] critical prover_offline aleo1abc","alias":"prover_offline/aleo1abc",
+		"description":"prover_offline aleo1abc: value 0 crossed threshold 0","priority":"P1","source":"vm1",
+		"tags":["aleo-prover-monitor","prover_offline"],
+		"details":{"rule":"prover_offline","severity":"critical","value":"0","threshold":"0","addr":"aleo1abc"}}`
+	if !sameJSON(t, create.Body, want) {
+		t.Errorf("create %s", create.Body)
+	}
+	closed := requests[1]
+	if closed.Path != "/v2/alerts/prover_offline/aleo1abc/close" || closed.Query != "identifierType=alias" {
+		t.Errorf("close %s?%s", closed.Path, closed.Query)
+	}
+	if !sameJSON(t, closed.Body, `{"source":"vm1","note":"prover_offline aleo1abc: recovered to 12"}`) {
+		t.Errorf("close %s", closed.Body)
+	}
+}
+
+func TestOpsgeniePriorities(t *testing.T) {
+	e := newEndpoint(t, 202, "")
+	o := &Opsgenie{APIKey: "k3y", URL: e.URL, Severities: map[string]bool{"warning": true, "info": true}}
+	for _, severity := range []string{"critical", "warning", "info"} {
+		ev := firing
+		ev.Severity = severity
+		o.Notify(context.Background(), ev)
+	}
+	requests := e.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want the listed severities only", len(requests))
+	}
+	for i, priority := range []string{"P3", "P5"} {
+		var alert opsgenieAlert
+		if err := json.Unmarshal([]byte(requests[i].Body), &alert); err != nil {
+			t.Fatal(err)
+		}
+		if alert.Priority != priority {
+			t.Errorf("alert %s, want priority %s", requests[i].Body, priority)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package alert
+
+import "fmt"
+
+// Override replaces the thresholds of Rule for the listed Addresses or for
+// addresses carrying all Labels, e.g. a rack of slower machines. The first
+// matching override of a rule wins.
+type Override struct {
+	Rule      string            `json:"rule" yaml:"rule"`
+	Addresses []string          `json:"addresses,omitempty" yaml:"addresses,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Threshold float64           `json:"threshold" yaml:"threshold"`
+	Clear     *float64          `json:"clear,omitempty" yaml:"clear,omitempty"`
+}
+
+// SetOverrides replaces the per-address overrides, labels, if set, returns
+// the labels of an address they may match on.
+func (e *Engine) SetOverrides(overrides []Override, labels func(addr string) map[string]string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, o := range overrides {
+		if _, ok := e.rules[o.Rule]; !ok {
+			return fmt.Errorf("override of unknown or disabled rule %q", o.Rule)
+		}
+		if len(o.Addresses) == 0 && len(o.Labels) == 0 {
+			return fmt.Errorf("override of %s needs addresses or labels", o.Rule)
+		}
+	}
+	e.overrides, e.labels = overrides, labels
+	return nil
+}
+
+// ruleFor returns r with the thresholds of the first override matching addr.
+func (e *Engine) ruleFor(r Rule, addr string) Rule {
+	if addr == "" || len(e.overrides) == 0 {
+		return r
+	}
+	var labels map[string]string
+	if e.labels != nil {
+		labels = e.labels(addr)
+	}
+	for _, o := range e.overrides {
+		if o.Rule == r.Name && matchAddr(o.Addresses, o.Labels, addr, labels) {
+			r.Threshold, r.Clear = o.Threshold, o.Clear
+			return r
+		}
+	}
+	return r
+}
+
+// matchAddr reports whether addr is one of addresses or carries all of want.
+func matchAddr(addresses []string, want map[string]string, addr string, labels map[string]string) bool {
+	if contains(addresses, addr) {
+		return true
+	}
+	if len(want) == 0 {
+		return false
+	}
+	for name, value := range want {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverrides(t *testing.T) {
+	slow := Rule{Name: "slow", Severity: "warning", Threshold: 40, Below: true}
+	clear := 25.0
+	inventory := map[string]map[string]string{
+		"aleo2": {"rack": "b2"},
+		"aleo3": {"rack": "b2", "gpu": "3090"},
+	}
+	e := NewEngine(nil, slow)
+	err := e.SetOverrides([]Override{
+		{Rule: "slow", Addresses: []string{"aleo1"}, Threshold: 10},
+		{Rule: "slow", Labels: map[string]string{"rack": "b2", "gpu": "3090"}, Threshold: 20, Clear: &clear},
+		{Rule: "slow", Labels: map[string]string{"rack": "b2"}, Threshold: 30},
+	}, func(addr string) map[string]string { return inventory[addr] })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		addr      string
+		threshold float64
+		clear     *float64
+	}{
+		{"aleo1", 10, nil},
+		{"aleo2", 30, nil},
+		{"aleo3", 20, &clear},
+		{"aleo4", 40, nil},
+		{"", 40, nil},
+	}
+	for _, tt := range tests {
+		r := e.ruleFor(slow, tt.addr)
+		if r.Threshold != tt.threshold || r.Clear != tt.clear {
+			t.Errorf("%q: threshold %g clear %v, want %g %v", tt.addr, r.Threshold, r.Clear, tt.threshold, tt.clear)
+		}
+	}
+
+	at := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	if _, fired := e.Evaluate("slow", "aleo1", 15, at); fired {
+		t.Error("aleo1 fired above its override")
+	}
+	if ev, fired := e.Evaluate("slow", "aleo4", 15, at); !fired || ev.Threshold != 40 {
+		t.Errorf("aleo4 = %+v, %v, want firing at the rule threshold", ev, fired)
+	}
+	if ev, fired := e.Evaluate("slow", "aleo3", 15, at); !fired || ev.Threshold != 20 {
+		t.Errorf("aleo3 = %+v, %v, want firing at its override", ev, fired)
+	}
+	if _, changed := e.Evaluate("slow", "aleo3", 22, at.Add(time.Minute)); changed {
+		t.Error("aleo3 resolved before its override Clear")
+	}
+}
+
+func TestSetOverridesRejects(t *testing.T) {
+	e := NewEngine(nil, Rule{Name: "slow", Threshold: 40, Below: true})
+	for _, overrides := range [][]Override{
+		{{Rule: "fast", Addresses: []string{"aleo1"}, Threshold: 1}},
+		{{Rule: "slow", Threshold: 1}},
+	} {
+		if err := e.SetOverrides(overrides, nil); err == nil {
+			t.Errorf("%+v accepted", overrides)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty opens an incident through the Events API v2 when an alert fires
+// and resolves it again on recovery, deduplicated by rule and address.
+type PagerDuty struct {
+	RoutingKey string
+	// Source names the monitor in the incident, e.g. the instance.
+	Source string
+	// Severities limits the events sent, empty sends every event.
+	Severities map[string]bool
+	Client     *http.Client
+	// URL overrides the Events API endpoint.
+	URL string
+}
+
+type pagerDutyPayload struct {
+	Summary       string      `json:"summary"`
+	Source        string      `json:"source"`
+	Severity      string      `json:"severity"`
+	Timestamp     string      `json:"timestamp"`
+	CustomDetails interface{} `json:"custom_details"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+func (p *PagerDuty) Notify(ctx context.Context, ev Event) error {
+	if ev.State == Report || len(p.Severities) > 0 && !p.Severities[ev.Severity] {
+		return nil
+	}
+
+	event := pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "resolve",
+		DedupKey:    ev.Rule + "/" + ev.Addr,
+	}
+	if ev.State == Firing {
+		event.EventAction = "trigger"
+		event.Payload = &pagerDutyPayload{
+			Summary:       ev.Message,
+			Source:        p.Source,
+			Severity:      pagerDutySeverity(ev.Severity),
+			Timestamp:     ev.Time.UTC().Format("2006-01-02T15:04:05Z"),
+			CustomDetails: ev,
+		}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	url := p.URL
+	if url == "" {
+		url = pagerDutyEventsURL
+	}
+	return postJSON(ctx, p.Client, url, body)
+}
+
+// pagerDutySeverity maps a rule severity onto the ones PagerDuty accepts.
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case "critical", "error", "warning", "info":
+		return severity
+	}
+	return "error"
+}
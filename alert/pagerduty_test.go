@@ -0,0 +1,65 @@
+package alert
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPagerDutyNotify(t *testing.T) {
+	e := newEndpoint(t, 202, `{"status":"success"}`)
+	p := &PagerDuty{RoutingKey: "R0UT1NG", Source: "vm1", URL: e.URL + "/v2/enqueue"}
+
+	if err := p.Notify(context.Background(), firing); err != nil {
+		t.Fatal(err)
+	}
+	resolved := firing
+	resolved.State = Resolved
+	if err := p.Notify(context.Background(), resolved); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Notify(context.Background(), report); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := e.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want trigger and resolve only", len(requests))
+	}
+	if r := requests[0]; r.Path != "/v2/enqueue" || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("%s with %q", r.Path, r.Header.Get("Content-Type"))
+	}
+	trigger := `{"routing_key":"R0UT1NG","event_action":"trigger","dedup_key":"prover_offline/aleo1abc","payload":{
+		"summary":"prover_offline aleo1abc: value 0 crossed threshold 0","source":"vm1","severity":"critical","timestamp":"2026-10-14T12:00:00Z",
+		"custom_details":{"rule":"prover_offline","addr":"aleo1abc","state":"firing","severity":"critical","value":0,"threshold":0,
+			"message":"prover_offline aleo1abc: value 0 crossed threshold 0","time":"2026-10-14T12:00:00Z"}}}`
+	if !sameJSON(t, requests[0].Body, trigger) {
+		t.Errorf("trigger %s", requests[0].Body)
+	}
+	resolve := `{"routing_key":"R0UT1NG","event_action":"resolve","dedup_key":"prover_offline/aleo1abc"}`
+	if !sameJSON(t, requests[1].Body, resolve) {
+		t.Errorf("resolve %s", requests[1].Body)
+	}
+}
+
+func TestPagerDutySeverities(t *testing.T) {
+	e := newEndpoint(t, 202, "")
+	p := &PagerDuty{RoutingKey: "R0UT1NG", URL: e.URL, Severities: map[string]bool{"critical": true, "page": true}}
+	warning := firing
+	warning.Severity = "warning"
+	page := firing
+	page.Severity = "page"
+	p.Notify(context.Background(), warning)
+	p.Notify(context.Background(), page)
+
+	requests := e.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("%d requests, want the listed severity only", len(requests))
+	}
+	want := `{"routing_key":"R0UT1NG","event_action":"trigger","dedup_key":"prover_offline/aleo1abc","payload":{
+		"summary":"prover_offline aleo1abc: value 0 crossed threshold 0","source":"","severity":"error","timestamp":"2026-10-14T12:00:00Z",
+		"custom_details":{"rule":"prover_offline","addr":"aleo1abc","state":"firing","severity":"page","value":0,"threshold":0,
+			"message":"prover_offline aleo1abc: value 0 crossed threshold 0","time":"2026-10-14T12:00:00Z"}}}`
+	if !sameJSON(t, requests[0].Body, want) {
+		t.Errorf("body %s, want an unknown severity sent as error", requests[0].Body)
+	}
+}
@@ -0,0 +1,99 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QuietHours is a daily window of local time, wrapping past midnight when To
+// is earlier than From, in which a channel only delivers alerts of severity
+// Deliver or higher. An empty Deliver means critical.
+type QuietHours struct {
+	From    string `yaml:"from"`
+	To      string `yaml:"to"`
+	Deliver string `yaml:"deliver,omitempty"`
+}
+
+func (q QuietHours) Validate() error {
+	for _, s := range []string{q.From, q.To} {
+		if _, err := time.Parse("15:04", s); err != nil {
+			return fmt.Errorf("quiet hours: want HH:MM, got %q", s)
+		}
+	}
+	if _, ok := severityRank[q.deliver()]; !ok {
+		return fmt.Errorf("quiet hours: unknown severity %q", q.Deliver)
+	}
+	return nil
+}
+
+func (q QuietHours) deliver() string {
+	if q.Deliver == "" {
+		return "critical"
+	}
+	return q.Deliver
+}
+
+// Active reports whether at falls inside the window.
+func (q QuietHours) Active(at time.Time) bool {
+	from, err1 := time.Parse("15:04", q.From)
+	to, err2 := time.Parse("15:04", q.To)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	now := at.Hour()*60 + at.Minute()
+	start := from.Hour()*60 + from.Minute()
+	end := to.Hour()*60 + to.Minute()
+	if start <= end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// Quiet drops the events of Notifier below the Hours severity while the
+// window is active. The resolve of a dropped firing is dropped too, whenever
+// it comes, so the channel never sees a recovery it wasn't told about, every
+// other resolve is delivered. It is used from the dispatcher goroutine only.
+type Quiet struct {
+	Notifier
+	Hours QuietHours
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	dropped map[string]bool
+}
+
+// Summary passes reports through, they are scheduled on purpose.
+func (q *Quiet) Summary(ctx context.Context, s Summary) error {
+	if r, ok := q.Notifier.(Reporter); ok {
+		return r.Summary(ctx, s)
+	}
+	return q.Notifier.Notify(ctx, reportEvent(s))
+}
+
+func (q *Quiet) Notify(ctx context.Context, ev Event) error {
+	key := ev.Rule + "/" + ev.Addr
+	if ev.State == Resolved {
+		if q.dropped[key] {
+			delete(q.dropped, key)
+			return nil
+		}
+		return q.Notifier.Notify(ctx, ev)
+	}
+
+	now := time.Now
+	if q.Now != nil {
+		now = q.Now
+	}
+	if q.Hours.Active(now()) && severityRank[ev.Severity] < severityRank[q.Hours.deliver()] {
+		if ev.State == Firing {
+			if q.dropped == nil {
+				q.dropped = make(map[string]bool)
+			}
+			q.dropped[key] = true
+		}
+		return nil
+	}
+	delete(q.dropped, key)
+	return q.Notifier.Notify(ctx, ev)
+}
@@ -0,0 +1,107 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func at(hhmm string) time.Time {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(2026, 10, 14, t.Hour(), t.Minute(), 0, 0, time.Local)
+}
+
+func TestQuietHoursActive(t *testing.T) {
+	tests := []struct {
+		from, to string
+		at       string
+		want     bool
+	}{
+		{"22:00", "07:00", "21:59", false},
+		{"22:00", "07:00", "22:00", true},
+		{"22:00", "07:00", "23:59", true},
+		{"22:00", "07:00", "00:00", true},
+		{"22:00", "07:00", "06:59", true},
+		{"22:00", "07:00", "07:00", false},
+		{"22:00", "07:00", "12:00", false},
+		{"09:00", "17:00", "08:59", false},
+		{"09:00", "17:00", "09:00", true},
+		{"09:00", "17:00", "16:59", true},
+		{"09:00", "17:00", "17:00", false},
+		{"09:00", "17:00", "23:00", false},
+		{"bad", "07:00", "23:00", false},
+	}
+	for _, tt := range tests {
+		q := QuietHours{From: tt.from, To: tt.to}
+		if got := q.Active(at(tt.at)); got != tt.want {
+			t.Errorf("%s-%s at %s: Active = %v, want %v", tt.from, tt.to, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestQuietHoursValidate(t *testing.T) {
+	if err := (QuietHours{From: "22:00", To: "07:00", Deliver: "warning"}).Validate(); err != nil {
+		t.Errorf("valid quiet hours rejected: %v", err)
+	}
+	for _, q := range []QuietHours{
+		{From: "22", To: "07:00"},
+		{From: "22:00", To: "25:00"},
+		{From: "22:00", To: "07:00", Deliver: "loud"},
+	} {
+		if err := q.Validate(); err == nil {
+			t.Errorf("%+v accepted", q)
+		}
+	}
+}
+
+type recorder struct{ events []Event }
+
+func (r *recorder) Notify(ctx context.Context, ev Event) error {
+	r.events = append(r.events, ev)
+	return nil
+}
+
+func TestQuietNotify(t *testing.T) {
+	rec := &recorder{}
+	now := at("21:00")
+	q := &Quiet{Notifier: rec, Hours: QuietHours{From: "22:00", To: "07:00"}, Now: func() time.Time { return now }}
+	notify := func(rule string, state State, severity string) {
+		if err := q.Notify(context.Background(), Event{Rule: rule, Addr: "aleo1", State: state, Severity: severity}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// delivered before the window, resolved inside it
+	notify("offline", Firing, "warning")
+	now = at("23:00")
+	notify("offline", Resolved, "warning")
+
+	// fired and resolved inside the window
+	notify("slow", Firing, "warning")
+	notify("slow", Resolved, "warning")
+
+	// critical goes through
+	notify("down", Firing, "critical")
+
+	// dropped inside the window, resolved after it
+	notify("lag", Firing, "info")
+	now = at("08:00")
+	notify("lag", Resolved, "info")
+
+	var got []string
+	for _, ev := range rec.events {
+		got = append(got, ev.Rule+"/"+string(ev.State))
+	}
+	want := []string{"offline/firing", "offline/resolved", "down/firing"}
+	if len(got) != len(want) {
+		t.Fatalf("delivered %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("delivered %v, want %v", got, want)
+		}
+	}
+}
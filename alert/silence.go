@@ -0,0 +1,233 @@
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Silence holds back the notifications of matching alerts between Start and
+// End, repeated every Every when set, e.g. 168h for a weekly window. It
+// matches alerts of the listed Addresses or of addresses carrying all Labels,
+// every alert when neither is set, and only the listed Rules when set.
+type Silence struct {
+	ID        string            `json:"id" yaml:"id"`
+	Addresses []string          `json:"addresses,omitempty" yaml:"addresses,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Rules     []string          `json:"rules,omitempty" yaml:"rules,omitempty"`
+	Start     time.Time         `json:"start" yaml:"start"`
+	End       time.Time         `json:"end" yaml:"end"`
+	Every     time.Duration     `json:"-" yaml:"every,omitempty"`
+	Comment   string            `json:"comment,omitempty" yaml:"comment,omitempty"`
+
+	static bool
+}
+
+type silenceJSON struct {
+	silenceAlias
+	Every string `json:"every,omitempty"`
+}
+
+type silenceAlias Silence
+
+// MarshalJSON writes Every as a duration string like the YAML config.
+func (s Silence) MarshalJSON() ([]byte, error) {
+	v := silenceJSON{silenceAlias: silenceAlias(s)}
+	if s.Every > 0 {
+		v.Every = s.Every.String()
+	}
+	return json.Marshal(v)
+}
+
+func (s *Silence) UnmarshalJSON(data []byte) error {
+	var v silenceJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = Silence(v.silenceAlias)
+	if v.Every != "" {
+		every, err := time.ParseDuration(v.Every)
+		if err != nil {
+			return fmt.Errorf("every: %v", err)
+		}
+		s.Every = every
+	}
+	return nil
+}
+
+func (s Silence) validate() error {
+	if !s.End.After(s.Start) {
+		return fmt.Errorf("silence %s: end must be after start", s.ID)
+	}
+	if s.Every > 0 && s.Every < s.End.Sub(s.Start) {
+		return fmt.Errorf("silence %s: every must not be shorter than the window", s.ID)
+	}
+	return nil
+}
+
+// Active reports whether at falls within one of the windows of s.
+func (s Silence) Active(at time.Time) bool {
+	if at.Before(s.Start) {
+		return false
+	}
+	if s.Every > 0 {
+		return at.Sub(s.Start)%s.Every < s.End.Sub(s.Start)
+	}
+	return at.Before(s.End)
+}
+
+func (s Silence) matches(ev Event, labels map[string]string) bool {
+	if len(s.Rules) > 0 && !contains(s.Rules, ev.Rule) {
+		return false
+	}
+	if len(s.Addresses) == 0 && len(s.Labels) == 0 {
+		return true
+	}
+	return ev.Addr != "" && matchAddr(s.Addresses, s.Labels, ev.Addr, labels)
+}
+
+// Silences is the set of silences from the config and the ones added at
+// runtime, the latter optionally persisted to a JSON file.
+type Silences struct {
+	// Labels, if set, returns the labels of an address that silences may
+	// match on, e.g. from an inventory.
+	Labels func(addr string) map[string]string
+
+	mu       sync.Mutex
+	silences []Silence
+	path     string
+}
+
+func NewSilences(static []Silence, path string) (*Silences, error) {
+	s := &Silences{path: path}
+	for _, sil := range static {
+		if sil.ID == "" {
+			return nil, errors.New("silences from the config need an id")
+		}
+		if err := sil.validate(); err != nil {
+			return nil, err
+		}
+		sil.static = true
+		s.silences = append(s.silences, sil)
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved []Silence
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	s.silences = append(s.silences, saved...)
+	return s, nil
+}
+
+// Add adds sil, generating its ID when empty, and returns it.
+func (s *Silences) Add(sil Silence) (Silence, error) {
+	if err := sil.validate(); err != nil {
+		return Silence{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sil.ID == "" {
+		id := make([]byte, 4)
+		rand.Read(id)
+		sil.ID = hex.EncodeToString(id)
+	}
+	for _, existing := range s.silences {
+		if existing.ID == sil.ID {
+			return Silence{}, fmt.Errorf("silence %s exists", sil.ID)
+		}
+	}
+	sil.static = false
+	s.silences = append(s.silences, sil)
+	log.Printf("silence %s added, %s to %s", sil.ID, sil.Start.Format(time.RFC3339), sil.End.Format(time.RFC3339))
+	s.save()
+	return sil, nil
+}
+
+// Remove deletes the silence id and reports whether it existed.
+func (s *Silences) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, sil := range s.silences {
+		if sil.ID == id {
+			s.silences = append(s.silences[:i], s.silences[i+1:]...)
+			log.Printf("silence %s removed", id)
+			s.save()
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Silences) List() []Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Silence{}, s.silences...)
+}
+
+// Silenced returns the ID of an active silence matching ev at at.
+func (s *Silences) Silenced(ev Event, at time.Time) (string, bool) {
+	var labels map[string]string
+	if s.Labels != nil && ev.Addr != "" {
+		labels = s.Labels(ev.Addr)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sil := range s.silences {
+		if sil.Active(at) && sil.matches(ev, labels) {
+			return sil.ID, true
+		}
+	}
+	return "", false
+}
+
+// save writes the runtime silences, the ones from the config come from it
+// again on start.
+func (s *Silences) save() {
+	if s.path == "" {
+		return
+	}
+	runtime := []Silence{}
+	for _, sil := range s.silences {
+		if !sil.static {
+			runtime = append(runtime, sil)
+		}
+	}
+	data, err := json.Marshal(runtime)
+	if err == nil {
+		tmp := s.path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, s.path)
+		}
+	}
+	if err != nil {
+		log.Printf("save silences %s failed:%s", s.path, err)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
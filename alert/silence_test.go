@@ -0,0 +1,131 @@
+package alert
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSilenceActive(t *testing.T) {
+	start := time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC)
+	once := Silence{ID: "once", Start: start, End: start.Add(2 * time.Hour)}
+	weekly := Silence{ID: "weekly", Start: start, End: start.Add(2 * time.Hour), Every: 168 * time.Hour}
+	tests := []struct {
+		silence Silence
+		at      time.Time
+		want    bool
+	}{
+		{once, start.Add(-time.Second), false},
+		{once, start, true},
+		{once, start.Add(2*time.Hour - time.Second), true},
+		{once, start.Add(2 * time.Hour), false},
+		{once, start.Add(168 * time.Hour), false},
+		{weekly, start.Add(-time.Second), false},
+		{weekly, start.Add(time.Hour), true},
+		{weekly, start.Add(3 * time.Hour), false},
+		{weekly, start.Add(168*time.Hour + time.Hour), true},
+		{weekly, start.Add(168*time.Hour + 2*time.Hour), false},
+	}
+	for _, tt := range tests {
+		if got := tt.silence.Active(tt.at); got != tt.want {
+			t.Errorf("%s at %s: Active = %v, want %v", tt.silence.ID, tt.at.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
+
+func TestSilenceMatches(t *testing.T) {
+	labels := map[string]string{"rack": "b2", "team": "ops"}
+	tests := []struct {
+		name    string
+		silence Silence
+		ev      Event
+		want    bool
+	}{
+		{"everything", Silence{}, Event{Rule: "offline", Addr: "aleo1"}, true},
+		{"fleet alert", Silence{}, Event{Rule: "fleet_speed_collapse"}, true},
+		{"listed address", Silence{Addresses: []string{"aleo1"}}, Event{Rule: "offline", Addr: "aleo1"}, true},
+		{"other address", Silence{Addresses: []string{"aleo1"}}, Event{Rule: "offline", Addr: "aleo2"}, false},
+		{"address silence on a fleet alert", Silence{Addresses: []string{"aleo1"}}, Event{Rule: "fleet_speed_collapse"}, false},
+		{"all labels", Silence{Labels: map[string]string{"rack": "b2", "team": "ops"}}, Event{Rule: "offline", Addr: "aleo1"}, true},
+		{"one label differs", Silence{Labels: map[string]string{"rack": "b3", "team": "ops"}}, Event{Rule: "offline", Addr: "aleo1"}, false},
+		{"listed rule", Silence{Rules: []string{"offline"}}, Event{Rule: "offline", Addr: "aleo1"}, true},
+		{"other rule", Silence{Rules: []string{"offline"}, Addresses: []string{"aleo1"}}, Event{Rule: "slow", Addr: "aleo1"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.silence.matches(tt.ev, labels); got != tt.want {
+			t.Errorf("%s: matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSilencedEngine(t *testing.T) {
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	silences, err := NewSilences([]Silence{{ID: "maint", Addresses: []string{"aleo1"}, Start: start, End: start.Add(time.Hour)}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, notified := sent(Rule{Name: "offline", Severity: "warning", Threshold: 0, Below: true})
+	e.Silences = silences
+
+	e.Evaluate("offline", "aleo1", 0, start.Add(time.Minute))
+	e.Evaluate("offline", "aleo2", 0, start.Add(time.Minute))
+	if want := []string{"offline/firing"}; !equal(*notified, want) {
+		t.Fatalf("notified %v, want only aleo2 firing", *notified)
+	}
+	e.Evaluate("offline", "aleo1", 0, start.Add(30*time.Minute))
+	e.Evaluate("offline", "aleo1", 0, start.Add(time.Hour))
+	if want := []string{"offline/firing", "offline/firing"}; !equal(*notified, want) {
+		t.Errorf("notified %v, want aleo1 sent once its silence ended", *notified)
+	}
+
+	// a silenced alert resolving inside the silence isn't notified at all
+	e, notified = sent(Rule{Name: "offline", Severity: "warning", Threshold: 0, Below: true})
+	e.Silences = silences
+	e.Evaluate("offline", "aleo1", 0, start)
+	e.Evaluate("offline", "aleo1", 10, start.Add(time.Minute))
+	if len(*notified) != 0 {
+		t.Errorf("notified %v, want nothing", *notified)
+	}
+}
+
+func TestSilencesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "silences.json")
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	static := []Silence{{ID: "config", Start: start, End: start.Add(time.Hour)}}
+	s, err := NewSilences(static, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := s.Add(Silence{Rules: []string{"offline"}, Start: start, End: start.Add(time.Hour), Every: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.ID == "" {
+		t.Error("no ID generated")
+	}
+	if _, err := s.Add(Silence{ID: added.ID, Start: start, End: start.Add(time.Hour)}); err == nil {
+		t.Error("duplicate ID accepted")
+	}
+	if _, err := s.Add(Silence{Start: start, End: start}); err == nil {
+		t.Error("empty window accepted")
+	}
+	if _, err := s.Add(Silence{Start: start, End: start.Add(2 * time.Hour), Every: time.Hour}); err == nil {
+		t.Error("every shorter than the window accepted")
+	}
+
+	s, err = NewSilences(static, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := s.List()
+	if len(list) != 2 || list[0].ID != "config" || list[1].ID != added.ID || list[1].Every != 24*time.Hour {
+		t.Fatalf("reloaded %+v, want the config silence and the added one once", list)
+	}
+	if !s.Remove(added.ID) || s.Remove(added.ID) {
+		t.Error("remove doesn't report the silence existing once")
+	}
+	s, _ = NewSilences(nil, path)
+	if len(s.List()) != 0 {
+		t.Errorf("removed silence reloaded: %+v", s.List())
+	}
+}
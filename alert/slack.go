@@ -0,0 +1,46 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// DefaultSlackTemplate renders an event as a Slack mrkdwn message.
+const DefaultSlackTemplate = "*{{upper .State}}* {{.Severity}} `{{.Rule}}`{{if .Addr}} {{.Addr}}{{end}}: value {{.Value}}, threshold {{.Threshold}}"
+
+// Slack posts events to an incoming webhook, the text rendered from
+// Template with the Event as data.
+type Slack struct {
+	WebhookURL string
+	Template   *template.Template
+	Client     *http.Client
+}
+
+// ParseTemplate parses an event message template, it may use the upper
+// function and json, which quotes a value for use inside a JSON document.
+func ParseTemplate(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(template.FuncMap{
+		"upper": func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+}
+
+func (s *Slack) Notify(ctx context.Context, ev Event) error {
+	var text bytes.Buffer
+	if err := s.Template.Execute(&text, ev); err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]string{"text": text.String()})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.Client, s.WebhookURL, body)
+}
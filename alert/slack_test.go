@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestSlackNotify(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{DefaultSlackTemplate, "*FIRING* critical `prover_offline` aleo1abc: value 0, threshold 0"},
+		{"{{.Rule}} {{json .Message}}", `prover_offline "prover_offline aleo1abc: value 0 crossed threshold 0"`},
+	}
+	for _, tt := range tests {
+		e := newEndpoint(t, 200, "ok")
+		tmpl, err := ParseTemplate("slack", tt.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &Slack{WebhookURL: e.URL + "/services/T0/B0/x", Template: tmpl}
+		if err := s.Notify(context.Background(), firing); err != nil {
+			t.Fatal(err)
+		}
+
+		r := e.Requests()[0]
+		if r.Path != "/services/T0/B0/x" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s with %q", r.Path, r.Header.Get("Content-Type"))
+		}
+		var msg map[string]string
+		if err := json.Unmarshal([]byte(r.Body), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if len(msg) != 1 || msg["text"] != tt.want {
+			t.Errorf("%q: message %v, want text %q", tt.template, msg, tt.want)
+		}
+	}
+}
+
+func TestSlackTemplateError(t *testing.T) {
+	e := newEndpoint(t, 200, "ok")
+	tmpl, err := ParseTemplate("slack", "{{.Missing}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Slack{WebhookURL: e.URL, Template: tmpl}
+	if err := s.Notify(context.Background(), firing); err == nil {
+		t.Error("template error not returned")
+	}
+	if n := len(e.Requests()); n != 0 {
+		t.Errorf("%d requests after a template error", n)
+	}
+}
@@ -0,0 +1,95 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary is the fleet and alert activity of one reporting period.
+type Summary struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Addresses int       `json:"addresses"`
+	// Speed is the fleet total speed averaged over the period, Reward what
+	// the fleet earned in it.
+	Speed    float64 `json:"speed"`
+	Reward   float64 `json:"reward"`
+	Fired    int     `json:"fired"`
+	Resolved int     `json:"resolved"`
+	Active   []Event `json:"active"`
+	// PerAddress, if set, breaks the period down by address.
+	PerAddress []AddressSummary `json:"per_address,omitempty"`
+}
+
+// AddressSummary is one address of a Summary, Downtime is how many minutes
+// it was seen at zero speed or not seen at all.
+type AddressSummary struct {
+	Addr     string  `json:"addr"`
+	Speed    float64 `json:"speed"`
+	Reward   float64 `json:"reward"`
+	Downtime float64 `json:"downtime_minutes"`
+}
+
+// Reporter is a Notifier with its own layout for summaries, the others get
+// them as a Report event.
+type Reporter interface {
+	Summary(ctx context.Context, s Summary) error
+}
+
+// Count fills Fired and Resolved from the events within the period.
+func (s *Summary) Count(events []Event) {
+	for _, ev := range events {
+		if ev.Time.Before(s.From) || ev.Time.After(s.To) {
+			continue
+		}
+		switch ev.State {
+		case Firing:
+			s.Fired++
+		case Resolved:
+			s.Resolved++
+		}
+	}
+}
+
+// Text renders s as plain text.
+func (s Summary) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Period:               %s - %s\n", s.From.Format(time.RFC3339), s.To.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Addresses:            %d\n", s.Addresses)
+	fmt.Fprintf(&b, "Average fleet speed:  %.2f\n", s.Speed)
+	fmt.Fprintf(&b, "Reward earned:        %.6f\n", s.Reward)
+	fmt.Fprintf(&b, "Alerts:               %d fired, %d resolved\n", s.Fired, s.Resolved)
+
+	if active := s.sortedActive(); len(active) > 0 {
+		fmt.Fprintf(&b, "\nStill firing:\n")
+		for _, ev := range active {
+			fmt.Fprintf(&b, "  %s since %s\n", Title(ev), ev.Time.Format(time.RFC3339))
+		}
+	}
+	if len(s.PerAddress) > 0 {
+		fmt.Fprintf(&b, "\nPer address (average speed, reward, downtime minutes):\n")
+		for _, a := range s.PerAddress {
+			fmt.Fprintf(&b, "  %s  %.2f  %.6f  %.0f\n", a.Addr, a.Speed, a.Reward, a.Downtime)
+		}
+	}
+	return b.String()
+}
+
+func (s Summary) sortedActive() []Event {
+	active := append([]Event(nil), s.Active...)
+	sort.Slice(active, func(i, j int) bool { return active[i].Time.Before(active[j].Time) })
+	return active
+}
+
+// title is the headline of a summary ending on the day of s.To.
+func (s Summary) title() string {
+	return "Daily summary " + s.To.Format("2006-01-02")
+}
+
+// reportEvent wraps s for notifiers that only know events.
+func reportEvent(s Summary) Event {
+	return Event{Rule: "daily_digest", State: Report, Severity: "info", Message: s.Text(), Time: s.To, Summary: &s}
+}
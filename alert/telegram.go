@@ -0,0 +1,33 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+const telegramAPI = "https://api.telegram.org"
+
+// Telegram sends events as bot messages to a chat.
+type Telegram struct {
+	Token  string
+	ChatID string
+	Client *http.Client
+	// APIURL overrides the Telegram Bot API base URL.
+	APIURL string
+}
+
+func (t *Telegram) Notify(ctx context.Context, ev Event) error {
+	base := t.APIURL
+	if base == "" {
+		base = telegramAPI
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id": t.ChatID,
+		"text":    Text(ev),
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, t.Client, base+"/bot"+t.Token+"/sendMessage", body)
+}
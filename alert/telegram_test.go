@@ -0,0 +1,41 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTelegramNotify(t *testing.T) {
+	e := newEndpoint(t, 200, `{"ok":true}`)
+	tg := &Telegram{Token: "123:abc", ChatID: "-10042", APIURL: e.URL}
+	if err := tg.Notify(context.Background(), firing); err != nil {
+		t.Fatal(err)
+	}
+
+	r := e.Requests()[0]
+	if r.Method != "POST" || r.Path != "/bot123:abc/sendMessage" {
+		t.Errorf("%s %s", r.Method, r.Path)
+	}
+	if r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("content type %q", r.Header.Get("Content-Type"))
+	}
+	var msg map[string]string
+	if err := json.Unmarshal([]byte(r.Body), &msg); err != nil {
+		t.Fatal(err)
+	}
+	want := "[FIRING] critical prover_offline aleo1abc\n" + firing.Message + "\n2026-10-14T12:00:00Z"
+	if msg["chat_id"] != "-10042" || msg["text"] != want {
+		t.Errorf("message %v", msg)
+	}
+}
+
+func TestTelegramFailed(t *testing.T) {
+	e := newEndpoint(t, 403, `{"ok":false,"description":"Forbidden: bot was kicked"}`)
+	tg := &Telegram{Token: "123:abc", ChatID: "-10042", APIURL: e.URL}
+	err := tg.Notify(context.Background(), firing)
+	if err == nil || !strings.Contains(err.Error(), "bot was kicked") {
+		t.Errorf("error = %v, want the answer", err)
+	}
+}
@@ -0,0 +1,92 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const twilioAPIURL = "https://api.twilio.com"
+
+// smsLimit is the longest body Twilio accepts, longer messages are cut.
+const smsLimit = 1600
+
+// Twilio texts every recipient through the Programmable Messaging API. It is
+// meant for the few rules worth a phone buzzing, so it sends only the
+// severities listed.
+type Twilio struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	To         []string
+	// Severities limits the events sent, empty sends every event.
+	Severities map[string]bool
+	Client     *http.Client
+	// URL overrides the API base URL.
+	URL string
+}
+
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (t *Twilio) Notify(ctx context.Context, ev Event) error {
+	if ev.State == Report || len(t.Severities) > 0 && !t.Severities[ev.Severity] {
+		return nil
+	}
+
+	text := Title(ev) + "\n" + ev.Message
+	if r := []rune(text); len(r) > smsLimit {
+		text = string(r[:smsLimit])
+	}
+	var failed []string
+	for _, to := range t.To {
+		if err := t.send(ctx, to, text); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", to, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (t *Twilio) send(ctx context.Context, to, text string) error {
+	base := t.URL
+	if base == "" {
+		base = twilioAPIURL
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimRight(base, "/"), url.PathEscape(t.AccountSID))
+	form := url.Values{"From": {t.From}, "To": {to}, "Body": {text}}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		var e twilioError
+		if json.Unmarshal(body, &e) == nil && e.Message != "" {
+			return fmt.Errorf("%s: error %d: %s", resp.Status, e.Code, e.Message)
+		}
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package alert
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTwilioNotify(t *testing.T) {
+	e := newEndpoint(t, 201, `{"sid":"SM1"}`)
+	tw := &Twilio{AccountSID: "AC123", AuthToken: "secret", From: "+15550001", To: []string{"+15550002", "+15550003"}, URL: e.URL}
+
+	if err := tw.Notify(context.Background(), firing); err != nil {
+		t.Fatal(err)
+	}
+	requests := e.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want one per recipient", len(requests))
+	}
+	for i, to := range tw.To {
+		r := requests[i]
+		if r.Method != "POST" || r.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			t.Errorf("%s %s", r.Method, r.Path)
+		}
+		if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+			t.Errorf("content type %q", r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Authorization") != "Basic QUMxMjM6c2VjcmV0" {
+			t.Errorf("authorization %q, want basic AC123:secret", r.Header.Get("Authorization"))
+		}
+		form, err := url.ParseQuery(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "[FIRING] critical prover_offline aleo1abc\n" + firing.Message
+		if form.Get("From") != "+15550001" || form.Get("To") != to || form.Get("Body") != want {
+			t.Errorf("form %v", form)
+		}
+	}
+}
+
+func TestTwilioSkips(t *testing.T) {
+	e := newEndpoint(t, 201, "")
+	tw := &Twilio{AccountSID: "AC123", To: []string{"+15550002"}, URL: e.URL}
+	// the daily digest isn't texted even with every severity sent
+	if err := tw.Notify(context.Background(), report); err != nil {
+		t.Fatal(err)
+	}
+	tw.Severities = map[string]bool{"critical": true}
+	warning := firing
+	warning.Severity = "warning"
+	if err := tw.Notify(context.Background(), warning); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(e.Requests()); n != 0 {
+		t.Errorf("%d requests, want none", n)
+	}
+}
+
+func TestTwilioLongAndFailed(t *testing.T) {
+	e := newEndpoint(t, 400, `{"code":21211,"message":"Invalid 'To' Phone Number"}`)
+	tw := &Twilio{AccountSID: "AC123", To: []string{"bad"}, URL: e.URL}
+	long := firing
+	long.Message = strings.Repeat("é", 2000)
+	err := tw.Notify(context.Background(), long)
+	if err == nil || !strings.Contains(err.Error(), "error 21211: Invalid 'To' Phone Number") {
+		t.Errorf("error = %v", err)
+	}
+	form, _ := url.ParseQuery(e.Requests()[0].Body)
+	if n := len([]rune(form.Get("Body"))); n != smsLimit {
+		t.Errorf("body of %d characters, want it cut to %d", n, smsLimit)
+	}
+}
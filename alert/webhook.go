@@ -0,0 +1,37 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+// Webhook posts events to an arbitrary HTTP endpoint. The body is the Event
+// as JSON, or rendered from Template when set, which must produce JSON.
+type Webhook struct {
+	URL      string
+	Template *template.Template
+	Client   *http.Client
+}
+
+func (w *Webhook) Notify(ctx context.Context, ev Event) error {
+	if w.Template == nil {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		return postJSON(ctx, w.Client, w.URL, body)
+	}
+
+	var body bytes.Buffer
+	if err := w.Template.Execute(&body, ev); err != nil {
+		return err
+	}
+	if !json.Valid(body.Bytes()) {
+		return fmt.Errorf("template rendered invalid JSON: %s", body.String())
+	}
+	return postJSON(ctx, w.Client, w.URL, body.Bytes())
+}
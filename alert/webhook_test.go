@@ -0,0 +1,62 @@
+package alert
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", `{"rule":"prover_offline","addr":"aleo1abc","state":"firing","severity":"critical","value":0,"threshold":0,
+			"message":"prover_offline aleo1abc: value 0 crossed threshold 0","time":"2026-10-14T12:00:00Z"}`},
+		{`{"alert":{{json .Rule}},"who":{{json .Addr}},"text":{{json .Message}},"up":{{if eq .State "resolved"}}true{{else}}false{{end}}}`,
+			`{"alert":"prover_offline","who":"aleo1abc","text":"prover_offline aleo1abc: value 0 crossed threshold 0","up":false}`},
+	}
+	for _, tt := range tests {
+		e := newEndpoint(t, 200, "")
+		w := &Webhook{URL: e.URL + "/hook"}
+		if tt.template != "" {
+			tmpl, err := ParseTemplate("webhook", tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Template = tmpl
+		}
+		if err := w.Notify(context.Background(), firing); err != nil {
+			t.Fatal(err)
+		}
+		r := e.Requests()[0]
+		if r.Method != "POST" || r.Path != "/hook" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s with %q", r.Method, r.Path, r.Header.Get("Content-Type"))
+		}
+		if !sameJSON(t, r.Body, tt.want) {
+			t.Errorf("%q: body %s", tt.template, r.Body)
+		}
+	}
+}
+
+func TestWebhookInvalidJSON(t *testing.T) {
+	e := newEndpoint(t, 200, "")
+	tmpl, err := ParseTemplate("webhook", `{"text": {{.Message}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Webhook{URL: e.URL, Template: tmpl}
+	if err := w.Notify(context.Background(), firing); err == nil {
+		t.Error("invalid JSON sent")
+	}
+	if n := len(e.Requests()); n != 0 {
+		t.Errorf("%d requests after invalid JSON", n)
+	}
+}
+
+func TestWebhookFailed(t *testing.T) {
+	e := newEndpoint(t, 500, "boom")
+	w := &Webhook{URL: e.URL}
+	if err := w.Notify(context.Background(), firing); err == nil || err.Error() != "500 Internal Server Error: boom" {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WeCom sends events to a WeCom (WeChat Work) group robot webhook as markdown.
+type WeCom struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+func (w *WeCom) Notify(ctx context.Context, ev Event) error {
+	color := "warning"
+	if ev.State == Resolved {
+		color = "info"
+	}
+	text := fmt.Sprintf("**<font color=\"%s\">%s</font>**\n> %s\n> %s", color, Title(ev), ev.Message, ev.Time.Format(time.RFC3339))
+
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": text},
+	})
+	if err != nil {
+		return err
+	}
+	var result robotResult
+	if err := postJSONDecode(ctx, w.Client, w.WebhookURL, body, &result); err != nil {
+		return err
+	}
+	return result.err()
+}
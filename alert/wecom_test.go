@@ -0,0 +1,43 @@
+package alert
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeComNotify(t *testing.T) {
+	resolved := firing
+	resolved.State = Resolved
+	stamp := firing.Time.Format(time.RFC3339)
+	tests := []struct {
+		ev   Event
+		want string
+	}{
+		{firing, `**<font color=\"warning\">[FIRING] critical prover_offline aleo1abc</font>**\n> prover_offline aleo1abc: value 0 crossed threshold 0\n> ` + stamp},
+		{resolved, `**<font color=\"info\">[RESOLVED] critical prover_offline aleo1abc</font>**\n> prover_offline aleo1abc: value 0 crossed threshold 0\n> ` + stamp},
+	}
+	for _, tt := range tests {
+		e := newEndpoint(t, 200, `{"errcode":0,"errmsg":"ok"}`)
+		w := &WeCom{WebhookURL: e.URL + "/cgi-bin/webhook/send?key=k3y"}
+		if err := w.Notify(context.Background(), tt.ev); err != nil {
+			t.Fatal(err)
+		}
+		r := e.Requests()[0]
+		if r.Path != "/cgi-bin/webhook/send" || r.Query != "key=k3y" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s?%s with %q", r.Path, r.Query, r.Header.Get("Content-Type"))
+		}
+		if !sameJSON(t, r.Body, `{"msgtype":"markdown","markdown":{"content":"`+tt.want+`"}}`) {
+			t.Errorf("%s: body %s", tt.ev.State, r.Body)
+		}
+	}
+}
+
+func TestWeComError(t *testing.T) {
+	e := newEndpoint(t, 200, `{"errcode":93000,"errmsg":"invalid webhook url"}`)
+	w := &WeCom{WebhookURL: e.URL}
+	if err := w.Notify(context.Background(), firing); err == nil || !strings.Contains(err.Error(), "errcode 93000") {
+		t.Errorf("error = %v, want the robot's errcode", err)
+	}
+}
@@ -0,0 +1,123 @@
+package apiclient
+
+import (
+	"context"
+	"strings"
+)
+
+// ProverAPI is the pool API the monitor collects from.
+type ProverAPI interface {
+	Speed(ctx context.Context, addresses []string, duration int) (SpeedResponse, error)
+	Rewards(ctx context.Context, addresses []string) (RewardResponse, error)
+	Heights(ctx context.Context, addresses []string) (HeightResponse, error)
+	LatestBlock(ctx context.Context) (BlockData, error)
+}
+
+// PoolAPI is implemented by backends exposing pool-wide statistics.
+type PoolAPI interface {
+	PoolStats(ctx context.Context) (PoolStatsResponse, error)
+}
+
+// BlockRewardAPI is implemented by backends attributing rewards to single
+// blocks.
+type BlockRewardAPI interface {
+	BlockRewards(ctx context.Context, addresses []string) (BlockRewardResponse, error)
+}
+
+type SpeedRequestPayload struct {
+	Address  []string `json:"address"`
+	Duration int      `json:"duration"`
+}
+
+type SpeedItem struct {
+	Address string `json:"address"`
+	Speed   string `json:"speed"`
+}
+
+type SpeedResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		List  []SpeedItem `json:"list"`
+		Total string      `json:"total"`
+	} `json:"data"`
+}
+
+type RewardRequestPayload struct {
+	Address []string `json:"address"`
+}
+
+type RewardItem struct {
+	Address     string `json:"address"`
+	TotalReward string `json:"total_reward"`
+}
+
+type RewardResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		List  []RewardItem `json:"list"`
+		Total string       `json:"total"`
+	} `json:"data"`
+}
+
+type HeightRequestPayload struct {
+	Address []string `json:"address"`
+}
+
+type HeightItem struct {
+	Address string `json:"address"`
+	Height  int    `json:"height"`
+}
+
+type HeightResponse struct {
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Data    []HeightItem `json:"data"`
+}
+
+type BlockData struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Height         int    `json:"height"`
+		ProofTarget    string `json:"proof_target"`
+		CoinbaseReward string `json:"coinbase_reward"`
+		// Epoch is only sent by some APIs, nil when missing.
+		Epoch *int `json:"epoch"`
+	} `json:"data"`
+}
+
+type BlockRewardItem struct {
+	Address string `json:"address"`
+	Height  int    `json:"height"`
+	// Epoch is only sent by some APIs, nil when missing.
+	Epoch  *int   `json:"epoch"`
+	Reward string `json:"reward"`
+}
+
+type BlockRewardResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		List []BlockRewardItem `json:"list"`
+	} `json:"data"`
+}
+
+type PoolStatsResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Fee        string `json:"fee"`
+		Luck       string `json:"luck"`
+		Efficiency string `json:"efficiency"`
+	} `json:"data"`
+}
+
+// NormalizeAddress strips a UTF-8 BOM and surrounding whitespace and lower
+// cases the address, aleo bech32 addresses are lower case on the wire.
+func NormalizeAddress(addr string) string {
+	addr = strings.TrimPrefix(addr, "\ufeff")
+	addr = strings.TrimSpace(addr)
+	return strings.ToLower(addr)
+}
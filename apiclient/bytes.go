@@ -0,0 +1,20 @@
+package apiclient
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type byteCounterKey struct{}
+
+// WithByteCounter makes every request made with the returned context add the
+// size of its response body to n.
+func WithByteCounter(ctx context.Context, n *atomic.Int64) context.Context {
+	return context.WithValue(ctx, byteCounterKey{}, n)
+}
+
+func countBytes(ctx context.Context, size int) {
+	if n, ok := ctx.Value(byteCounterKey{}).(*atomic.Int64); ok {
+		n.Add(int64(size))
+	}
+}
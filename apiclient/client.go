@@ -0,0 +1,200 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrDecode wraps the errors of answers that are not the expected JSON.
+var ErrDecode = errors.New("JSON反序列化错误")
+
+const (
+	SpeedPath  = "/api/v1/provers/prover_speed_list"
+	RewardPath = "/api/v1/provers/prover_reward_list"
+	HeightPath = "/api/v1/provers/prover_latest_height"
+	BlockPath  = "/api/v1/chain/latest_block"
+)
+
+// Client talks to one pool API over HTTP.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Timeouts bounds single requests per endpoint name (speed, reward,
+	// height, block, pool) on top of the HTTPClient timeout.
+	Timeouts map[string]time.Duration
+	// Headers are static headers per endpoint name, "*" applies to all.
+	Headers map[string]map[string]string
+	// SpeedPaths overrides SpeedPath per duration window, a value starting
+	// with http:// or https:// is used as the full URL.
+	SpeedPaths       map[int]string
+	PoolStatsPath    string
+	BlockRewardsPath string
+	// Observe, if set, is called with the duration of every request.
+	Observe func(endpoint string, elapsed time.Duration)
+	// Skew, if set, is called with how far the local clock is ahead of the
+	// Date header of every answer that has one.
+	Skew func(skew time.Duration)
+}
+
+func New(baseURL string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{
+		BaseURL:    baseURL,
+		HTTPClient: client,
+	}
+}
+
+func (c *Client) Speed(ctx context.Context, addresses []string, duration int) (SpeedResponse, error) {
+	var response SpeedResponse
+	path := SpeedPath
+	if p, ok := c.SpeedPaths[duration]; ok {
+		path = p
+	}
+	err := c.post(ctx, "speed", path, SpeedRequestPayload{addresses, duration}, &response)
+	for i := range response.Data.List {
+		response.Data.List[i].Address = NormalizeAddress(response.Data.List[i].Address)
+	}
+	return response, err
+}
+
+func (c *Client) Rewards(ctx context.Context, addresses []string) (RewardResponse, error) {
+	var response RewardResponse
+	err := c.post(ctx, "reward", RewardPath, RewardRequestPayload{addresses}, &response)
+	for i := range response.Data.List {
+		response.Data.List[i].Address = NormalizeAddress(response.Data.List[i].Address)
+	}
+	return response, err
+}
+
+func (c *Client) Heights(ctx context.Context, addresses []string) (HeightResponse, error) {
+	var response HeightResponse
+	err := c.post(ctx, "height", HeightPath, HeightRequestPayload{addresses}, &response)
+	for i := range response.Data {
+		response.Data[i].Address = NormalizeAddress(response.Data[i].Address)
+	}
+	return response, err
+}
+
+func (c *Client) LatestBlock(ctx context.Context) (BlockData, error) {
+	var response BlockData
+	err := c.get(ctx, "block", BlockPath, &response)
+	return response, err
+}
+
+func (c *Client) PoolStats(ctx context.Context) (PoolStatsResponse, error) {
+	var response PoolStatsResponse
+	if c.PoolStatsPath == "" {
+		return response, fmt.Errorf("pool stats path not configured")
+	}
+	err := c.get(ctx, "pool", c.PoolStatsPath, &response)
+	return response, err
+}
+
+func (c *Client) BlockRewards(ctx context.Context, addresses []string) (BlockRewardResponse, error) {
+	var response BlockRewardResponse
+	if c.BlockRewardsPath == "" {
+		return response, fmt.Errorf("block rewards path not configured")
+	}
+	err := c.post(ctx, "block_reward", c.BlockRewardsPath, RewardRequestPayload{addresses}, &response)
+	for i := range response.Data.List {
+		response.Data.List[i].Address = NormalizeAddress(response.Data.List[i].Address)
+	}
+	return response, err
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, path string, payload interface{}, response interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("JSON序列化错误: %v", err)
+	}
+
+	ctx, cancel := c.endpointContext(ctx, endpoint)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url(path), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求错误: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req, endpoint)
+	return c.do(endpoint, req, response)
+}
+
+func (c *Client) get(ctx context.Context, endpoint string, path string, response interface{}) error {
+	ctx, cancel := c.endpointContext(ctx, endpoint)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url(path), nil)
+	if err != nil {
+		return fmt.Errorf("创建请求错误: %v", err)
+	}
+	c.setHeaders(req, endpoint)
+	return c.do(endpoint, req, response)
+}
+
+func (c *Client) url(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.BaseURL + path
+}
+
+func (c *Client) setHeaders(req *http.Request, endpoint string) {
+	for name, value := range c.Headers["*"] {
+		req.Header.Set(name, value)
+	}
+	for name, value := range c.Headers[endpoint] {
+		req.Header.Set(name, value)
+	}
+}
+
+func (c *Client) do(endpoint string, req *http.Request, response interface{}) error {
+	start := time.Now()
+	if c.Observe != nil {
+		defer func() { c.Observe(endpoint, time.Since(start)) }()
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求错误: %v", err)
+	}
+	defer resp.Body.Close()
+	if c.Skew != nil {
+		if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+			// the header is truncated to seconds and stamped somewhere
+			// between sending the request and receiving the headers
+			local := start.Add(time.Since(start) / 2)
+			c.Skew(local.Sub(date.Add(500 * time.Millisecond)))
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	countBytes(req.Context(), len(body))
+	if err != nil {
+		return fmt.Errorf("读取响应错误: %v", err)
+	}
+
+	err = json.Unmarshal(body, response)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+
+	return nil
+}
+
+func (c *Client) endpointContext(ctx context.Context, endpoint string) (context.Context, context.CancelFunc) {
+	if d := c.Timeouts[endpoint]; d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,202 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+type FieldStatus string
+
+const (
+	FieldOK        FieldStatus = "present"
+	FieldMissing   FieldStatus = "missing"
+	FieldMisshaped FieldStatus = "misshaped"
+)
+
+type FieldReport struct {
+	Path   string      `json:"path"`
+	Status FieldStatus `json:"status"`
+	Detail string      `json:"detail,omitempty"`
+}
+
+type EndpointReport struct {
+	Endpoint string        `json:"endpoint"`
+	Path     string        `json:"path"`
+	Error    string        `json:"error,omitempty"`
+	Fields   []FieldReport `json:"fields,omitempty"`
+}
+
+func (r EndpointReport) OK() bool {
+	if r.Error != "" {
+		return false
+	}
+	for _, f := range r.Fields {
+		if f.Status != FieldOK {
+			return false
+		}
+	}
+	return true
+}
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindNumber
+	// kindNumericString is a number sent as JSON string, like speeds and rewards.
+	kindNumericString
+)
+
+type fieldSpec struct {
+	path string
+	kind fieldKind
+}
+
+var conformanceSpecs = map[string][]fieldSpec{
+	"speed": {
+		{"data.list[].address", kindString},
+		{"data.list[].speed", kindNumericString},
+		{"data.total", kindNumericString},
+	},
+	"reward": {
+		{"data.list[].address", kindString},
+		{"data.list[].total_reward", kindNumericString},
+		{"data.total", kindNumericString},
+	},
+	"height": {
+		{"data[].address", kindString},
+		{"data[].height", kindNumber},
+	},
+	"block": {
+		{"data.height", kindNumber},
+		{"data.proof_target", kindNumericString},
+		{"data.coinbase_reward", kindNumericString},
+	},
+}
+
+// CheckConformance queries every endpoint the monitor relies on for address
+// and reports which of the fields it reads are present, missing or of the
+// wrong shape.
+func CheckConformance(ctx context.Context, c *Client, address string, duration int) []EndpointReport {
+	addrs := []string{address}
+	queries := []struct {
+		endpoint string
+		path     string
+		fetch    func(raw *json.RawMessage) error
+	}{
+		{"speed", SpeedPath, func(raw *json.RawMessage) error {
+			return c.post(ctx, "speed", SpeedPath, SpeedRequestPayload{addrs, duration}, raw)
+		}},
+		{"reward", RewardPath, func(raw *json.RawMessage) error {
+			return c.post(ctx, "reward", RewardPath, RewardRequestPayload{addrs}, raw)
+		}},
+		{"height", HeightPath, func(raw *json.RawMessage) error {
+			return c.post(ctx, "height", HeightPath, HeightRequestPayload{addrs}, raw)
+		}},
+		{"block", BlockPath, func(raw *json.RawMessage) error {
+			return c.get(ctx, "block", BlockPath, raw)
+		}},
+	}
+
+	var reports []EndpointReport
+	for _, q := range queries {
+		report := EndpointReport{Endpoint: q.endpoint, Path: q.path}
+		var raw json.RawMessage
+		var doc interface{}
+		if err := q.fetch(&raw); err != nil {
+			report.Error = err.Error()
+		} else if err := json.Unmarshal(raw, &doc); err != nil {
+			report.Error = err.Error()
+		} else {
+			for _, spec := range conformanceSpecs[q.endpoint] {
+				report.Fields = append(report.Fields, checkField(doc, spec))
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func checkField(doc interface{}, spec fieldSpec) FieldReport {
+	report := FieldReport{Path: spec.path}
+	values, detail := lookup(doc, strings.Split(spec.path, "."))
+	if values == nil {
+		report.Status = FieldMissing
+		report.Detail = detail
+		return report
+	}
+
+	for _, v := range values {
+		if d := checkKind(v, spec.kind); d != "" {
+			report.Status = FieldMisshaped
+			report.Detail = d
+			return report
+		}
+	}
+	report.Status = FieldOK
+	return report
+}
+
+// lookup resolves a dotted path where a "[]" suffix fans out over a list.
+func lookup(v interface{}, path []string) ([]interface{}, string) {
+	if len(path) == 0 {
+		return []interface{}{v}, ""
+	}
+
+	name := path[0]
+	list := strings.HasSuffix(name, "[]")
+	name = strings.TrimSuffix(name, "[]")
+
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, "parent of " + name + " is not an object"
+	}
+	child, ok := obj[name]
+	if !ok || child == nil {
+		return nil, name + " not found"
+	}
+	if !list {
+		return lookup(child, path[1:])
+	}
+
+	items, ok := child.([]interface{})
+	if !ok {
+		return nil, name + " is not a list"
+	}
+	if len(items) == 0 {
+		return nil, name + " is an empty list"
+	}
+	var values []interface{}
+	for _, item := range items {
+		found, detail := lookup(item, path[1:])
+		if found == nil {
+			return nil, detail
+		}
+		values = append(values, found...)
+	}
+	return values, ""
+}
+
+func checkKind(v interface{}, kind fieldKind) string {
+	switch kind {
+	case kindString:
+		if _, ok := v.(string); !ok {
+			return "want string"
+		}
+	case kindNumber:
+		if _, ok := v.(float64); !ok {
+			return "want number"
+		}
+	case kindNumericString:
+		s, ok := v.(string)
+		if !ok {
+			return "want number as string"
+		}
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return "not numeric: " + strconv.Quote(s)
+		}
+	}
+	return ""
+}
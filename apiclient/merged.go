@@ -0,0 +1,216 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Merged combines a primary and a fallback API per collector (speed, reward,
+// height, block, pool). Per address the higher precedence source wins and
+// the other one only fills the gaps.
+type Merged struct {
+	Primary  ProverAPI
+	Fallback ProverAPI
+	// FallbackFor lists the collectors allowed to use Fallback,
+	// PreferFallback those where Fallback takes precedence.
+	FallbackFor    map[string]bool
+	PreferFallback map[string]bool
+}
+
+func (m *Merged) sources(collector string) []ProverAPI {
+	if m.Fallback == nil || !m.FallbackFor[collector] {
+		return []ProverAPI{m.Primary}
+	}
+	if m.PreferFallback[collector] {
+		return []ProverAPI{m.Fallback, m.Primary}
+	}
+	return []ProverAPI{m.Primary, m.Fallback}
+}
+
+// mergeByAddress asks each source in turn for the addresses still missing.
+func mergeByAddress[T any](sources []ProverAPI, addresses []string, fetch func(api ProverAPI, addrs []string) ([]T, error), key func(T) string, valid func(T) bool) ([]T, bool, error) {
+	var merged []T
+	var lastErr error
+	filled := false
+	missing := addresses
+	for i, api := range sources {
+		if len(missing) == 0 {
+			break
+		}
+		items, err := fetch(api, missing)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", sourceName(api), err)
+			continue
+		}
+
+		found := make(map[string]bool)
+		for _, item := range items {
+			if valid(item) {
+				merged = append(merged, item)
+				found[key(item)] = true
+				if i > 0 {
+					filled = true
+				}
+			}
+		}
+
+		var rest []string
+		for _, a := range missing {
+			if !found[a] {
+				rest = append(rest, a)
+			}
+		}
+		missing = rest
+		lastErr = nil
+	}
+
+	if merged == nil && lastErr != nil {
+		return nil, false, lastErr
+	}
+	return merged, filled, nil
+}
+
+func sourceName(api ProverAPI) string {
+	if c, ok := api.(*Client); ok {
+		return c.BaseURL
+	}
+	return fmt.Sprintf("%T", api)
+}
+
+func (m *Merged) Speed(ctx context.Context, addresses []string, duration int) (SpeedResponse, error) {
+	var response SpeedResponse
+	list, filled, err := mergeByAddress(m.sources("speed"), addresses,
+		func(api ProverAPI, addrs []string) ([]SpeedItem, error) {
+			resp, err := api.Speed(ctx, addrs, duration)
+			if err != nil {
+				return nil, err
+			}
+			if response.Data.Total == "" {
+				response.Code, response.Message, response.Data.Total = resp.Code, resp.Message, resp.Data.Total
+			}
+			return resp.Data.List, nil
+		},
+		func(item SpeedItem) string { return item.Address },
+		func(item SpeedItem) bool { return item.Speed != "" })
+	if err != nil {
+		return response, err
+	}
+
+	response.Data.List = list
+	if filled {
+		total := 0.0
+		for _, item := range list {
+			v, _ := strconv.ParseFloat(item.Speed, 64)
+			total += v
+		}
+		response.Data.Total = strconv.FormatFloat(total, 'f', -1, 64)
+	}
+	return response, nil
+}
+
+func (m *Merged) Rewards(ctx context.Context, addresses []string) (RewardResponse, error) {
+	var response RewardResponse
+	list, filled, err := mergeByAddress(m.sources("reward"), addresses,
+		func(api ProverAPI, addrs []string) ([]RewardItem, error) {
+			resp, err := api.Rewards(ctx, addrs)
+			if err != nil {
+				return nil, err
+			}
+			if response.Data.Total == "" {
+				response.Code, response.Message, response.Data.Total = resp.Code, resp.Message, resp.Data.Total
+			}
+			return resp.Data.List, nil
+		},
+		func(item RewardItem) string { return item.Address },
+		func(item RewardItem) bool { return item.TotalReward != "" })
+	if err != nil {
+		return response, err
+	}
+
+	response.Data.List = list
+	if filled {
+		total := 0.0
+		for _, item := range list {
+			v, _ := strconv.ParseFloat(item.TotalReward, 64)
+			total += v
+		}
+		response.Data.Total = strconv.FormatFloat(total, 'f', -1, 64)
+	}
+	return response, nil
+}
+
+func (m *Merged) Heights(ctx context.Context, addresses []string) (HeightResponse, error) {
+	var response HeightResponse
+	list, _, err := mergeByAddress(m.sources("height"), addresses,
+		func(api ProverAPI, addrs []string) ([]HeightItem, error) {
+			resp, err := api.Heights(ctx, addrs)
+			if err != nil {
+				return nil, err
+			}
+			response.Code, response.Message = resp.Code, resp.Message
+			return resp.Data, nil
+		},
+		func(item HeightItem) string { return item.Address },
+		func(item HeightItem) bool { return item.Height > 0 })
+	if err != nil {
+		return response, err
+	}
+
+	response.Data = list
+	return response, nil
+}
+
+func (m *Merged) LatestBlock(ctx context.Context) (BlockData, error) {
+	var response BlockData
+	var lastErr error
+	for _, api := range m.sources("block") {
+		resp, err := api.LatestBlock(ctx)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", sourceName(api), err)
+			continue
+		}
+		if resp.Data.Height > 0 {
+			return resp, nil
+		}
+		response = resp
+	}
+	if response.Data.Height == 0 && lastErr != nil {
+		return response, lastErr
+	}
+	return response, nil
+}
+
+func (m *Merged) BlockRewards(ctx context.Context, addresses []string) (BlockRewardResponse, error) {
+	lastErr := fmt.Errorf("no source provides block rewards")
+	for _, api := range m.sources("block_reward") {
+		rewards, ok := api.(BlockRewardAPI)
+		if !ok {
+			continue
+		}
+		resp, err := rewards.BlockRewards(ctx, addresses)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", sourceName(api), err)
+			continue
+		}
+		return resp, nil
+	}
+	return BlockRewardResponse{}, lastErr
+}
+
+func (m *Merged) PoolStats(ctx context.Context) (PoolStatsResponse, error) {
+	lastErr := fmt.Errorf("no source provides pool stats")
+	for _, api := range m.sources("pool") {
+		pool, ok := api.(PoolAPI)
+		if !ok {
+			continue
+		}
+		resp, err := pool.PoolStats(ctx)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", sourceName(api), err)
+			continue
+		}
+		return resp, nil
+	}
+	return PoolStatsResponse{}, lastErr
+}
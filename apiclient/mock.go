@@ -0,0 +1,42 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+)
+
+// Mock is a ProverAPI backed by funcs, unset funcs fail with an error.
+type Mock struct {
+	SpeedFunc       func(ctx context.Context, addresses []string, duration int) (SpeedResponse, error)
+	RewardsFunc     func(ctx context.Context, addresses []string) (RewardResponse, error)
+	HeightsFunc     func(ctx context.Context, addresses []string) (HeightResponse, error)
+	LatestBlockFunc func(ctx context.Context) (BlockData, error)
+}
+
+func (m *Mock) Speed(ctx context.Context, addresses []string, duration int) (SpeedResponse, error) {
+	if m.SpeedFunc == nil {
+		return SpeedResponse{}, fmt.Errorf("mock: Speed not implemented")
+	}
+	return m.SpeedFunc(ctx, addresses, duration)
+}
+
+func (m *Mock) Rewards(ctx context.Context, addresses []string) (RewardResponse, error) {
+	if m.RewardsFunc == nil {
+		return RewardResponse{}, fmt.Errorf("mock: Rewards not implemented")
+	}
+	return m.RewardsFunc(ctx, addresses)
+}
+
+func (m *Mock) Heights(ctx context.Context, addresses []string) (HeightResponse, error) {
+	if m.HeightsFunc == nil {
+		return HeightResponse{}, fmt.Errorf("mock: Heights not implemented")
+	}
+	return m.HeightsFunc(ctx, addresses)
+}
+
+func (m *Mock) LatestBlock(ctx context.Context) (BlockData, error) {
+	if m.LatestBlockFunc == nil {
+		return BlockData{}, fmt.Errorf("mock: LatestBlock not implemented")
+	}
+	return m.LatestBlockFunc(ctx)
+}
@@ -0,0 +1,145 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Requery detects speed and reward answers whose total is more than the sum
+// of their list, i.e. an API that silently truncated the list, and asks for
+// the addresses missing from it again in ever smaller batches until the
+// answers are complete or down to single addresses. The total of the first
+// answer is kept.
+type Requery struct {
+	API ProverAPI
+	// Truncated, if set, is called with the endpoint name (speed, reward) of
+	// every truncated answer.
+	Truncated func(endpoint string)
+}
+
+func (r *Requery) Speed(ctx context.Context, addresses []string, duration int) (SpeedResponse, error) {
+	response, err := r.API.Speed(ctx, addresses, duration)
+	if err != nil {
+		return response, err
+	}
+	value := func(item SpeedItem) string { return item.Speed }
+	if !truncated(response.Data.Total, response.Data.List, value) {
+		return response, nil
+	}
+	r.truncated("speed")
+	response.Data.List = refill(response.Data.List, addresses,
+		func(addrs []string) ([]SpeedItem, string, error) {
+			resp, err := r.API.Speed(ctx, addrs, duration)
+			return resp.Data.List, resp.Data.Total, err
+		},
+		func(item SpeedItem) string { return item.Address }, value)
+	return response, nil
+}
+
+func (r *Requery) Rewards(ctx context.Context, addresses []string) (RewardResponse, error) {
+	response, err := r.API.Rewards(ctx, addresses)
+	if err != nil {
+		return response, err
+	}
+	value := func(item RewardItem) string { return item.TotalReward }
+	if !truncated(response.Data.Total, response.Data.List, value) {
+		return response, nil
+	}
+	r.truncated("reward")
+	response.Data.List = refill(response.Data.List, addresses,
+		func(addrs []string) ([]RewardItem, string, error) {
+			resp, err := r.API.Rewards(ctx, addrs)
+			return resp.Data.List, resp.Data.Total, err
+		},
+		func(item RewardItem) string { return item.Address }, value)
+	return response, nil
+}
+
+func (r *Requery) Heights(ctx context.Context, addresses []string) (HeightResponse, error) {
+	return r.API.Heights(ctx, addresses)
+}
+
+func (r *Requery) LatestBlock(ctx context.Context) (BlockData, error) {
+	return r.API.LatestBlock(ctx)
+}
+
+func (r *Requery) PoolStats(ctx context.Context) (PoolStatsResponse, error) {
+	pool, ok := r.API.(PoolAPI)
+	if !ok {
+		return PoolStatsResponse{}, fmt.Errorf("no source provides pool stats")
+	}
+	return pool.PoolStats(ctx)
+}
+
+func (r *Requery) BlockRewards(ctx context.Context, addresses []string) (BlockRewardResponse, error) {
+	rewards, ok := r.API.(BlockRewardAPI)
+	if !ok {
+		return BlockRewardResponse{}, fmt.Errorf("no source provides block rewards")
+	}
+	return rewards.BlockRewards(ctx, addresses)
+}
+
+func (r *Requery) truncated(endpoint string) {
+	if r.Truncated != nil {
+		r.Truncated(endpoint)
+	}
+}
+
+// truncated reports whether total exceeds the sum of the item values beyond
+// rounding. Answers without a numeric total are never truncated.
+func truncated[T any](total string, items []T, value func(T) string) bool {
+	t, err := strconv.ParseFloat(total, 64)
+	if err != nil {
+		return false
+	}
+	sum := 0.0
+	for _, item := range items {
+		v, _ := strconv.ParseFloat(value(item), 64)
+		sum += v
+	}
+	return t-sum > 1e-6*math.Max(1, math.Abs(t))
+}
+
+// refill queries the addresses missing from items in halves, splitting every
+// batch whose answer is truncated again. Failed queries leave their addresses
+// missing.
+func refill[T any](items []T, addresses []string, fetch func(addrs []string) ([]T, string, error), key func(T) string, value func(T) string) []T {
+	var query, split func(addrs []string) []T
+	query = func(addrs []string) []T {
+		found, total, err := fetch(addrs)
+		if err != nil {
+			return nil
+		}
+		if len(addrs) == 1 || !truncated(total, found, value) {
+			return found
+		}
+		return append(found, split(missing(addrs, found, key))...)
+	}
+	split = func(addrs []string) []T {
+		if len(addrs) <= 1 {
+			if len(addrs) == 1 {
+				return query(addrs)
+			}
+			return nil
+		}
+		half := len(addrs) / 2
+		return append(query(addrs[:half]), query(addrs[half:])...)
+	}
+	return append(items, split(missing(addresses, items, key))...)
+}
+
+func missing[T any](addresses []string, items []T, key func(T) string) []string {
+	found := make(map[string]bool, len(items))
+	for _, item := range items {
+		found[key(item)] = true
+	}
+	var rest []string
+	for _, addr := range addresses {
+		if !found[addr] {
+			rest = append(rest, addr)
+		}
+	}
+	return rest
+}
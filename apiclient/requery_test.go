@@ -0,0 +1,146 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// cappedSpeeds answers at most limit items per query while the total still
+// covers every address asked for, the speed of aleo<n> being n.
+func cappedSpeeds(limit int, calls *int) *Mock {
+	return &Mock{SpeedFunc: func(ctx context.Context, addresses []string, duration int) (SpeedResponse, error) {
+		*calls++
+		var resp SpeedResponse
+		total := 0
+		for i, addr := range addresses {
+			n, _ := strconv.Atoi(addr[len("aleo"):])
+			total += n
+			if i < limit {
+				resp.Data.List = append(resp.Data.List, SpeedItem{Address: addr, Speed: strconv.Itoa(n)})
+			}
+		}
+		resp.Data.Total = strconv.Itoa(total)
+		return resp, nil
+	}}
+}
+
+func testAddresses(n int) []string {
+	addrs := make([]string, n)
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("aleo%d", i+1)
+	}
+	return addrs
+}
+
+func speedAddresses(resp SpeedResponse) []string {
+	var addrs []string
+	for _, item := range resp.Data.List {
+		addrs = append(addrs, item.Address)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func TestRequerySpeedRefillsTruncatedAnswer(t *testing.T) {
+	calls := 0
+	var truncations []string
+	r := &Requery{API: cappedSpeeds(3, &calls), Truncated: func(endpoint string) { truncations = append(truncations, endpoint) }}
+
+	addrs := testAddresses(10)
+	resp, err := r.Speed(context.Background(), addrs, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := speedAddresses(resp)
+	want := append([]string(nil), addrs...)
+	sort.Strings(want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("addresses = %v, want %v", got, want)
+	}
+	if resp.Data.Total != "55" {
+		t.Errorf("total = %s, want the one of the first answer, 55", resp.Data.Total)
+	}
+	if len(truncations) != 1 || truncations[0] != "speed" {
+		t.Errorf("truncations = %v, want [speed]", truncations)
+	}
+	if calls < 2 {
+		t.Errorf("%d queries, want the missing addresses asked again", calls)
+	}
+}
+
+func TestRequerySpeedCompleteAnswer(t *testing.T) {
+	calls := 0
+	r := &Requery{API: cappedSpeeds(100, &calls), Truncated: func(string) { t.Error("complete answer reported truncated") }}
+
+	resp, err := r.Speed(context.Background(), testAddresses(10), 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.List) != 10 || calls != 1 {
+		t.Errorf("%d items in %d queries, want 10 in 1", len(resp.Data.List), calls)
+	}
+}
+
+func TestRequerySpeedFailedRefill(t *testing.T) {
+	calls := 0
+	api := cappedSpeeds(2, &calls)
+	capped := api.SpeedFunc
+	api.SpeedFunc = func(ctx context.Context, addresses []string, duration int) (SpeedResponse, error) {
+		if calls > 0 {
+			calls++
+			return SpeedResponse{}, errors.New("down")
+		}
+		return capped(ctx, addresses, duration)
+	}
+	r := &Requery{API: api}
+
+	resp, err := r.Speed(context.Background(), testAddresses(6), 15)
+	if err != nil {
+		t.Fatalf("the first answer succeeded, got %v", err)
+	}
+	if got := speedAddresses(resp); fmt.Sprint(got) != "[aleo1 aleo2]" {
+		t.Errorf("addresses = %v, want the first answer only", got)
+	}
+}
+
+func TestRequeryRewards(t *testing.T) {
+	r := &Requery{API: &Mock{RewardsFunc: func(ctx context.Context, addresses []string) (RewardResponse, error) {
+		var resp RewardResponse
+		resp.Data.Total = strconv.Itoa(len(addresses))
+		resp.Data.List = append(resp.Data.List, RewardItem{Address: addresses[0], TotalReward: "1"})
+		return resp, nil
+	}}}
+
+	resp, err := r.Rewards(context.Background(), testAddresses(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.List) != 5 {
+		t.Errorf("%d rewards, want one per address refilled one by one", len(resp.Data.List))
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	items := []SpeedItem{{Speed: "1.5"}, {Speed: "2.5"}}
+	value := func(item SpeedItem) string { return item.Speed }
+	tests := []struct {
+		total string
+		want  bool
+	}{
+		{"4", false},
+		{"4.0000000001", false},
+		{"3", false},
+		{"5", true},
+		{"", false},
+		{"n/a", false},
+	}
+	for _, tt := range tests {
+		if got := truncated(tt.total, items, value); got != tt.want {
+			t.Errorf("truncated(%q) = %v, want %v", tt.total, got, tt.want)
+		}
+	}
+}
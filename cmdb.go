@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"aleo-prover-monitor/inventory"
+	"aleo-prover-monitor/prometh"
+)
+
+// withInventory labels the per-address series of gw with the inventory
+// labels of addresses. It returns the function resolving addresses added
+// later and the one returning the labels of an address, both nil without an
+// inventory.
+func withInventory(gw prometh.Gateway, client *http.Client, addresses []string) (prometh.Gateway, func([]string), func(string) map[string]string) {
+	if cfg.InventoryURL == "" {
+		return gw, nil, nil
+	}
+
+	var keys []string
+	for _, key := range strings.Split(cfg.InventoryLabels, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	lookup := &inventory.Lookup{URL: cfg.InventoryURL, Keys: keys, Client: client, CachePath: cfg.InventoryCache}
+	labeled := &prometh.AddressLabels{Next: gw, Names: keys}
+	enrich := func(addrs []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		labeled.Set(lookup.Resolve(ctx, addrs))
+	}
+
+	enrich(addresses)
+	return labeled, enrich, labeled.Labels
+}
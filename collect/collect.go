@@ -0,0 +1,302 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"aleo-prover-monitor/apiclient"
+)
+
+// Snapshot is the result of one collection, decoupled from how it is
+// emitted. Every query keeps its own error so a failing endpoint doesn't
+// hide the others, values are left as the API sent them.
+type Snapshot struct {
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Addresses []string  `json:"addresses"`
+	// Fresh, if set, names the addresses queried by this collection, the
+	// answers of the others are carried over by a Rotation.
+	Fresh map[string]bool `json:"-"`
+
+	Speeds []Speed `json:"speeds"`
+
+	Rewards      apiclient.RewardResponse `json:"rewards"`
+	RewardsError string                   `json:"rewards_error,omitempty"`
+
+	Heights      apiclient.HeightResponse `json:"heights"`
+	HeightsError string                   `json:"heights_error,omitempty"`
+
+	Block      apiclient.BlockData `json:"block"`
+	BlockError string              `json:"block_error,omitempty"`
+
+	// Pool is nil when pool stats were not queried.
+	Pool      *apiclient.PoolStatsResponse `json:"pool,omitempty"`
+	PoolError string                       `json:"pool_error,omitempty"`
+
+	// BlockRewards is nil when block rewards were not queried.
+	BlockRewards      *apiclient.BlockRewardResponse `json:"block_rewards,omitempty"`
+	BlockRewardsError string                         `json:"block_rewards_error,omitempty"`
+
+	Runs []Run `json:"runs"`
+	// Malformed names the queries whose answer could not be decoded.
+	Malformed []string `json:"malformed,omitempty"`
+}
+
+// Run records one query of a collection, named like speed/15 or block.
+type Run struct {
+	Collector string    `json:"collector"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Items     int       `json:"items"`
+	Bytes     int64     `json:"bytes"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Speed is the speed list of one duration window.
+type Speed struct {
+	Duration int `json:"duration"`
+	apiclient.SpeedResponse
+	Error string `json:"error,omitempty"`
+}
+
+// Collector queries everything a cycle needs from the API.
+type Collector struct {
+	API       apiclient.ProverAPI
+	Durations []int
+	// Concurrency limits the queries running at the same time, 0 means no limit.
+	Concurrency int
+	// PoolStats queries pool stats when API implements apiclient.PoolAPI.
+	PoolStats bool
+	// BlockRewards queries per-block rewards when API implements
+	// apiclient.BlockRewardAPI.
+	BlockRewards bool
+	// Deadline, if positive, is the time budget of a collection. Queries
+	// start in the order of Priority, names like speed/15 or reward with the
+	// unnamed ones last, and one whose Expected duration no longer fits in
+	// what is left of the budget is skipped, so the least important go
+	// first when the API is slow. Queries that started in time are
+	// cancelled at the deadline.
+	Deadline time.Duration
+	Priority []string
+	// Expected holds the last duration of every query, updated by Collect.
+	// Skipping a query halves its estimate, so one slow answer doesn't keep
+	// it skipped for good.
+	Expected map[string]time.Duration
+}
+
+// ErrSkipped is the error of the queries skipped for the deadline.
+var ErrSkipped = errors.New("skipped, not enough time left before the cycle deadline")
+
+// query is one query of a collection, fail records err as its answer
+// without running it.
+type query struct {
+	name string
+	run  func(ctx context.Context) (int, error)
+	fail func(err error)
+}
+
+// Collect runs all queries concurrently, so it takes about as long as the
+// slowest one.
+func (c *Collector) Collect(ctx context.Context, addresses []string) *Snapshot {
+	s := &Snapshot{
+		Started:   time.Now(),
+		Addresses: addresses,
+		Speeds:    make([]Speed, len(c.Durations)),
+	}
+	var queries []query
+	for i, d := range c.Durations {
+		i, d := i, d
+		queries = append(queries, query{
+			name: "speed/" + strconv.Itoa(d),
+			run: func(ctx context.Context) (int, error) {
+				resp, err := c.API.Speed(ctx, addresses, d)
+				s.Speeds[i] = Speed{Duration: d, SpeedResponse: resp, Error: errString(err)}
+				return len(resp.Data.List), err
+			},
+			fail: func(err error) { s.Speeds[i] = Speed{Duration: d, Error: errString(err)} },
+		})
+	}
+	queries = append(queries, query{
+		name: "reward",
+		run: func(ctx context.Context) (int, error) {
+			var err error
+			s.Rewards, err = c.API.Rewards(ctx, addresses)
+			s.RewardsError = errString(err)
+			return len(s.Rewards.Data.List), err
+		},
+		fail: func(err error) { s.RewardsError = errString(err) },
+	}, query{
+		name: "height",
+		run: func(ctx context.Context) (int, error) {
+			var err error
+			s.Heights, err = c.API.Heights(ctx, addresses)
+			s.HeightsError = errString(err)
+			return len(s.Heights.Data), err
+		},
+		fail: func(err error) { s.HeightsError = errString(err) },
+	}, query{
+		name: "block",
+		run: func(ctx context.Context) (int, error) {
+			var err error
+			s.Block, err = c.API.LatestBlock(ctx)
+			s.BlockError = errString(err)
+			return itemCount(err), err
+		},
+		fail: func(err error) { s.BlockError = errString(err) },
+	})
+	if pool, ok := c.API.(apiclient.PoolAPI); ok && c.PoolStats {
+		queries = append(queries, query{
+			name: "pool",
+			run: func(ctx context.Context) (int, error) {
+				resp, err := pool.PoolStats(ctx)
+				s.Pool, s.PoolError = &resp, errString(err)
+				return itemCount(err), err
+			},
+			fail: func(err error) { s.Pool, s.PoolError = &apiclient.PoolStatsResponse{}, errString(err) },
+		})
+	}
+	if rewards, ok := c.API.(apiclient.BlockRewardAPI); ok && c.BlockRewards {
+		queries = append(queries, query{
+			name: "block_reward",
+			run: func(ctx context.Context) (int, error) {
+				resp, err := rewards.BlockRewards(ctx, addresses)
+				s.BlockRewards, s.BlockRewardsError = &resp, errString(err)
+				return len(resp.Data.List), err
+			},
+			fail: func(err error) {
+				s.BlockRewards, s.BlockRewardsError = &apiclient.BlockRewardResponse{}, errString(err)
+			},
+		})
+	}
+	c.sortQueries(queries)
+
+	deadline := s.Started.Add(c.Deadline)
+	if c.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+		if c.Expected == nil {
+			c.Expected = make(map[string]time.Duration)
+		}
+	}
+	var mu sync.Mutex
+	var g errgroup.Group
+	if c.Concurrency > 0 {
+		g.SetLimit(c.Concurrency)
+	}
+	for _, q := range queries {
+		q := q
+		g.Go(func() error {
+			var n atomic.Int64
+			run := Run{Collector: q.name, Start: time.Now()}
+			var items int
+			var err error
+			mu.Lock()
+			skip := c.Deadline > 0 && deadline.Sub(run.Start) < c.Expected[q.name]
+			mu.Unlock()
+			if skip {
+				err = ErrSkipped
+				q.fail(err)
+			} else {
+				items, err = q.run(apiclient.WithByteCounter(ctx, &n))
+			}
+			run.Items, run.Error = items, errString(err)
+			run.End, run.Bytes = time.Now(), n.Load()
+			mu.Lock()
+			if c.Deadline > 0 {
+				if skip {
+					c.Expected[q.name] /= 2
+				} else {
+					c.Expected[q.name] = run.End.Sub(run.Start)
+				}
+			}
+			s.Runs = append(s.Runs, run)
+			if errors.Is(err, apiclient.ErrDecode) {
+				s.Malformed = append(s.Malformed, q.name)
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+
+	s.Finished = time.Now()
+	return s
+}
+
+// sortQueries orders queries by Priority, keeping the order of the others.
+func (c *Collector) sortQueries(queries []query) {
+	rank := make(map[string]int, len(c.Priority))
+	for i, name := range c.Priority {
+		rank[name] = i + 1
+	}
+	sort.SliceStable(queries, func(i, j int) bool {
+		ri, rj := rank[queries[i].name], rank[queries[j].name]
+		if ri == 0 || rj == 0 {
+			return ri != 0 && rj == 0
+		}
+		return ri < rj
+	})
+}
+
+// Failed reports whether every query of the snapshot failed.
+func (s *Snapshot) Failed() bool {
+	for _, sp := range s.Speeds {
+		if sp.Error == "" {
+			return false
+		}
+	}
+	if s.Pool != nil && s.PoolError == "" {
+		return false
+	}
+	return s.RewardsError != "" && s.HeightsError != "" && s.BlockError != ""
+}
+
+// IsFresh reports whether addr was queried by this collection.
+func (s *Snapshot) IsFresh(addr string) bool {
+	return s.Fresh == nil || s.Fresh[addr]
+}
+
+// Phases returns the wall time of every query kind, the speed windows
+// running in parallel count as one phase from the first start to the last end.
+func (s *Snapshot) Phases() map[string]time.Duration {
+	start := make(map[string]time.Time)
+	end := make(map[string]time.Time)
+	for _, run := range s.Runs {
+		phase, _, _ := strings.Cut(run.Collector, "/")
+		if t, ok := start[phase]; !ok || run.Start.Before(t) {
+			start[phase] = run.Start
+		}
+		if run.End.After(end[phase]) {
+			end[phase] = run.End
+		}
+	}
+	phases := make(map[string]time.Duration, len(start))
+	for phase, t := range start {
+		phases[phase] = end[phase].Sub(t)
+	}
+	return phases
+}
+
+// itemCount is the item count of a query answering a single object.
+func itemCount(err error) int {
+	if err != nil {
+		return 0
+	}
+	return 1
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
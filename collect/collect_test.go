@@ -0,0 +1,107 @@
+package collect
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCollectSkipsQueriesPastDeadline(t *testing.T) {
+	c := Collector{
+		API:       fleetAPI(0),
+		Durations: []int{15},
+		Deadline:  time.Second,
+		Expected:  map[string]time.Duration{"reward": time.Hour},
+	}
+	s := c.Collect(context.Background(), []string{"aleo1", "aleo2"})
+
+	if s.RewardsError != ErrSkipped.Error() {
+		t.Errorf("rewards error = %q, want skipped", s.RewardsError)
+	}
+	if s.Speeds[0].Error != "" || len(s.Speeds[0].Data.List) != 2 {
+		t.Errorf("speed = %+v, want both addresses", s.Speeds[0])
+	}
+	if s.HeightsError != "" || s.BlockError != "" {
+		t.Errorf("height and block errors = %q, %q, want none", s.HeightsError, s.BlockError)
+	}
+	if got := c.Expected["reward"]; got != 30*time.Minute {
+		t.Errorf("reward estimate = %v, want it halved to 30m", got)
+	}
+	if _, ok := c.Expected["speed/15"]; !ok {
+		t.Errorf("speed/15 estimate not recorded: %v", c.Expected)
+	}
+}
+
+func TestCollectSkipEstimateAgesOut(t *testing.T) {
+	c := Collector{
+		API:       fleetAPI(0),
+		Durations: []int{15},
+		Deadline:  100 * time.Millisecond,
+		Expected:  map[string]time.Duration{"reward": 10 * time.Second},
+	}
+	for cycle := 1; cycle <= 10; cycle++ {
+		s := c.Collect(context.Background(), []string{"aleo1"})
+		if s.RewardsError == "" {
+			if cycle == 1 {
+				t.Fatal("reward ran with an estimate past the deadline")
+			}
+			return
+		}
+	}
+	t.Errorf("reward still skipped after 10 cycles, estimate %v", c.Expected["reward"])
+}
+
+func TestCollectCancelsAtDeadline(t *testing.T) {
+	c := Collector{
+		API:       fleetAPI(time.Minute),
+		Durations: []int{15},
+		Deadline:  50 * time.Millisecond,
+	}
+	start := time.Now()
+	s := c.Collect(context.Background(), []string{"aleo1"})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("collection took %v, want it cut at the deadline", elapsed)
+	}
+	if s.HeightsError != context.DeadlineExceeded.Error() {
+		t.Errorf("heights error = %q, want the deadline", s.HeightsError)
+	}
+	if s.RewardsError != "" {
+		t.Errorf("rewards error = %q, want the fast query to succeed", s.RewardsError)
+	}
+}
+
+func TestCollectWithoutDeadline(t *testing.T) {
+	c := Collector{API: fleetAPI(0), Durations: []int{15, 60}}
+	s := c.Collect(context.Background(), []string{"aleo1", "aleo2", "aleo3"})
+
+	if len(s.Runs) != 5 {
+		t.Errorf("%d runs, want speed/15, speed/60, reward, height and block", len(s.Runs))
+	}
+	if c.Expected != nil {
+		t.Errorf("estimates recorded without a deadline: %v", c.Expected)
+	}
+	if s.Failed() {
+		t.Error("snapshot reported failed")
+	}
+}
+
+func TestSortQueries(t *testing.T) {
+	c := Collector{Priority: []string{"height", "speed/15"}}
+	var queries []query
+	for _, name := range []string{"speed/15", "speed/60", "reward", "height", "block"} {
+		queries = append(queries, query{name: name})
+	}
+	c.sortQueries(queries)
+
+	var got []string
+	for _, q := range queries {
+		got = append(got, q.name)
+	}
+	want := []string{"height", "speed/15", "speed/60", "reward", "block"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package collect
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"aleo-prover-monitor/apiclient"
+)
+
+// Rotation spreads the addresses over Slices, each Collect queries one of
+// them and carries the last answers of the others over, so every address is
+// refreshed once per rotation while the API sees a Slices-th of the fleet per
+// call. Answers older than MaxAge are no longer carried. Fresh of the result
+// names the addresses queried by the call, its totals are the sums of the
+// merged lists.
+type Rotation struct {
+	Collector Collector
+	Slices    int
+	MaxAge    time.Duration
+
+	next   int
+	slices []*answers
+}
+
+// answers are the last successful answers of one slice.
+type answers struct {
+	speeds    map[int]apiclient.SpeedResponse
+	speedsAt  map[int]time.Time
+	rewards   apiclient.RewardResponse
+	rewardsAt time.Time
+	heights   apiclient.HeightResponse
+	heightsAt time.Time
+}
+
+func (r *Rotation) Collect(ctx context.Context, addresses []string) *Snapshot {
+	if len(r.slices) != r.Slices {
+		r.slices, r.next = make([]*answers, r.Slices), 0
+		for i := range r.slices {
+			r.slices[i] = &answers{speeds: make(map[int]apiclient.SpeedResponse), speedsAt: make(map[int]time.Time)}
+		}
+	}
+	slice := make(map[string]int, len(addresses))
+	var subset []string
+	for i, addr := range addresses {
+		slice[addr] = i % r.Slices
+		if i%r.Slices == r.next {
+			subset = append(subset, addr)
+		}
+	}
+
+	fresh := r.Collector.Collect(ctx, subset)
+	if ctx.Err() != nil {
+		return fresh
+	}
+	a := r.slices[r.next]
+	for _, sp := range fresh.Speeds {
+		if sp.Error == "" {
+			a.speeds[sp.Duration], a.speedsAt[sp.Duration] = sp.SpeedResponse, fresh.Finished
+		}
+	}
+	if fresh.RewardsError == "" {
+		a.rewards, a.rewardsAt = fresh.Rewards, fresh.Finished
+	}
+	if fresh.HeightsError == "" {
+		a.heights, a.heightsAt = fresh.Heights, fresh.Finished
+	}
+	r.next = (r.next + 1) % r.Slices
+
+	merged := *fresh
+	merged.Addresses = addresses
+	merged.Fresh = make(map[string]bool, len(subset))
+	for _, addr := range subset {
+		merged.Fresh[addr] = true
+	}
+	// carried drops old answers and what a slice answered for addresses no
+	// longer in it
+	carried := func(j int, at time.Time, addr string) bool {
+		s, ok := slice[addr]
+		return ok && s == j && (r.MaxAge <= 0 || fresh.Finished.Sub(at) <= r.MaxAge)
+	}
+
+	merged.Speeds = make([]Speed, len(fresh.Speeds))
+	for i, sp := range fresh.Speeds {
+		merged.Speeds[i] = sp
+		if sp.Error != "" {
+			continue
+		}
+		merged.Speeds[i].Data.List = nil
+		total := 0.0
+		for j, a := range r.slices {
+			for _, item := range a.speeds[sp.Duration].Data.List {
+				if carried(j, a.speedsAt[sp.Duration], item.Address) {
+					merged.Speeds[i].Data.List = append(merged.Speeds[i].Data.List, item)
+					total += parseFloat(item.Speed)
+				}
+			}
+		}
+		merged.Speeds[i].Data.Total = strconv.FormatFloat(total, 'f', -1, 64)
+	}
+
+	if fresh.RewardsError == "" {
+		merged.Rewards.Data.List = nil
+		total := 0.0
+		for j, a := range r.slices {
+			for _, item := range a.rewards.Data.List {
+				if carried(j, a.rewardsAt, item.Address) {
+					merged.Rewards.Data.List = append(merged.Rewards.Data.List, item)
+					total += parseFloat(item.TotalReward)
+				}
+			}
+		}
+		merged.Rewards.Data.Total = strconv.FormatFloat(total, 'f', -1, 64)
+	}
+
+	if fresh.HeightsError == "" {
+		merged.Heights.Data = nil
+		for j, a := range r.slices {
+			for _, item := range a.heights.Data {
+				if carried(j, a.heightsAt, item.Address) {
+					merged.Heights.Data = append(merged.Heights.Data, item)
+				}
+			}
+		}
+	}
+	return &merged
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
@@ -0,0 +1,163 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"aleo-prover-monitor/apiclient"
+)
+
+// fleetAPI answers every address like aleo7 with a speed and reward of 7
+// and a height of 100, delay, if set, holds the height query.
+func fleetAPI(delay time.Duration) *apiclient.Mock {
+	return &apiclient.Mock{
+		SpeedFunc: func(ctx context.Context, addresses []string, duration int) (apiclient.SpeedResponse, error) {
+			var resp apiclient.SpeedResponse
+			for _, addr := range addresses {
+				resp.Data.List = append(resp.Data.List, apiclient.SpeedItem{Address: addr, Speed: addrValue(addr)})
+			}
+			return resp, nil
+		},
+		RewardsFunc: func(ctx context.Context, addresses []string) (apiclient.RewardResponse, error) {
+			var resp apiclient.RewardResponse
+			for _, addr := range addresses {
+				resp.Data.List = append(resp.Data.List, apiclient.RewardItem{Address: addr, TotalReward: addrValue(addr)})
+			}
+			return resp, nil
+		},
+		HeightsFunc: func(ctx context.Context, addresses []string) (apiclient.HeightResponse, error) {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return apiclient.HeightResponse{}, ctx.Err()
+			}
+			var resp apiclient.HeightResponse
+			for _, addr := range addresses {
+				resp.Data = append(resp.Data, apiclient.HeightItem{Address: addr, Height: 100})
+			}
+			return resp, nil
+		},
+		LatestBlockFunc: func(ctx context.Context) (apiclient.BlockData, error) {
+			return apiclient.BlockData{}, nil
+		},
+	}
+}
+
+func addrValue(addr string) string {
+	return addr[len("aleo"):]
+}
+
+func speedList(s *Snapshot) []string {
+	var addrs []string
+	for _, item := range s.Speeds[0].Data.List {
+		addrs = append(addrs, item.Address)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func freshList(s *Snapshot) []string {
+	var addrs []string
+	for addr := range s.Fresh {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func TestRotationQueriesOneSlicePerCollect(t *testing.T) {
+	var asked [][]string
+	api := fleetAPI(0)
+	speed := api.SpeedFunc
+	api.SpeedFunc = func(ctx context.Context, addresses []string, duration int) (apiclient.SpeedResponse, error) {
+		asked = append(asked, addresses)
+		return speed(ctx, addresses, duration)
+	}
+	r := &Rotation{Collector: Collector{API: api, Durations: []int{15}}, Slices: 2}
+	addrs := []string{"aleo1", "aleo2", "aleo3", "aleo4"}
+
+	s := r.Collect(context.Background(), addrs)
+	if got := freshList(s); len(got) != 2 || got[0] != "aleo1" || got[1] != "aleo3" {
+		t.Errorf("first fresh = %v, want [aleo1 aleo3]", got)
+	}
+	if got := speedList(s); len(got) != 2 {
+		t.Errorf("first speeds = %v, want the first slice only", got)
+	}
+	if s.IsFresh("aleo2") || !s.IsFresh("aleo1") {
+		t.Error("IsFresh doesn't follow the queried slice")
+	}
+
+	s = r.Collect(context.Background(), addrs)
+	if got := freshList(s); len(got) != 2 || got[0] != "aleo2" || got[1] != "aleo4" {
+		t.Errorf("second fresh = %v, want [aleo2 aleo4]", got)
+	}
+	if got := speedList(s); len(got) != 4 {
+		t.Errorf("second speeds = %v, want the first slice carried over", got)
+	}
+	if total := s.Speeds[0].Data.Total; total != "10" {
+		t.Errorf("total = %s, want the sum of the merged list, 10", total)
+	}
+	if len(s.Addresses) != 4 {
+		t.Errorf("addresses = %v, want all of them", s.Addresses)
+	}
+
+	s = r.Collect(context.Background(), addrs)
+	if got := freshList(s); got[0] != "aleo1" {
+		t.Errorf("third fresh = %v, want the rotation to start over", got)
+	}
+	if len(asked) != 3 || len(asked[0]) != 2 || len(asked[1]) != 2 {
+		t.Errorf("asked %v, want a half of the fleet per call", asked)
+	}
+}
+
+func TestRotationDropsOldAnswers(t *testing.T) {
+	r := &Rotation{Collector: Collector{API: fleetAPI(0), Durations: []int{15}}, Slices: 2, MaxAge: 20 * time.Millisecond}
+	addrs := []string{"aleo1", "aleo2"}
+
+	r.Collect(context.Background(), addrs)
+	time.Sleep(40 * time.Millisecond)
+	s := r.Collect(context.Background(), addrs)
+	if got := speedList(s); len(got) != 1 || got[0] != "aleo2" {
+		t.Errorf("speeds = %v, want the expired slice dropped", got)
+	}
+}
+
+func TestRotationDropsRemovedAddresses(t *testing.T) {
+	r := &Rotation{Collector: Collector{API: fleetAPI(0), Durations: []int{15}}, Slices: 2}
+
+	r.Collect(context.Background(), []string{"aleo1", "aleo2", "aleo3", "aleo4"})
+	s := r.Collect(context.Background(), []string{"aleo1", "aleo2", "aleo4"})
+	for _, addr := range speedList(s) {
+		if addr == "aleo3" {
+			t.Errorf("speeds = %v, want the removed aleo3 gone", speedList(s))
+		}
+	}
+}
+
+func TestRotationFailedQuery(t *testing.T) {
+	api := fleetAPI(0)
+	rewards := api.RewardsFunc
+	fail := false
+	api.RewardsFunc = func(ctx context.Context, addresses []string) (apiclient.RewardResponse, error) {
+		if fail {
+			return apiclient.RewardResponse{}, errors.New("down")
+		}
+		return rewards(ctx, addresses)
+	}
+	r := &Rotation{Collector: Collector{API: api, Durations: []int{15}}, Slices: 2}
+	addrs := []string{"aleo1", "aleo2"}
+
+	r.Collect(context.Background(), addrs)
+	fail = true
+	if s := r.Collect(context.Background(), addrs); s.RewardsError == "" {
+		t.Error("failed reward query not reported")
+	}
+	fail = false
+	s := r.Collect(context.Background(), addrs)
+	if s.RewardsError != "" || len(s.Rewards.Data.List) != 1 {
+		t.Errorf("rewards = %+v, %q, want the slice answering again", s.Rewards.Data.List, s.RewardsError)
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AddrFile is an address file and the alias its addresses are labeled with.
+type AddrFile struct {
+	Path  string
+	Alias string
+}
+
+// Label is the alias of the file, or else its base name.
+func (f AddrFile) Label() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return filepath.Base(f.Path)
+}
+
+func (f AddrFile) String() string {
+	if f.Alias != "" {
+		return f.Alias + "=" + f.Path
+	}
+	return f.Path
+}
+
+// AddrFiles are the address files whose lists are merged, as a flag it is
+// repeated as "path" or "alias=path". In YAML it is one such string or a
+// list of them.
+type AddrFiles []AddrFile
+
+func (a *AddrFiles) String() string {
+	if a == nil {
+		return ""
+	}
+	var parts []string
+	for _, f := range *a {
+		parts = append(parts, f.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// Set adds a file, one listed already is ignored.
+func (a *AddrFiles) Set(s string) error {
+	f, err := parseAddrFile(s)
+	if err != nil {
+		return err
+	}
+	for _, have := range *a {
+		if have == f {
+			return nil
+		}
+	}
+	*a = append(*a, f)
+	return nil
+}
+
+// Labeled reports whether addresses are labeled with their file, which is
+// when there are several files or an alias was given.
+func (a AddrFiles) Labeled() bool {
+	return len(a) > 1 || len(a) == 1 && a[0].Alias != ""
+}
+
+// Check rejects a file listed twice and files sharing a label, their
+// addresses couldn't be told apart.
+func (a AddrFiles) Check() error {
+	paths := make(map[string]bool, len(a))
+	seen := make(map[string]string, len(a))
+	for _, f := range a {
+		if paths[filepath.Clean(f.Path)] {
+			return fmt.Errorf("address file %s listed twice", f.Path)
+		}
+		paths[filepath.Clean(f.Path)] = true
+		if other, ok := seen[f.Label()]; ok {
+			return fmt.Errorf("address files %s and %s are both labeled %q, give one an alias", other, f.Path, f.Label())
+		}
+		seen[f.Label()] = f.Path
+	}
+	return nil
+}
+
+func (a *AddrFiles) UnmarshalYAML(node *yaml.Node) error {
+	var list []string
+	if node.Kind == yaml.ScalarNode {
+		list = []string{node.Value}
+	} else if err := node.Decode(&list); err != nil {
+		return err
+	}
+
+	files := make(AddrFiles, 0, len(list))
+	for _, s := range list {
+		f, err := parseAddrFile(s)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	*a = files
+	return nil
+}
+
+func (a AddrFiles) MarshalYAML() (interface{}, error) {
+	if len(a) == 1 {
+		return a[0].String(), nil
+	}
+	list := make([]string, len(a))
+	for i, f := range a {
+		list[i] = f.String()
+	}
+	return list, nil
+}
+
+func parseAddrFile(s string) (AddrFile, error) {
+	alias, path, ok := strings.Cut(s, "=")
+	if !ok {
+		alias, path = "", alias
+	}
+	f := AddrFile{Path: strings.TrimSpace(path), Alias: strings.TrimSpace(alias)}
+	if f.Path == "" || ok && f.Alias == "" {
+		return AddrFile{}, fmt.Errorf("want path or alias=path, got %q", s)
+	}
+	return f, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestAddrFiles(t *testing.T) {
+	var files AddrFiles
+	for _, s := range []string{"/etc/a.txt", "team=/etc/b.txt", "/etc/a.txt"} {
+		if err := files.Set(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(files) != 2 || files[0].Label() != "a.txt" || files[1].Label() != "team" {
+		t.Errorf("files = %+v", files)
+	}
+	if !files.Labeled() || (AddrFiles{{Path: "/etc/a.txt"}}).Labeled() {
+		t.Error("Labeled wants several files or an alias")
+	}
+	for _, s := range []string{"", "team=", "=/etc/a.txt"} {
+		if err := files.Set(s); err == nil {
+			t.Errorf("Set(%q) accepted", s)
+		}
+	}
+
+	if err := (AddrFiles{{Path: "/a/list.txt"}, {Path: "/b/list.txt"}}).Check(); err == nil {
+		t.Error("files sharing a label accepted")
+	}
+	if err := (AddrFiles{{Path: "/a/list.txt"}, {Path: "/a/./list.txt", Alias: "b"}}).Check(); err == nil {
+		t.Error("file listed twice accepted")
+	}
+	if err := (AddrFiles{{Path: "/a/list.txt"}, {Path: "/b/list.txt", Alias: "b"}}).Check(); err != nil {
+		t.Errorf("aliased files rejected: %v", err)
+	}
+}
@@ -0,0 +1,521 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"aleo-prover-monitor/alert"
+)
+
+type Config struct {
+	API           string        `yaml:"api"`
+	PushGateway   string        `yaml:"push_gateway"`
+	StreamPush    int           `yaml:"stream_push_series"`
+	SpoolDir      string        `yaml:"spool_dir"`
+	SpoolMaxMB    int           `yaml:"spool_max_mb"`
+	Interval      int           `yaml:"interval"`
+	AddrFiles     AddrFiles     `yaml:"addr_file"`
+	WatchAddrFile bool          `yaml:"watch_addr_file"`
+	WatchDebounce time.Duration `yaml:"watch_debounce"`
+	DurFile       string        `yaml:"dur_file"`
+	Retiring      Retiring      `yaml:"retiring"`
+	RetireGrace   time.Duration `yaml:"retire_grace"`
+	Notes         Notes         `yaml:"notes"`
+
+	Concurrency      int           `yaml:"concurrency"`
+	Batches          int           `yaml:"batches"`
+	CycleDeadline    time.Duration `yaml:"cycle_deadline"`
+	Priority         string        `yaml:"collector_priority"`
+	SlowCycle        time.Duration `yaml:"slow_cycle"`
+	HTTPTimeout      time.Duration `yaml:"http_timeout"`
+	EndpointTimeouts Timeouts      `yaml:"endpoint_timeouts"`
+	Headers          Headers       `yaml:"headers"`
+
+	HTTPKeepAlive           time.Duration `yaml:"http_keep_alive"`
+	HTTPMaxIdleConns        int           `yaml:"http_max_idle_conns"`
+	HTTPMaxIdleConnsPerHost int           `yaml:"http_max_idle_conns_per_host"`
+	HTTPIdleConnTimeout     time.Duration `yaml:"http_idle_conn_timeout"`
+	HTTPTLSSessionCache     int           `yaml:"http_tls_session_cache"`
+
+	SpeedEndpoints SpeedEndpoints `yaml:"speed_endpoints"`
+	Transforms     Transforms     `yaml:"transforms"`
+
+	FallbackAPI    string `yaml:"fallback_api"`
+	FallbackFor    string `yaml:"fallback_for"`
+	PreferFallback string `yaml:"prefer_fallback"`
+	PoolStatsPath  string `yaml:"pool_stats_path"`
+	// BlockRewardsPath is queried like the reward list for the reward of
+	// every block attributed to the addresses.
+	BlockRewardsPath    string `yaml:"block_rewards_path"`
+	BlockRewardsByEpoch bool   `yaml:"block_rewards_by_epoch"`
+	// RequeryTruncated asks again for the addresses missing from speed and
+	// reward lists that sum to less than their total.
+	RequeryTruncated bool `yaml:"requery_truncated"`
+
+	InventoryURL    string `yaml:"inventory_url"`
+	InventoryLabels string `yaml:"inventory_labels"`
+	InventoryCache  string `yaml:"inventory_cache"`
+
+	Namespace     string        `yaml:"namespace"`
+	Instance      string        `yaml:"instance"`
+	InstanceLabel bool          `yaml:"instance_label"`
+	DedupFresh    time.Duration `yaml:"dedup_freshness"`
+
+	Migrate   bool `yaml:"migrate"`
+	RawValues bool `yaml:"raw_values"`
+
+	Strict         bool `yaml:"strict"`
+	StrictFailures int  `yaml:"strict_failures"`
+
+	EventLog string `yaml:"event_log"`
+
+	InfluxURL             string `yaml:"influx_url"`
+	InfluxOnly            bool   `yaml:"influx_only"`
+	InfluxDatabase        string `yaml:"influx_database"`
+	InfluxRetentionPolicy string `yaml:"influx_retention_policy"`
+	InfluxUsername        string `yaml:"influx_username"`
+	InfluxPassword        string `yaml:"influx_password"`
+	InfluxOrg             string `yaml:"influx_org"`
+	InfluxBucket          string `yaml:"influx_bucket"`
+	InfluxToken           string `yaml:"influx_token"`
+
+	StatsDAddr   string `yaml:"statsd_addr"`
+	StatsDOnly   bool   `yaml:"statsd_only"`
+	StatsDPrefix string `yaml:"statsd_prefix"`
+	StatsDTags   bool   `yaml:"statsd_tags"`
+
+	DatadogAPIKey string `yaml:"datadog_api_key"`
+	DatadogURL    string `yaml:"datadog_url"`
+	DatadogOnly   bool   `yaml:"datadog_only"`
+	DatadogPrefix string `yaml:"datadog_prefix"`
+
+	GraphiteAddr      string            `yaml:"graphite_addr"`
+	GraphiteOnly      bool              `yaml:"graphite_only"`
+	GraphitePrefix    string            `yaml:"graphite_prefix"`
+	GraphiteTemplates GraphiteTemplates `yaml:"graphite_templates"`
+
+	ExporterListen string `yaml:"exporter_listen"`
+	AdminListen    string `yaml:"admin_listen"`
+	AdminToken     string `yaml:"admin_token"`
+	AgentListen    string `yaml:"agent_listen"`
+	AgentToken     string `yaml:"agent_token"`
+
+	SolutionsURL  string        `yaml:"solutions_ws_url"`
+	SolutionsPush time.Duration `yaml:"solutions_push_interval"`
+	SolutionsIdle time.Duration `yaml:"solutions_idle_timeout"`
+
+	AlertMinSpeed    float64       `yaml:"alert_min_speed"`
+	AlertClearSpeed  float64       `yaml:"alert_clear_speed"`
+	AlertFlapChanges int           `yaml:"alert_flap_changes"`
+	AlertMinTotal    float64       `yaml:"alert_min_total_speed"`
+	AlertClearTotal  float64       `yaml:"alert_clear_total_speed"`
+	AlertFleetDrop   float64       `yaml:"alert_fleet_drop_percent"`
+	AlertAPIDown     int           `yaml:"alert_api_down_cycles"`
+	AlertClockSkew   time.Duration `yaml:"alert_clock_skew"`
+	AlertHistoryFile string        `yaml:"alert_history_file"`
+	AlertHistorySize int           `yaml:"alert_history_size"`
+
+	AlertOverrides []alert.Override `yaml:"alert_overrides"`
+
+	AlertCooldown   time.Duration `yaml:"alert_cooldown"`
+	AlertFlapWindow time.Duration `yaml:"alert_flap_window"`
+
+	Silences    []alert.Silence `yaml:"silences"`
+	SilenceFile string          `yaml:"silence_file"`
+
+	QuietHours QuietHours `yaml:"quiet_hours"`
+
+	DigestAt       string `yaml:"digest_at"`
+	DigestChannels string `yaml:"digest_channels"`
+
+	TelegramToken  string `yaml:"telegram_token"`
+	TelegramChatID string `yaml:"telegram_chat_id"`
+
+	SlackWebhook  string `yaml:"slack_webhook"`
+	SlackTemplate string `yaml:"slack_template"`
+
+	DiscordWebhook string `yaml:"discord_webhook"`
+
+	PagerDutyKey        string `yaml:"pagerduty_routing_key"`
+	PagerDutySeverities string `yaml:"pagerduty_severities"`
+	PagerDutyURL        string `yaml:"pagerduty_url"`
+
+	OpsgenieKey        string `yaml:"opsgenie_api_key"`
+	OpsgenieSeverities string `yaml:"opsgenie_severities"`
+	OpsgenieURL        string `yaml:"opsgenie_url"`
+
+	AlertmanagerURL         string            `yaml:"alertmanager_url"`
+	AlertmanagerLabels      map[string]string `yaml:"alertmanager_labels"`
+	AlertmanagerAnnotations map[string]string `yaml:"alertmanager_annotations"`
+
+	DingTalkWebhook string `yaml:"dingtalk_webhook"`
+	DingTalkSecret  string `yaml:"dingtalk_secret"`
+
+	WeComWebhook string `yaml:"wecom_webhook"`
+
+	Webhook         string `yaml:"webhook"`
+	WebhookTemplate string `yaml:"webhook_template"`
+
+	SMTPAddr       string `yaml:"smtp_addr"`
+	SMTPUsername   string `yaml:"smtp_username"`
+	SMTPPassword   string `yaml:"smtp_password"`
+	SMTPFrom       string `yaml:"smtp_from"`
+	SMTPTo         string `yaml:"smtp_to"`
+	SMTPTLS        string `yaml:"smtp_tls"`
+	EmailSummaryAt string `yaml:"email_summary_at"`
+
+	TwilioSID        string `yaml:"twilio_account_sid"`
+	TwilioToken      string `yaml:"twilio_auth_token"`
+	TwilioFrom       string `yaml:"twilio_from"`
+	TwilioTo         string `yaml:"twilio_to"`
+	TwilioSeverities string `yaml:"twilio_severities"`
+
+	FeishuWebhook   string `yaml:"feishu_webhook"`
+	FeishuSecret    string `yaml:"feishu_secret"`
+	FeishuSummaryAt string `yaml:"feishu_summary_at"`
+
+	HistoryFile      string        `yaml:"history_file"`
+	HistoryRetention time.Duration `yaml:"history_retention"`
+
+	PostgresDSN       string `yaml:"postgres_dsn"`
+	PostgresTable     string `yaml:"postgres_table"`
+	PostgresTimescale bool   `yaml:"postgres_timescale"`
+
+	ClickHouseURL      string        `yaml:"clickhouse_url"`
+	ClickHouseDatabase string        `yaml:"clickhouse_database"`
+	ClickHouseTable    string        `yaml:"clickhouse_table"`
+	ClickHouseUsername string        `yaml:"clickhouse_username"`
+	ClickHousePassword string        `yaml:"clickhouse_password"`
+	ClickHouseTTL      time.Duration `yaml:"clickhouse_ttl"`
+
+	ArchiveDir         string        `yaml:"archive_dir"`
+	ArchiveFormat      string        `yaml:"archive_format"`
+	ArchiveRotate      time.Duration `yaml:"archive_rotate"`
+	ArchiveS3URL       string        `yaml:"archive_s3_url"`
+	ArchiveS3Region    string        `yaml:"archive_s3_region"`
+	ArchiveS3AccessKey string        `yaml:"archive_s3_access_key"`
+	ArchiveS3SecretKey string        `yaml:"archive_s3_secret_key"`
+	ArchiveS3Remove    bool          `yaml:"archive_s3_remove"`
+
+	SQLiteFile      string        `yaml:"sqlite_file"`
+	SQLiteRetention time.Duration `yaml:"sqlite_retention"`
+
+	EfficiencyWindow time.Duration `yaml:"efficiency_window"`
+	EpochLength      int           `yaml:"epoch_length"`
+
+	ForecastWindow  time.Duration `yaml:"forecast_window"`
+	ForecastHorizon time.Duration `yaml:"forecast_horizon"`
+
+	WatchdogInterval      time.Duration `yaml:"watchdog_interval"`
+	WatchdogMaxGoroutines int           `yaml:"watchdog_max_goroutines"`
+	WatchdogMaxHeapMB     int           `yaml:"watchdog_max_heap_mb"`
+	WatchdogRestart       bool          `yaml:"watchdog_restart"`
+
+	SpeedEMA string `yaml:"speed_ema"`
+
+	Derived Derived `yaml:"derived"`
+
+	RestartDipRatio     float64 `yaml:"restart_dip_ratio"`
+	RestartRecoverRatio float64 `yaml:"restart_recover_ratio"`
+	RestartMaxCycles    int     `yaml:"restart_max_cycles"`
+}
+
+func Default() Config {
+	return Config{
+		API:              "http://localhost:8088",
+		PushGateway:      "http://pushgateway:9091",
+		StreamPush:       5000,
+		SpoolMaxMB:       100,
+		DatadogURL:       "https://api.datadoghq.com",
+		RequeryTruncated: true,
+		Interval:         5,
+		WatchDebounce:    2 * time.Second,
+		RetireGrace:      72 * time.Hour,
+
+		Concurrency: 4,
+		Batches:     1,
+		HTTPTimeout: 30 * time.Second,
+
+		HTTPKeepAlive:           30 * time.Second,
+		HTTPMaxIdleConns:        64,
+		HTTPMaxIdleConnsPerHost: 16,
+		HTTPIdleConnTimeout:     90 * time.Second,
+		HTTPTLSSessionCache:     64,
+
+		FallbackFor: "speed,reward,height,block,pool",
+
+		InventoryLabels: "rack,site,owner",
+
+		AlertAPIDown:     3,
+		AlertClockSkew:   30 * time.Second,
+		AlertHistorySize: 100,
+		AlertCooldown:    30 * time.Minute,
+		AlertFlapChanges: 4,
+		AlertFlapWindow:  time.Hour,
+
+		SlackTemplate: alert.DefaultSlackTemplate,
+
+		StrictFailures: 3,
+
+		SolutionsPush: 15 * time.Second,
+		SolutionsIdle: 2 * time.Minute,
+
+		PagerDutySeverities: "critical",
+		OpsgenieSeverities:  "critical,warning",
+
+		SMTPTLS: "auto",
+
+		TwilioSeverities: "critical",
+
+		HistoryRetention: 48 * time.Hour,
+		PostgresTable:    "prover_history",
+		SQLiteRetention:  30 * 24 * time.Hour,
+
+		ClickHouseDatabase: "default",
+		ClickHouseTable:    "prover_history",
+
+		ArchiveFormat:   "csv",
+		ArchiveRotate:   24 * time.Hour,
+		ArchiveS3Region: "us-east-1",
+
+		EfficiencyWindow: 24 * time.Hour,
+		EpochLength:      360,
+
+		ForecastWindow:  6 * time.Hour,
+		ForecastHorizon: time.Hour,
+
+		WatchdogInterval: time.Minute,
+
+		RestartDipRatio:     0.5,
+		RestartRecoverRatio: 0.8,
+		RestartMaxCycles:    3,
+	}
+}
+
+// RegisterFlags binds every setting to a flag defaulting to the current value,
+// so parsing the command line after Load lets flags override the file.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.API, "api", c.API, "Base URL of the API")
+	fs.StringVar(&c.PushGateway, "pushGateway", c.PushGateway, "pushgateway addr")
+	fs.StringVar(&c.SpoolDir, "spoolDir", c.SpoolDir, "directory failed pushes are kept in and replayed from once the pushgateway is back, empty drops them")
+	fs.IntVar(&c.SpoolMaxMB, "spoolMaxMB", c.SpoolMaxMB, "size in MB the spool may grow to before the oldest pushes are dropped, 0 means no limit")
+	fs.IntVar(&c.StreamPush, "streamPushSeries", c.StreamPush, "stream pushes of jobs with more series than this with chunked encoding, 0 never streams")
+	fs.IntVar(&c.Interval, "interval", c.Interval, "check interval(min)")
+	fs.Var(&c.AddrFiles, "addrFile", "address file as path or alias=path, repeatable, the lists are merged and labeled source with the alias or file name")
+	fs.BoolVar(&c.WatchAddrFile, "watch-addr-file", c.WatchAddrFile, "reload the address file automatically when it changes")
+	fs.DurationVar(&c.WatchDebounce, "watch-debounce", c.WatchDebounce, "quiet time after the last change before the address file is reloaded")
+	fs.Var(&c.Retiring, "retire", "mark an address as retiring since a date, as addr=2006-01-02, repeatable")
+	fs.DurationVar(&c.RetireGrace, "retireGrace", c.RetireGrace, "how long a retiring address is still collected, without alerts, before it is dropped")
+	fs.Var(&c.Notes, "note", "free-text note of an address pushed as an info label, as addr=text, repeatable")
+	fs.StringVar(&c.DurFile, "durFile", c.DurFile, "durationFile")
+
+	fs.IntVar(&c.Batches, "batches", c.Batches, "split the addresses into this many batches, one queried every interval/batches, 1 queries all every interval")
+	fs.IntVar(&c.Concurrency, "concurrency", c.Concurrency, "API queries running at the same time, 0 means no limit")
+	fs.DurationVar(&c.CycleDeadline, "cycleDeadline", c.CycleDeadline, "time budget of the API queries of a cycle, a query whose last duration no longer fits is skipped, 0 disables it")
+	fs.StringVar(&c.Priority, "collectorPriority", c.Priority, "comma separated queries by importance, e.g. speed/15,reward,height, started first and skipped last under -cycleDeadline")
+	fs.DurationVar(&c.SlowCycle, "slowCycle", c.SlowCycle, "log a timing breakdown of cycles taking longer than this, 0 disables it")
+	fs.DurationVar(&c.HTTPTimeout, "http-timeout", c.HTTPTimeout, "timeout of every API request")
+	fs.Var(&c.EndpointTimeouts, "endpoint-timeouts", "per-endpoint deadlines overriding -http-timeout, e.g. speed=10s,block=5s")
+	fs.DurationVar(&c.HTTPKeepAlive, "http-keep-alive", c.HTTPKeepAlive, "TCP keep-alive period of API and pushgateway connections")
+	fs.IntVar(&c.HTTPMaxIdleConns, "http-max-idle-conns", c.HTTPMaxIdleConns, "idle connections kept open in total")
+	fs.IntVar(&c.HTTPMaxIdleConnsPerHost, "http-max-idle-conns-per-host", c.HTTPMaxIdleConnsPerHost, "idle connections kept open per host")
+	fs.DurationVar(&c.HTTPIdleConnTimeout, "http-idle-conn-timeout", c.HTTPIdleConnTimeout, "how long an idle connection is kept open")
+	fs.IntVar(&c.HTTPTLSSessionCache, "http-tls-session-cache", c.HTTPTLSSessionCache, "TLS sessions cached for resumption")
+	fs.Var(&c.Headers, "header", "static request header as endpoint:Name=Value, endpoint * applies to all, repeatable")
+
+	fs.Var(&c.SpeedEndpoints, "speedEndpoint", "fetch a speed duration window from its own path or URL, as duration=path, repeatable")
+	fs.Var(&c.Transforms, "transform", "rewrite a metric's values before pushing, as metric=scale:x,offset:x,min:x,max:x,round:n, repeatable")
+
+	fs.StringVar(&c.FallbackAPI, "fallbackApi", c.FallbackAPI, "Base URL of the fallback API, fills per-address gaps of the primary API")
+	fs.StringVar(&c.FallbackFor, "fallbackFor", c.FallbackFor, "collectors allowed to use the fallback API")
+	fs.StringVar(&c.PreferFallback, "preferFallback", c.PreferFallback, "collectors where the fallback API takes precedence over the primary API")
+	fs.StringVar(&c.PoolStatsPath, "poolStatsPath", c.PoolStatsPath, "path of the pool stats endpoint (fee/luck/efficiency), empty disables the collector")
+	fs.StringVar(&c.BlockRewardsPath, "blockRewardsPath", c.BlockRewardsPath, "path of the per-block reward attribution endpoint, empty disables the collector")
+	fs.BoolVar(&c.BlockRewardsByEpoch, "blockRewardsByEpoch", c.BlockRewardsByEpoch, "split the per-block reward counters by epoch")
+	fs.BoolVar(&c.RequeryTruncated, "requeryTruncated", c.RequeryTruncated, "re-query the addresses missing from speed and reward lists that sum to less than the total the API reports")
+
+	fs.StringVar(&c.InventoryURL, "inventoryUrl", c.InventoryURL, "inventory API queried per address, {addr} is replaced, answering a JSON object of labels")
+	fs.StringVar(&c.InventoryLabels, "inventoryLabels", c.InventoryLabels, "comma separated inventory fields added as labels to per-address series")
+	fs.StringVar(&c.InventoryCache, "inventoryCache", c.InventoryCache, "file caching inventory answers for when the API is unreachable")
+
+	fs.StringVar(&c.Instance, "instance", c.Instance, "name of this monitor instance, defaults to the hostname")
+	fs.StringVar(&c.Namespace, "namespace", c.Namespace, "prefix of every job name on the pushgateway, e.g. team_a_, migrations only touch jobs of the namespace")
+	fs.BoolVar(&c.InstanceLabel, "instanceLabel", c.InstanceLabel, "add the instance as grouping label to every push")
+	fs.DurationVar(&c.DedupFresh, "dedupFreshness", c.DedupFresh, "stay passive while another instance pushed a heartbeat within this window, 0 disables it")
+	fs.BoolVar(&c.RawValues, "pushRawValues", c.RawValues, "push values that are not numbers as info metrics with the raw value as a label")
+	fs.BoolVar(&c.Strict, "strict", c.Strict, "fail the whole cycle, pushing nothing, when any API answer or value can't be parsed")
+	fs.IntVar(&c.StrictFailures, "strictFailures", c.StrictFailures, "exit after this many consecutive cycles failed in strict mode, 0 never exits")
+	fs.BoolVar(&c.Migrate, "migrate", c.Migrate, "delete pushgateway groups left by older versions before the first cycle")
+
+	fs.StringVar(&c.EventLog, "eventLog", c.EventLog, "write every emitted metric as a JSON line to this file, - for stdout")
+
+	fs.StringVar(&c.InfluxURL, "influxUrl", c.InfluxURL, "InfluxDB URL every emitted metric is also written to in line protocol")
+	fs.BoolVar(&c.InfluxOnly, "influxOnly", c.InfluxOnly, "write to InfluxDB only, without pushing to the pushgateway or serving metrics")
+	fs.StringVar(&c.InfluxDatabase, "influxDatabase", c.InfluxDatabase, "InfluxDB 1.x database")
+	fs.StringVar(&c.InfluxRetentionPolicy, "influxRetentionPolicy", c.InfluxRetentionPolicy, "InfluxDB 1.x retention policy, empty uses the default")
+	fs.StringVar(&c.InfluxUsername, "influxUsername", c.InfluxUsername, "InfluxDB 1.x user")
+	fs.StringVar(&c.InfluxPassword, "influxPassword", c.InfluxPassword, "InfluxDB 1.x password")
+	fs.StringVar(&c.InfluxOrg, "influxOrg", c.InfluxOrg, "InfluxDB 2.x organization")
+	fs.StringVar(&c.InfluxBucket, "influxBucket", c.InfluxBucket, "InfluxDB 2.x bucket, selects the 2.x API")
+	fs.StringVar(&c.InfluxToken, "influxToken", c.InfluxToken, "InfluxDB 2.x API token")
+
+	fs.StringVar(&c.StatsDAddr, "statsdAddr", c.StatsDAddr, "StatsD host:port every emitted metric is also sent to as a gauge over UDP")
+	fs.BoolVar(&c.StatsDOnly, "statsdOnly", c.StatsDOnly, "send to StatsD only, without pushing to the pushgateway or serving metrics")
+	fs.StringVar(&c.StatsDPrefix, "statsdPrefix", c.StatsDPrefix, "prefix of every StatsD metric name, e.g. aleo.")
+	fs.BoolVar(&c.StatsDTags, "statsdTags", c.StatsDTags, "send labels as DogStatsD tags instead of appending them to the metric name")
+
+	fs.StringVar(&c.DatadogAPIKey, "datadogApiKey", c.DatadogAPIKey, "Datadog API key, every emitted metric is also submitted to the Datadog metrics API")
+	fs.StringVar(&c.DatadogURL, "datadogUrl", c.DatadogURL, "Datadog API URL of the site, e.g. https://api.datadoghq.eu")
+	fs.BoolVar(&c.DatadogOnly, "datadogOnly", c.DatadogOnly, "submit to Datadog only, without pushing to the pushgateway or serving metrics")
+	fs.StringVar(&c.DatadogPrefix, "datadogPrefix", c.DatadogPrefix, "prefix of every Datadog metric name, e.g. aleo.")
+
+	fs.StringVar(&c.GraphiteAddr, "graphiteAddr", c.GraphiteAddr, "Carbon host:port every emitted metric is also written to in the Graphite plaintext protocol")
+	fs.BoolVar(&c.GraphiteOnly, "graphiteOnly", c.GraphiteOnly, "write to Graphite only, without pushing to the pushgateway or serving metrics")
+	fs.StringVar(&c.GraphitePrefix, "graphitePrefix", c.GraphitePrefix, "prefix of every Graphite path, e.g. aleo")
+	fs.Var(&c.GraphiteTemplates, "graphiteTemplate", "Graphite path of a metric as metric=template with {name} and {label} placeholders, * for all others, repeatable")
+
+	fs.StringVar(&c.ExporterListen, "exporter-listen", c.ExporterListen, "serve metrics on this address under /metrics instead of pushing to the pushgateway")
+	fs.StringVar(&c.AdminListen, "adminListen", c.AdminListen, "address of the admin HTTP listener, empty disables it")
+	fs.StringVar(&c.AdminToken, "adminToken", c.AdminToken, "bearer token required by admin calls that change settings, empty refuses all changes")
+	fs.StringVar(&c.AgentListen, "agentListen", c.AgentListen, "address prover agents POST their self-measured speed to under /speed, empty disables it")
+	fs.StringVar(&c.AgentToken, "agentToken", c.AgentToken, "bearer token agents must send, empty accepts every report")
+
+	fs.StringVar(&c.SolutionsURL, "solutionsWsUrl", c.SolutionsURL, "pool WebSocket feed of accepted solutions counted per address, empty disables it")
+	fs.DurationVar(&c.SolutionsPush, "solutionsPushInterval", c.SolutionsPush, "how often the solution counters are pushed, independent of the cycle")
+	fs.DurationVar(&c.SolutionsIdle, "solutionsIdleTimeout", c.SolutionsIdle, "reconnect the solution feed after it was silent this long, 0 never does")
+
+	fs.Float64Var(&c.AlertMinSpeed, "alertMinSpeed", c.AlertMinSpeed, "fire speed_low when a prover's speed is at or below this value, 0 disables it")
+	fs.Float64Var(&c.AlertClearSpeed, "alertClearSpeed", c.AlertClearSpeed, "resolve speed_low only once the speed is above this value, 0 resolves at -alertMinSpeed")
+	fs.IntVar(&c.AlertFlapChanges, "alertFlapChanges", c.AlertFlapChanges, "fire prover_flapping instead of prover_offline for a prover going up or down more than this often within -alertFlapWindow, 0 disables it")
+	fs.Float64Var(&c.AlertMinTotal, "alertMinTotalSpeed", c.AlertMinTotal, "fire the critical fleet_speed_collapse when the fleet speed is at or below this value, 0 disables it")
+	fs.Float64Var(&c.AlertClearTotal, "alertClearTotalSpeed", c.AlertClearTotal, "resolve fleet_speed_collapse only once the fleet speed is above this value, 0 resolves at -alertMinTotalSpeed")
+	fs.Float64Var(&c.AlertFleetDrop, "alertFleetDropPercent", c.AlertFleetDrop, "fire the critical fleet_speed_drop when the fleet speed fell by at least this many percent since the last cycle, it resolves once the fleet is back within this of its speed before the drop, 0 disables it")
+	fs.DurationVar(&c.AlertClockSkew, "alertClockSkew", c.AlertClockSkew, "fire clock_skew when the local clock is off the API server's Date header by this much, 0 disables it")
+	fs.IntVar(&c.AlertAPIDown, "alertApiDownCycles", c.AlertAPIDown, "fire the critical api_unreachable after this many cycles where every query failed, 0 disables it")
+	fs.StringVar(&c.AlertHistoryFile, "alertHistoryFile", c.AlertHistoryFile, "file persisting the alert history across restarts")
+	fs.IntVar(&c.AlertHistorySize, "alertHistorySize", c.AlertHistorySize, "number of alert events kept in the history")
+	fs.DurationVar(&c.AlertCooldown, "alertCooldown", c.AlertCooldown, "hold back notifications of an alert firing again within this long of its last one, 0 notifies every change")
+	fs.DurationVar(&c.AlertFlapWindow, "alertFlapWindow", c.AlertFlapWindow, "window prover up/down changes are counted in for prover_flapping")
+	fs.StringVar(&c.SilenceFile, "silenceFile", c.SilenceFile, "file persisting silences added through the admin listener")
+	fs.StringVar(&c.DigestAt, "digestAt", c.DigestAt, "local time of day (HH:MM) a digest of the last 24h is sent through the notification channels, empty disables it")
+	fs.StringVar(&c.DigestChannels, "digestChannels", c.DigestChannels, "comma separated notification channels receiving the digest, empty sends it to all")
+	fs.Var(&c.QuietHours, "quietHours", "quiet hours of a notification channel as telegram=22:00-07:00, only critical alerts are sent then, /warning also sends warnings, repeatable")
+
+	fs.StringVar(&c.TelegramToken, "telegramToken", c.TelegramToken, "Telegram bot token to send alerts with")
+	fs.StringVar(&c.TelegramChatID, "telegramChatId", c.TelegramChatID, "Telegram chat receiving alerts")
+
+	fs.StringVar(&c.SlackWebhook, "slackWebhook", c.SlackWebhook, "Slack incoming webhook URL receiving alerts")
+	fs.StringVar(&c.SlackTemplate, "slackTemplate", c.SlackTemplate, "Go template of Slack messages, rendered with the alert event")
+
+	fs.StringVar(&c.DiscordWebhook, "discordWebhook", c.DiscordWebhook, "Discord webhook URL receiving alerts")
+
+	fs.StringVar(&c.PagerDutyKey, "pagerDutyKey", c.PagerDutyKey, "PagerDuty Events API v2 routing key opening incidents for alerts")
+	fs.StringVar(&c.PagerDutySeverities, "pagerDutySeverities", c.PagerDutySeverities, "comma separated alert severities sent to PagerDuty, empty sends all")
+	fs.StringVar(&c.PagerDutyURL, "pagerDutyUrl", c.PagerDutyURL, "PagerDuty Events API endpoint, e.g. https://events.eu.pagerduty.com/v2/enqueue for the EU region")
+
+	fs.StringVar(&c.OpsgenieKey, "opsgenieKey", c.OpsgenieKey, "Opsgenie API key creating alerts, closed again on recovery")
+	fs.StringVar(&c.OpsgenieSeverities, "opsgenieSeverities", c.OpsgenieSeverities, "comma separated alert severities sent to Opsgenie, empty sends all")
+	fs.StringVar(&c.OpsgenieURL, "opsgenieUrl", c.OpsgenieURL, "Opsgenie API base URL, e.g. https://api.eu.opsgenie.com for the EU instance")
+
+	fs.StringVar(&c.AlertmanagerURL, "alertmanagerUrl", c.AlertmanagerURL, "Alertmanager base URL alerts are posted to through the v2 API")
+
+	fs.StringVar(&c.DingTalkWebhook, "dingTalkWebhook", c.DingTalkWebhook, "DingTalk group robot webhook URL receiving alerts")
+	fs.StringVar(&c.DingTalkSecret, "dingTalkSecret", c.DingTalkSecret, "secret of a DingTalk robot with signing enabled")
+
+	fs.StringVar(&c.WeComWebhook, "weComWebhook", c.WeComWebhook, "WeCom group robot webhook URL receiving alerts")
+
+	fs.StringVar(&c.Webhook, "webhook", c.Webhook, "URL alert events are POSTed to as JSON")
+	fs.StringVar(&c.WebhookTemplate, "webhookTemplate", c.WebhookTemplate, "Go template of the webhook JSON body, rendered with the alert event, empty posts the event itself")
+
+	fs.StringVar(&c.SMTPAddr, "smtpAddr", c.SMTPAddr, "SMTP server host:port alert mails are sent through, empty disables email")
+	fs.StringVar(&c.SMTPUsername, "smtpUsername", c.SMTPUsername, "SMTP username, empty sends without authentication")
+	fs.StringVar(&c.SMTPPassword, "smtpPassword", c.SMTPPassword, "SMTP password")
+	fs.StringVar(&c.SMTPFrom, "smtpFrom", c.SMTPFrom, "sender address of alert mails")
+	fs.StringVar(&c.SMTPTo, "smtpTo", c.SMTPTo, "comma separated recipients of alert mails")
+	fs.StringVar(&c.SMTPTLS, "smtpTls", c.SMTPTLS, "SMTP encryption: auto, tls, starttls or none")
+	fs.StringVar(&c.EmailSummaryAt, "emailSummaryAt", c.EmailSummaryAt, "local time of day (HH:MM) a summary of the last 24h is mailed, empty disables it")
+
+	fs.StringVar(&c.TwilioSID, "twilioSid", c.TwilioSID, "Twilio account SID texting alerts")
+	fs.StringVar(&c.TwilioToken, "twilioToken", c.TwilioToken, "Twilio auth token")
+	fs.StringVar(&c.TwilioFrom, "twilioFrom", c.TwilioFrom, "Twilio phone number or messaging service SID texts are sent from")
+	fs.StringVar(&c.TwilioTo, "twilioTo", c.TwilioTo, "comma separated phone numbers alerts are texted to")
+	fs.StringVar(&c.TwilioSeverities, "twilioSeverities", c.TwilioSeverities, "comma separated alert severities texted, empty sends all")
+
+	fs.StringVar(&c.FeishuWebhook, "feishuWebhook", c.FeishuWebhook, "Feishu/Lark custom bot webhook URL receiving alert cards")
+	fs.StringVar(&c.FeishuSecret, "feishuSecret", c.FeishuSecret, "secret of a Feishu bot with signature verification enabled")
+	fs.StringVar(&c.FeishuSummaryAt, "feishuSummaryAt", c.FeishuSummaryAt, "local time of day (HH:MM) a summary of the last 24h is sent to Feishu, empty disables it")
+
+	fs.StringVar(&c.HistoryFile, "historyFile", c.HistoryFile, "file persisting per-address speed and reward history and collector runs, empty keeps them in memory")
+	fs.DurationVar(&c.HistoryRetention, "historyRetention", c.HistoryRetention, "how long per-address history is kept")
+	fs.StringVar(&c.PostgresDSN, "postgresDsn", c.PostgresDSN, "PostgreSQL connection string every cycle's per-address speed, reward and height are inserted with, empty disables it")
+	fs.StringVar(&c.PostgresTable, "postgresTable", c.PostgresTable, "PostgreSQL table of the history, created if missing")
+	fs.BoolVar(&c.PostgresTimescale, "postgresTimescale", c.PostgresTimescale, "make the history table a TimescaleDB hypertable")
+	fs.StringVar(&c.ClickHouseURL, "clickhouseUrl", c.ClickHouseURL, "ClickHouse HTTP interface URL every cycle's per-address speed, reward and height are inserted through, empty disables it")
+	fs.StringVar(&c.ClickHouseDatabase, "clickhouseDatabase", c.ClickHouseDatabase, "ClickHouse database of the history table")
+	fs.StringVar(&c.ClickHouseTable, "clickhouseTable", c.ClickHouseTable, "ClickHouse history table, created if missing")
+	fs.StringVar(&c.ClickHouseUsername, "clickhouseUsername", c.ClickHouseUsername, "ClickHouse user")
+	fs.StringVar(&c.ClickHousePassword, "clickhousePassword", c.ClickHousePassword, "ClickHouse password")
+	fs.DurationVar(&c.ClickHouseTTL, "clickhouseTtl", c.ClickHouseTTL, "TTL of the rows of a created ClickHouse table in whole days, 0 keeps them forever")
+	fs.StringVar(&c.ArchiveDir, "archiveDir", c.ArchiveDir, "directory every cycle's per-address speed, reward and height are archived to in rotating files, empty disables it")
+	fs.StringVar(&c.ArchiveFormat, "archiveFormat", c.ArchiveFormat, "format of the archive files, csv or parquet")
+	fs.DurationVar(&c.ArchiveRotate, "archiveRotate", c.ArchiveRotate, "time window of one archive file")
+	fs.StringVar(&c.ArchiveS3URL, "archiveS3Url", c.ArchiveS3URL, "S3 endpoint with bucket and key prefix finished archive files are uploaded to, e.g. https://s3.eu-central-1.amazonaws.com/bucket/aleo")
+	fs.StringVar(&c.ArchiveS3Region, "archiveS3Region", c.ArchiveS3Region, "S3 region the uploads are signed for")
+	fs.StringVar(&c.ArchiveS3AccessKey, "archiveS3AccessKey", c.ArchiveS3AccessKey, "S3 access key ID")
+	fs.StringVar(&c.ArchiveS3SecretKey, "archiveS3SecretKey", c.ArchiveS3SecretKey, "S3 secret access key")
+	fs.BoolVar(&c.ArchiveS3Remove, "archiveS3Remove", c.ArchiveS3Remove, "remove archive files once uploaded")
+	fs.StringVar(&c.SQLiteFile, "sqliteFile", c.SQLiteFile, "SQLite database every cycle and its per-address speed, reward and height are recorded in, empty disables it")
+	fs.DurationVar(&c.SQLiteRetention, "sqliteRetention", c.SQLiteRetention, "how long cycles are kept in the SQLite database, 0 keeps them forever")
+
+	fs.DurationVar(&c.EfficiencyWindow, "effWindow", c.EfficiencyWindow, "window of the credits per TH efficiency metric")
+	fs.IntVar(&c.EpochLength, "epochLength", c.EpochLength, "blocks per epoch, used when the chain endpoint sends no epoch, 0 disables it")
+
+	fs.DurationVar(&c.ForecastWindow, "forecastWindow", c.ForecastWindow, "history the fleet speed trend is fitted over")
+	fs.DurationVar(&c.ForecastHorizon, "forecastHorizon", c.ForecastHorizon, "how far ahead the fleet speed forecast looks")
+
+	fs.DurationVar(&c.WatchdogInterval, "watchdogInterval", c.WatchdogInterval, "how often the watchdog samples goroutines and heap, 0 disables it")
+	fs.IntVar(&c.WatchdogMaxGoroutines, "watchdogMaxGoroutines", c.WatchdogMaxGoroutines, "goroutine count treated as a leak, 0 disables the check")
+	fs.IntVar(&c.WatchdogMaxHeapMB, "watchdogMaxHeapMB", c.WatchdogMaxHeapMB, "heap size in MB treated as a leak, 0 disables the check")
+	fs.BoolVar(&c.WatchdogRestart, "watchdogRestart", c.WatchdogRestart, "restart the collection subsystem when the watchdog detects a leak")
+
+	fs.StringVar(&c.SpeedEMA, "speedEma", c.SpeedEMA, "comma separated EMA alphas in (0,1] to push smoothed speeds for, empty disables it")
+	fs.Var(&c.Derived, "derived", "derived per-address metric as name=expression over the collected values, repeatable")
+
+	fs.Float64Var(&c.RestartDipRatio, "restartDipRatio", c.RestartDipRatio, "speed below this fraction of the baseline starts a restart dip")
+	fs.Float64Var(&c.RestartRecoverRatio, "restartRecoverRatio", c.RestartRecoverRatio, "speed back above this fraction of the baseline completes a restart")
+	fs.IntVar(&c.RestartMaxCycles, "restartMaxCycles", c.RestartMaxCycles, "cycles a dip may last and still count as a restart")
+}
+
+// Load reads a YAML file over c, keys missing from the file keep their value.
+func Load(path string, c *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parse config %s: %v", path, err)
+	}
+	return nil
+}
+
+// ResetRepeated empties the values of the repeatable and map flags set in
+// fs, so parsing the flags again over a loaded file replaces the file's
+// values rather than adding to them.
+func ResetRepeated(fs *flag.FlagSet) {
+	fs.Visit(resetValue)
+}
+
+func resetValue(f *flag.Flag) {
+	v := reflect.ValueOf(f.Value)
+	if v.Kind() != reflect.Pointer {
+		return
+	}
+	if e := v.Elem(); e.Kind() == reflect.Map || e.Kind() == reflect.Slice {
+		e.Set(reflect.Zero(e.Type()))
+	}
+}
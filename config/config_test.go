@@ -0,0 +1,112 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parse mimics the command line parsing of main: flags, the file over them,
+// the environment over the file, then the flags again.
+func parse(t *testing.T, file string, args ...string) Config {
+	t.Helper()
+	return parseEnv(t, file, nil, args...)
+}
+
+func parseEnv(t *testing.T, file string, env map[string]string, args ...string) Config {
+	t.Helper()
+	c := Default()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(file, &c); err != nil {
+		t.Fatal(err)
+	}
+	ResetRepeated(fs)
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	if _, err := LoadEnv(fs, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestFlagsReplaceFileValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := "addr_file:\n  - team-a=/etc/a.txt\n  - /etc/b.txt\nnotes:\n  aleo1abc: from file\ninterval: 3\n"
+	if err := os.WriteFile(file, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := parse(t, file)
+	if got := c.AddrFiles.String(); got != "team-a=/etc/a.txt /etc/b.txt" {
+		t.Errorf("file address files = %q", got)
+	}
+	if c.Notes["aleo1abc"] != "from file" || c.Interval != 3 {
+		t.Errorf("file values lost: notes %v, interval %d", c.Notes, c.Interval)
+	}
+
+	c = parse(t, file, "-addrFile", "/etc/c.txt", "-addrFile", "ops=/etc/d.txt", "-note", "aleo1xyz=from flag", "-interval", "7")
+	if got := c.AddrFiles.String(); got != "/etc/c.txt ops=/etc/d.txt" {
+		t.Errorf("address files = %q, want the flags only", got)
+	}
+	if len(c.Notes) != 1 || c.Notes["aleo1xyz"] != "from flag" {
+		t.Errorf("notes = %v, want the flag only", c.Notes)
+	}
+	if c.Interval != 7 {
+		t.Errorf("interval = %d, want 7", c.Interval)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for name, want := range map[string]string{
+		"api":           "ALEO_MONITOR_API",
+		"pushGateway":   "ALEO_MONITOR_PUSH_GATEWAY",
+		"datadogApiKey": "ALEO_MONITOR_DATADOG_API_KEY",
+		"archiveS3Url":  "ALEO_MONITOR_ARCHIVE_S3_URL",
+	} {
+		if got := EnvName(name); got != want {
+			t.Errorf("EnvName(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestEnvBetweenFileAndFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := "addr_file:\n  - /etc/a.txt\n  - /etc/b.txt\ninterval: 3\nnamespace: file\ninstance: file\n"
+	if err := os.WriteFile(file, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"ALEO_MONITOR_INTERVAL":  "5",
+		"ALEO_MONITOR_ADDR_FILE": "/etc/env.txt",
+		"ALEO_MONITOR_INSTANCE":  "env",
+	}
+
+	c := parseEnv(t, file, env, "-interval", "7")
+	if c.Interval != 7 {
+		t.Errorf("interval = %d, want the flag over the environment", c.Interval)
+	}
+	if got := c.AddrFiles.String(); got != "/etc/env.txt" {
+		t.Errorf("address files = %q, want the environment over the file", got)
+	}
+	if c.Instance != "env" || c.Namespace != "file" {
+		t.Errorf("instance %q namespace %q, want env and file", c.Instance, c.Namespace)
+	}
+
+	lookup := func(string) (string, bool) { return "soon", true }
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c = Default()
+	c.RegisterFlags(fs)
+	if _, err := LoadEnv(fs, lookup); err == nil {
+		t.Error("bad environment value accepted")
+	}
+}
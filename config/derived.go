@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"aleo-prover-monitor/derive"
+)
+
+// Derived maps the name of a derived metric to its expression, as a flag it
+// is repeated as "reward_per_speed=reward_delta / speed_15m".
+type Derived map[string]string
+
+func (d *Derived) String() string {
+	if d == nil || *d == nil {
+		return ""
+	}
+	var parts []string
+	for name, expr := range *d {
+		parts = append(parts, name+"="+expr)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+func (d *Derived) Set(s string) error {
+	name, expr, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("want name=expression, got %q", s)
+	}
+	if _, err := derive.ParseExpr(expr); err != nil {
+		return fmt.Errorf("derived metric %s: %v", name, err)
+	}
+
+	if *d == nil {
+		*d = make(Derived)
+	}
+	(*d)[name] = strings.TrimSpace(expr)
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SpeedEndpoints maps a speed duration window to the path, or full URL, it
+// is fetched from. As a flag it is repeated as "1440=/api/v1/daily_speed".
+type SpeedEndpoints map[int]string
+
+func (e *SpeedEndpoints) String() string {
+	if e == nil || *e == nil {
+		return ""
+	}
+	var parts []string
+	for d, path := range *e {
+		parts = append(parts, strconv.Itoa(d)+"="+path)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (e *SpeedEndpoints) Set(s string) error {
+	window, path, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return fmt.Errorf("want duration=path, got %q", s)
+	}
+	d, err := strconv.Atoi(strings.TrimSpace(window))
+	if err != nil {
+		return fmt.Errorf("duration %q: %v", window, err)
+	}
+	if *e == nil {
+		*e = make(SpeedEndpoints)
+	}
+	(*e)[d] = strings.TrimSpace(path)
+	return nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix starts the environment variables overriding the config, one per
+// flag like ALEO_MONITOR_PUSH_GATEWAY for -pushGateway.
+const EnvPrefix = "ALEO_MONITOR_"
+
+// EnvName returns the environment variable of the flag name.
+func EnvName(name string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// LoadEnv sets the config flags of fs not given on the command line from
+// their environment variables, looked up with lookup, and returns the
+// variables it applied. A repeatable or map flag takes one value that
+// replaces the file's values.
+func LoadEnv(fs *flag.FlagSet, lookup func(string) (string, bool)) ([]string, error) {
+	var known Config
+	names := flag.NewFlagSet("", flag.ContinueOnError)
+	known.RegisterFlags(names)
+
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var applied []string
+	var err error
+	names.VisitAll(func(f *flag.Flag) {
+		if err != nil || given[f.Name] || fs.Lookup(f.Name) == nil {
+			return
+		}
+		env := EnvName(f.Name)
+		value, ok := lookup(env)
+		if !ok {
+			return
+		}
+		resetValue(fs.Lookup(f.Name))
+		if serr := fs.Set(f.Name, value); serr != nil {
+			err = fmt.Errorf("%s: %v", env, serr)
+			return
+		}
+		applied = append(applied, env)
+	})
+	return applied, err
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GraphiteTemplates maps a metric name, or "*" for all others, to its
+// Graphite path template, as a flag it is repeated as
+// "aleo_prover_speed=provers.{addr}.speed.{duration}".
+type GraphiteTemplates map[string]string
+
+func (t *GraphiteTemplates) String() string {
+	if t == nil || *t == nil {
+		return ""
+	}
+	var parts []string
+	for name, tmpl := range *t {
+		parts = append(parts, name+"="+tmpl)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+func (t *GraphiteTemplates) Set(s string) error {
+	name, tmpl, ok := strings.Cut(s, "=")
+	name, tmpl = strings.TrimSpace(name), strings.TrimSpace(tmpl)
+	if !ok || name == "" || tmpl == "" {
+		return fmt.Errorf("want metric=template, got %q", s)
+	}
+	if strings.Count(tmpl, "{") != strings.Count(tmpl, "}") {
+		return fmt.Errorf("graphite template %s: unbalanced braces in %q", name, tmpl)
+	}
+
+	if *t == nil {
+		*t = make(GraphiteTemplates)
+	}
+	(*t)[name] = tmpl
+	return nil
+}
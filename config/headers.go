@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Headers maps an endpoint name, or "*" for all of them, to static request
+// headers. As a flag it is repeated as "endpoint:Name=Value".
+type Headers map[string]map[string]string
+
+func (h *Headers) String() string {
+	if h == nil || *h == nil {
+		return ""
+	}
+	var parts []string
+	for endpoint, headers := range *h {
+		for name, value := range headers {
+			parts = append(parts, endpoint+":"+name+"="+value)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (h *Headers) Set(s string) error {
+	endpoint, header, ok := strings.Cut(s, ":")
+	if !ok {
+		return fmt.Errorf("want endpoint:Name=Value, got %q", s)
+	}
+	name, value, ok := strings.Cut(header, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("want endpoint:Name=Value, got %q", s)
+	}
+
+	if *h == nil {
+		*h = make(Headers)
+	}
+	endpoint = strings.TrimSpace(endpoint)
+	if (*h)[endpoint] == nil {
+		(*h)[endpoint] = make(map[string]string)
+	}
+	(*h)[endpoint][strings.TrimSpace(name)] = value
+	return nil
+}
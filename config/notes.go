@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Notes maps an address to a free-text note, as a flag it is repeated as
+// "addr=PSU replaced 2024-05-01".
+type Notes map[string]string
+
+func (n *Notes) String() string {
+	if n == nil || *n == nil {
+		return ""
+	}
+	var parts []string
+	for addr, note := range *n {
+		parts = append(parts, addr+"="+note)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+func (n *Notes) Set(s string) error {
+	addr, note, ok := strings.Cut(s, "=")
+	addr = strings.TrimSpace(addr)
+	if !ok || addr == "" {
+		return fmt.Errorf("want addr=note, got %q", s)
+	}
+
+	if *n == nil {
+		*n = make(Notes)
+	}
+	(*n)[addr] = strings.TrimSpace(note)
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "<redacted>"
+
+// Redacted returns a copy of c with header values and URL passwords masked,
+// safe to share in support requests.
+func (c Config) Redacted() Config {
+	c.API = redactURL(c.API)
+	c.PushGateway = redactURL(c.PushGateway)
+	c.FallbackAPI = redactURL(c.FallbackAPI)
+	c.AlertmanagerURL = redactURL(c.AlertmanagerURL)
+	c.InventoryURL = redactURL(c.InventoryURL)
+	c.Webhook = redactURL(c.Webhook)
+	c.InfluxURL = redactURL(c.InfluxURL)
+	c.ClickHouseURL = redactURL(c.ClickHouseURL)
+	for _, secret := range []*string{&c.AdminToken, &c.AgentToken, &c.TelegramToken, &c.SlackWebhook, &c.DiscordWebhook, &c.PagerDutyKey, &c.OpsgenieKey, &c.DingTalkWebhook, &c.DingTalkSecret, &c.WeComWebhook, &c.FeishuWebhook, &c.FeishuSecret, &c.SMTPPassword, &c.InfluxPassword, &c.InfluxToken, &c.TwilioToken, &c.DatadogAPIKey, &c.PostgresDSN, &c.ClickHousePassword, &c.ArchiveS3SecretKey} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+
+	if c.SpeedEndpoints != nil {
+		endpoints := make(SpeedEndpoints, len(c.SpeedEndpoints))
+		for d, path := range c.SpeedEndpoints {
+			endpoints[d] = redactURL(path)
+		}
+		c.SpeedEndpoints = endpoints
+	}
+
+	if c.Headers != nil {
+		headers := make(Headers, len(c.Headers))
+		for endpoint, values := range c.Headers {
+			headers[endpoint] = make(map[string]string, len(values))
+			for name := range values {
+				headers[endpoint][name] = redacted
+			}
+		}
+		c.Headers = headers
+	}
+	return c
+}
+
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Redacted()
+}
+
+// Print writes c as YAML with secrets redacted.
+func Print(w io.Writer, c Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Hash fingerprints c for comparing replicas, the instance name is left out
+// since it differs by design.
+func Hash(c Config) string {
+	c.Instance = ""
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
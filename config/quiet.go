@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"aleo-prover-monitor/alert"
+)
+
+// QuietHours maps a notification channel to its quiet hours, as a flag it is
+// repeated as "telegram=22:00-07:00" or "telegram=22:00-07:00/warning" to
+// still deliver warnings.
+type QuietHours map[string]alert.QuietHours
+
+func (q *QuietHours) String() string {
+	if q == nil || *q == nil {
+		return ""
+	}
+	var parts []string
+	for channel, h := range *q {
+		part := channel + "=" + h.From + "-" + h.To
+		if h.Deliver != "" {
+			part += "/" + h.Deliver
+		}
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+func (q *QuietHours) Set(s string) error {
+	channel, value, ok := strings.Cut(s, "=")
+	channel = strings.TrimSpace(channel)
+	if !ok || channel == "" {
+		return fmt.Errorf("want channel=HH:MM-HH:MM, got %q", s)
+	}
+	window, deliver, _ := strings.Cut(strings.TrimSpace(value), "/")
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return fmt.Errorf("channel %s: want HH:MM-HH:MM, got %q", channel, value)
+	}
+	h := alert.QuietHours{From: strings.TrimSpace(from), To: strings.TrimSpace(to), Deliver: strings.TrimSpace(deliver)}
+	if err := h.Validate(); err != nil {
+		return fmt.Errorf("channel %s: %v", channel, err)
+	}
+
+	if *q == nil {
+		*q = make(QuietHours)
+	}
+	(*q)[channel] = h
+	return nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Retiring maps an address to when its retirement began, as a flag it is
+// repeated as "addr=2006-01-02" or with an RFC 3339 time.
+type Retiring map[string]time.Time
+
+func (r *Retiring) String() string {
+	if r == nil || *r == nil {
+		return ""
+	}
+	var parts []string
+	for addr, since := range *r {
+		parts = append(parts, addr+"="+since.Format(time.RFC3339))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+func (r *Retiring) Set(s string) error {
+	addr, value, ok := strings.Cut(s, "=")
+	addr = strings.TrimSpace(addr)
+	if !ok || addr == "" {
+		return fmt.Errorf("want addr=date, got %q", s)
+	}
+	value = strings.TrimSpace(value)
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if since, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return fmt.Errorf("address %s: want a date or RFC 3339 time, got %q", addr, value)
+		}
+	}
+
+	if *r == nil {
+		*r = make(Retiring)
+	}
+	(*r)[addr] = since
+	return nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Timeouts maps an endpoint name to its deadline, as a flag it is written as
+// "speed=10s,block=5s".
+type Timeouts map[string]time.Duration
+
+func (t *Timeouts) String() string {
+	if t == nil || *t == nil {
+		return ""
+	}
+	var parts []string
+	for name, d := range *t {
+		parts = append(parts, name+"="+d.String())
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (t *Timeouts) Set(s string) error {
+	parsed := make(Timeouts)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return fmt.Errorf("want endpoint=duration, got %q", part)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("endpoint %s: %v", name, err)
+		}
+		parsed[strings.TrimSpace(name)] = d
+	}
+	*t = parsed
+	return nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Transform is applied to a metric's values before they are pushed: scaled,
+// offset, clamped to [Min, Max], then rounded to Round decimals.
+type Transform struct {
+	Scale  *float64 `yaml:"scale,omitempty"`
+	Offset float64  `yaml:"offset,omitempty"`
+	Min    *float64 `yaml:"min,omitempty"`
+	Max    *float64 `yaml:"max,omitempty"`
+	Round  *int     `yaml:"round,omitempty"`
+}
+
+func (t Transform) Apply(v float64) float64 {
+	if t.Scale != nil {
+		v *= *t.Scale
+	}
+	v += t.Offset
+	if t.Min != nil {
+		v = math.Max(v, *t.Min)
+	}
+	if t.Max != nil {
+		v = math.Min(v, *t.Max)
+	}
+	if t.Round != nil {
+		pow := math.Pow(10, float64(*t.Round))
+		v = math.Round(v*pow) / pow
+	}
+	return v
+}
+
+func (t Transform) String() string {
+	var parts []string
+	if t.Scale != nil {
+		parts = append(parts, "scale:"+strconv.FormatFloat(*t.Scale, 'g', -1, 64))
+	}
+	if t.Offset != 0 {
+		parts = append(parts, "offset:"+strconv.FormatFloat(t.Offset, 'g', -1, 64))
+	}
+	if t.Min != nil {
+		parts = append(parts, "min:"+strconv.FormatFloat(*t.Min, 'g', -1, 64))
+	}
+	if t.Max != nil {
+		parts = append(parts, "max:"+strconv.FormatFloat(*t.Max, 'g', -1, 64))
+	}
+	if t.Round != nil {
+		parts = append(parts, "round:"+strconv.Itoa(*t.Round))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Transforms maps a metric name to its transform, as a flag it is repeated
+// as "metric=scale:0.000001,min:0,round:6".
+type Transforms map[string]Transform
+
+func (t *Transforms) String() string {
+	if t == nil || *t == nil {
+		return ""
+	}
+	var parts []string
+	for name, tr := range *t {
+		parts = append(parts, name+"="+tr.String())
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+func (t *Transforms) Set(s string) error {
+	name, spec, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("want metric=scale:x,offset:x,min:x,max:x,round:n, got %q", s)
+	}
+
+	var tr Transform
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		op, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return fmt.Errorf("metric %s: want op:value, got %q", name, part)
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("metric %s: %v", name, err)
+		}
+		switch op {
+		case "scale":
+			tr.Scale = &v
+		case "offset":
+			tr.Offset = v
+		case "min":
+			tr.Min = &v
+		case "max":
+			tr.Max = &v
+		case "round":
+			decimals, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("metric %s: round wants a number of decimals, got %q", name, value)
+			}
+			tr.Round = &decimals
+		default:
+			return fmt.Errorf("metric %s: unknown transform %q", name, op)
+		}
+	}
+
+	if *t == nil {
+		*t = make(Transforms)
+	}
+	(*t)[name] = tr
+	return nil
+}
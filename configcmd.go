@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"aleo-prover-monitor/config"
+)
+
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: config print [-config file] [flags]")
+		fmt.Fprintln(os.Stderr, "prints the file, the "+config.EnvPrefix+"* environment variables and the flags merged")
+		return 2
+	}
+
+	parseConfig(flag.NewFlagSet("config print", flag.ExitOnError), args[1:])
+	for _, env := range fromEnv {
+		fmt.Printf("# %s set from the environment\n", env)
+	}
+	if err := config.Print(os.Stdout, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "print config: %v\n", err)
+		return 1
+	}
+	return 0
+}
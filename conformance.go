@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"aleo-prover-monitor/apiclient"
+)
+
+func runConformance(args []string) int {
+	fs := flag.NewFlagSet("conformance", flag.ExitOnError)
+	api := fs.String("api", cfg.API, "Base URL of the API to check")
+	addr := fs.String("addr", "", "sample prover address to query")
+	duration := fs.Int("duration", 15, "speed duration window to query")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of every request")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	if *addr == "" {
+		fmt.Fprintln(os.Stderr, "conformance: -addr is required")
+		return 2
+	}
+
+	client := apiclient.New(*api, nil)
+	client.Timeouts = map[string]time.Duration{"speed": *timeout, "reward": *timeout, "height": *timeout, "block": *timeout}
+	reports := apiclient.CheckConformance(context.Background(), client, apiclient.NormalizeAddress(*addr), *duration)
+
+	ok := true
+	for _, r := range reports {
+		ok = ok && r.OK()
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(reports)
+	} else {
+		for _, r := range reports {
+			status := "OK"
+			if !r.OK() {
+				status = "FAIL"
+			}
+			fmt.Printf("%-6s %-4s %s\n", r.Endpoint, status, r.Path)
+			if r.Error != "" {
+				fmt.Printf("       error: %s\n", r.Error)
+			}
+			for _, f := range r.Fields {
+				line := fmt.Sprintf("       %-10s %s", f.Status, f.Path)
+				if f.Detail != "" {
+					line += " (" + f.Detail + ")"
+				}
+				fmt.Println(line)
+			}
+		}
+	}
+
+	if !ok {
+		return 1
+	}
+	return 0
+}
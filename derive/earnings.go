@@ -0,0 +1,14 @@
+package derive
+
+const secondsPerDay = 24 * 60 * 60
+
+// DailyEarnings estimates the credits a prover earns per day: at speed
+// solutions per second it is expected to find speed*86400/proofTarget
+// solutions meeting the target, each worth the current coinbase reward.
+// The result keeps the unit of coinbaseReward.
+func DailyEarnings(speed float64, proofTarget float64, coinbaseReward float64) (float64, bool) {
+	if proofTarget <= 0 {
+		return 0, false
+	}
+	return speed * secondsPerDay / proofTarget * coinbaseReward, true
+}
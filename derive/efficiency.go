@@ -0,0 +1,64 @@
+package derive
+
+import "time"
+
+const tera = 1e12
+
+type workSample struct {
+	at     time.Time
+	reward float64
+	work   float64
+}
+
+// Efficiency tracks reward earned against work done (speed integrated over
+// time) per key across a sliding window.
+type Efficiency struct {
+	window time.Duration
+	series map[string][]workSample
+	speed  map[string]float64
+}
+
+func NewEfficiency(window time.Duration) *Efficiency {
+	return &Efficiency{
+		window: window,
+		series: make(map[string][]workSample),
+		speed:  make(map[string]float64),
+	}
+}
+
+func (e *Efficiency) Observe(key string, at time.Time, speed float64, reward float64) {
+	samples := e.series[key]
+	s := workSample{at: at, reward: reward}
+	if n := len(samples); n > 0 {
+		last := samples[n-1]
+		if reward < last.reward {
+			// cumulative reward went backwards, the upstream counter was reset
+			samples = nil
+		} else {
+			elapsed := at.Sub(last.at).Seconds()
+			s.work = last.work + (e.speed[key]+speed)/2*elapsed
+		}
+	}
+	samples = append(samples, s)
+
+	cut := 0
+	for cut < len(samples)-1 && at.Sub(samples[cut+1].at) >= e.window {
+		cut++
+	}
+	e.series[key] = samples[cut:]
+	e.speed[key] = speed
+}
+
+// CreditsPerTH returns the credits earned per 1e12 units of work over the window.
+func (e *Efficiency) CreditsPerTH(key string) (float64, bool) {
+	samples := e.series[key]
+	if len(samples) < 2 {
+		return 0, false
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	work := last.work - first.work
+	if work <= 0 {
+		return 0, false
+	}
+	return (last.reward - first.reward) / (work / tera), true
+}
@@ -0,0 +1,28 @@
+package derive
+
+// EMA smooths a series per key with an exponential moving average,
+// a larger Alpha follows new samples faster.
+type EMA struct {
+	Alpha float64
+	value map[string]float64
+}
+
+func NewEMA(alpha float64) *EMA {
+	return &EMA{Alpha: alpha, value: make(map[string]float64)}
+}
+
+// Observe feeds one sample and returns the smoothed value, the first sample
+// of a key is taken as is.
+func (e *EMA) Observe(key string, v float64) float64 {
+	prev, ok := e.value[key]
+	if ok {
+		v = prev + e.Alpha*(v-prev)
+	}
+	e.value[key] = v
+	return v
+}
+
+// Value returns the smoothed value of key.
+func (e *EMA) Value(key string) float64 {
+	return e.value[key]
+}
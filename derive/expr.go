@@ -0,0 +1,222 @@
+package derive
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+// Expr is an arithmetic expression over named values: numbers, names,
+// + - * /, unary minus and parentheses.
+type Expr struct {
+	src  string
+	root node
+}
+
+type node interface {
+	eval(vars map[string]float64) (float64, bool)
+}
+
+type num float64
+
+type name string
+
+type neg struct{ x node }
+
+type binary struct {
+	op   byte
+	l, r node
+}
+
+func (n num) eval(map[string]float64) (float64, bool) { return float64(n), true }
+
+func (n name) eval(vars map[string]float64) (float64, bool) {
+	v, ok := vars[string(n)]
+	return v, ok
+}
+
+func (n neg) eval(vars map[string]float64) (float64, bool) {
+	v, ok := n.x.eval(vars)
+	return -v, ok
+}
+
+func (n binary) eval(vars map[string]float64) (float64, bool) {
+	l, ok := n.l.eval(vars)
+	if !ok {
+		return 0, false
+	}
+	r, ok := n.r.eval(vars)
+	if !ok {
+		return 0, false
+	}
+	switch n.op {
+	case '+':
+		return l + r, true
+	case '-':
+		return l - r, true
+	case '*':
+		return l * r, true
+	}
+	if r == 0 {
+		return 0, false
+	}
+	return l / r, true
+}
+
+func ParseExpr(src string) (*Expr, error) {
+	p := &exprParser{src: src}
+	root, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.skip(); p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at %d", p.src[p.pos:], p.pos)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Eval returns the value of the expression, false when a name is missing
+// from vars, it divides by zero or the result is not a finite number.
+func (e *Expr) Eval(vars map[string]float64) (float64, bool) {
+	v, ok := e.root.eval(vars)
+	if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+// Names returns the names the expression uses, sorted.
+func (e *Expr) Names() []string {
+	seen := make(map[string]bool)
+	var walk func(node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case name:
+			seen[string(n)] = true
+		case neg:
+			walk(n.x)
+		case binary:
+			walk(n.l)
+			walk(n.r)
+		}
+	}
+	walk(e.root)
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) skip() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skip()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// sum = product {("+" | "-") product}
+func (p *exprParser) sum() (node, error) {
+	l, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		r, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		l = binary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+// product = unary {("*" | "/") unary}
+func (p *exprParser) product() (node, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = binary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+// unary = "-" unary | "(" sum ")" | number | name
+func (p *exprParser) unary() (node, error) {
+	switch c := p.peek(); {
+	case c == '-':
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return neg{x}, nil
+	case c == '(':
+		p.pos++
+		x, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) at %d", p.pos)
+		}
+		p.pos++
+		return x, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+			p.pos++
+			if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+				p.pos++
+			}
+			for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+				p.pos++
+			}
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q at %d", p.src[start:p.pos], start)
+		}
+		return num(v), nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		return name(p.src[start:p.pos]), nil
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at %d", c, p.pos)
+	}
+}
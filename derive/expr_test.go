@@ -0,0 +1,82 @@
+package derive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExprEval(t *testing.T) {
+	vars := map[string]float64{"speed": 6, "reward": 3, "zero": 0}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"24 / 4 / 2", 3},
+		{"-speed + 1", -5},
+		{"--2", 2},
+		{"-(speed - reward) * 2", -6},
+		{"reward / speed * 100", 50},
+		{"1.5e2 + .5", 150.5},
+		{"speed*reward", 18},
+	}
+	for _, tt := range tests {
+		e, err := ParseExpr(tt.src)
+		if err != nil {
+			t.Errorf("ParseExpr(%q): %v", tt.src, err)
+			continue
+		}
+		got, ok := e.Eval(vars)
+		if !ok || got != tt.want {
+			t.Errorf("%q = %v, %v, want %v", tt.src, got, ok, tt.want)
+		}
+	}
+}
+
+func TestExprEvalUndefined(t *testing.T) {
+	vars := map[string]float64{"speed": 6, "zero": 0}
+	for _, src := range []string{
+		"speed / 0",
+		"speed / zero",
+		"1 + speed / (zero * 2)",
+		"missing + 1",
+		"1e308 * 1e308",
+	} {
+		e, err := ParseExpr(src)
+		if err != nil {
+			t.Errorf("ParseExpr(%q): %v", src, err)
+			continue
+		}
+		if got, ok := e.Eval(vars); ok {
+			t.Errorf("%q = %v, want no value", src, got)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"1 + 2)",
+		"speed reward",
+		"1..2",
+		"speed % 2",
+	} {
+		if _, err := ParseExpr(src); err == nil {
+			t.Errorf("ParseExpr(%q) succeeded, want an error", src)
+		}
+	}
+}
+
+func TestExprNames(t *testing.T) {
+	e, err := ParseExpr("reward / speed + reward * -height")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Names(), []string{"height", "reward", "speed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
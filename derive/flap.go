@@ -0,0 +1,39 @@
+package derive
+
+import "time"
+
+// Flap counts how often the up/down state of every key changed within
+// Window.
+type Flap struct {
+	Window  time.Duration
+	up      map[string]bool
+	changes map[string][]time.Time
+}
+
+func NewFlap(window time.Duration) *Flap {
+	return &Flap{Window: window, up: make(map[string]bool), changes: make(map[string][]time.Time)}
+}
+
+// Observe feeds the state of key at at and returns the changes within the
+// window before it.
+func (f *Flap) Observe(key string, up bool, at time.Time) int {
+	prev, seen := f.up[key]
+	f.up[key] = up
+	changes := f.changes[key]
+	if seen && prev != up {
+		changes = append(changes, at)
+	}
+
+	from := at.Add(-f.Window)
+	i := 0
+	for i < len(changes) && changes[i].Before(from) {
+		i++
+	}
+	changes = changes[i:]
+	if len(changes) == 0 {
+		delete(f.changes, key)
+	} else {
+		f.changes[key] = changes
+	}
+	return len(changes)
+}
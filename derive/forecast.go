@@ -0,0 +1,54 @@
+package derive
+
+import "time"
+
+type point struct {
+	at    time.Time
+	value float64
+}
+
+// Trend fits a least squares line over the samples of a sliding window.
+type Trend struct {
+	window time.Duration
+	points []point
+}
+
+func NewTrend(window time.Duration) *Trend {
+	return &Trend{window: window}
+}
+
+// Observe adds a sample and drops the ones that left the window.
+func (t *Trend) Observe(at time.Time, value float64) {
+	t.points = append(t.points, point{at, value})
+	cut := 0
+	for cut < len(t.points) && at.Sub(t.points[cut].at) > t.window {
+		cut++
+	}
+	t.points = t.points[cut:]
+}
+
+// Predict evaluates the fitted line at the given time, it needs at least
+// three samples spread over time.
+func (t *Trend) Predict(at time.Time) (float64, bool) {
+	n := float64(len(t.points))
+	if n < 3 {
+		return 0, false
+	}
+
+	origin := t.points[0].at
+	var sx, sy, sxx, sxy float64
+	for _, p := range t.points {
+		x := p.at.Sub(origin).Seconds()
+		sx += x
+		sy += p.value
+		sxx += x * x
+		sxy += x * p.value
+	}
+	denom := n*sxx - sx*sx
+	if denom == 0 {
+		return 0, false
+	}
+	slope := (n*sxy - sx*sy) / denom
+	intercept := (sy - slope*sx) / n
+	return intercept + slope*at.Sub(origin).Seconds(), true
+}
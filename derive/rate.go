@@ -0,0 +1,36 @@
+package derive
+
+import "time"
+
+type rateSample struct {
+	at    time.Time
+	value float64
+}
+
+// Rate turns a cumulative value into a per-hour rate from the delta between
+// consecutive observations of the same key.
+type Rate struct {
+	last map[string]rateSample
+}
+
+func NewRate() *Rate {
+	return &Rate{last: make(map[string]rateSample)}
+}
+
+// Observe records value for key and returns the rate per hour since the
+// previous observation. A value below the previous one is a counter reset
+// (pool recount, prover moved): the sample becomes the new baseline and no
+// rate is reported for that cycle.
+func (r *Rate) Observe(key string, at time.Time, value float64) (float64, bool) {
+	last, ok := r.last[key]
+	r.last[key] = rateSample{at: at, value: value}
+	if !ok || value < last.value {
+		return 0, false
+	}
+
+	elapsed := at.Sub(last.at).Hours()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return (value - last.value) / elapsed, true
+}
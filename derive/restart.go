@@ -0,0 +1,71 @@
+package derive
+
+const baselineAlpha = 0.3
+
+type restartState struct {
+	baseline float64
+	seen     bool
+	dipped   int
+	count    int
+}
+
+// RestartDetector counts the dip-and-recover speed pattern of a prover
+// restart: speed falls under DipRatio of its baseline and climbs back over
+// RecoverRatio within MaxCycles observations.
+type RestartDetector struct {
+	DipRatio     float64
+	RecoverRatio float64
+	MaxCycles    int
+	state        map[string]*restartState
+}
+
+func NewRestartDetector(dipRatio float64, recoverRatio float64, maxCycles int) *RestartDetector {
+	return &RestartDetector{
+		DipRatio:     dipRatio,
+		RecoverRatio: recoverRatio,
+		MaxCycles:    maxCycles,
+		state:        make(map[string]*restartState),
+	}
+}
+
+// Observe feeds one speed sample and returns the restarts detected so far.
+func (d *RestartDetector) Observe(addr string, speed float64) int {
+	s, ok := d.state[addr]
+	if !ok {
+		s = &restartState{}
+		d.state[addr] = s
+	}
+	if !s.seen {
+		s.baseline, s.seen = speed, true
+		return s.count
+	}
+
+	if s.dipped > 0 {
+		if speed >= s.baseline*d.RecoverRatio {
+			s.count++
+			s.dipped = 0
+			return s.count
+		}
+		s.dipped++
+		if s.dipped > d.MaxCycles {
+			// down for too long to be a restart, start over from here
+			s.baseline, s.dipped = speed, 0
+		}
+		return s.count
+	}
+
+	if speed < s.baseline*d.DipRatio {
+		s.dipped = 1
+		return s.count
+	}
+	s.baseline += baselineAlpha * (speed - s.baseline)
+	return s.count
+}
+
+// Count returns the restarts detected for addr so far.
+func (d *RestartDetector) Count(addr string) int {
+	if s, ok := d.state[addr]; ok {
+		return s.count
+	}
+	return 0
+}
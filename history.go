@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"aleo-prover-monitor/apiclient"
+	"aleo-prover-monitor/store"
+)
+
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	since := fs.Duration("since", 24*time.Hour, "summarize the cycles of this long ago until now")
+	addr := fs.String("addr", "", "only summarize this address")
+	parseConfig(fs, args)
+
+	if cfg.SQLiteFile == "" {
+		fmt.Fprintln(os.Stderr, "history: no SQLite database configured, set -sqliteFile or sqlite_file")
+		return 2
+	}
+	if _, err := os.Stat(cfg.SQLiteFile); err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		return 1
+	}
+	// no retention, reading must not prune what the monitor keeps
+	db, err := store.OpenSQLite(cfg.SQLiteFile, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	from := time.Now().Add(-*since)
+	cycles, failed, err := db.Cycles(ctx, from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		return 1
+	}
+	stats, err := db.Stats(ctx, from, apiclient.NormalizeAddress(*addr))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("%d cycles since %s, %d with failed queries\n\n", cycles, from.Local().Format(time.RFC3339), failed)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tSAMPLES\tAVG SPEED\tMIN\tMAX\tZERO\tREWARD\tHEIGHT\tLAST SEEN")
+	for _, st := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%.2f\t%.2f\t%.2f\t%d\t%.6f\t%d\t%s\n", st.Addr, st.Samples, st.AvgSpeed, st.MinSpeed, st.MaxSpeed,
+			st.ZeroSpeed, st.Reward, st.Height, st.LastSeen.Local().Format(time.RFC3339))
+	}
+	w.Flush()
+	return 0
+}
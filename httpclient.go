@@ -0,0 +1,29 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
+// newHTTPClient builds the one client shared by the API client and the
+// pusher, so connections and TLS sessions are reused across cycles.
+func newHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   cfg.HTTPTimeout,
+		KeepAlive: cfg.HTTPKeepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        cfg.HTTPMaxIdleConns,
+		MaxIdleConnsPerHost: cfg.HTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.HTTPIdleConnTimeout,
+		TLSHandshakeTimeout: cfg.HTTPTimeout,
+		TLSClientConfig: &tls.Config{
+			ClientSessionCache: tls.NewLRUClientSessionCache(cfg.HTTPTLSSessionCache),
+		},
+	}
+	return &http.Client{Transport: transport, Timeout: cfg.HTTPTimeout}
+}
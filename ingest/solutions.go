@@ -0,0 +1,120 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"aleo-prover-monitor/apiclient"
+)
+
+// Solution is one message of the pool's accepted solution feed, Count
+// defaults to one solution.
+type Solution struct {
+	Address string `json:"address"`
+	Count   *int64 `json:"count,omitempty"`
+}
+
+// Solutions counts the accepted solutions of every address from a pool
+// WebSocket feed, between and independent of the polling cycles.
+type Solutions struct {
+	URL    string
+	Header http.Header
+	// Idle is how long the feed may be silent before it is reconnected.
+	Idle time.Duration
+
+	mu    sync.Mutex
+	total map[string]int64
+	last  map[string]time.Time
+}
+
+func NewSolutions(url string, header http.Header, idle time.Duration) *Solutions {
+	return &Solutions{URL: url, Header: header, Idle: idle, total: make(map[string]int64), last: make(map[string]time.Time)}
+}
+
+func (s *Solutions) Add(addr string, n int64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	addr = apiclient.NormalizeAddress(addr)
+	s.total[addr] += n
+	s.last[addr] = at
+}
+
+// Totals returns the solutions counted per address since start and when the
+// last one arrived.
+func (s *Solutions) Totals() (map[string]int64, map[string]time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := make(map[string]int64, len(s.total))
+	last := make(map[string]time.Time, len(s.last))
+	for addr, n := range s.total {
+		total[addr] = n
+		last[addr] = s.last[addr]
+	}
+	return total, last
+}
+
+// Run reads the feed until ctx is done, reconnecting with a growing backoff
+// up to a minute whenever it drops.
+func (s *Solutions) Run(ctx context.Context) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := s.read(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("solution feed %s dropped, reconnecting in %s:%s", s.URL, backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, time.Minute)
+	}
+}
+
+func (s *Solutions) read(ctx context.Context) error {
+	conn, err := dialWebSocket(s.URL, s.Header, 30*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		message, err := conn.ReadMessage(s.Idle)
+		if err != nil {
+			return err
+		}
+		var solutions []Solution
+		if err := json.Unmarshal(message, &solutions); err != nil {
+			var one Solution
+			if err := json.Unmarshal(message, &one); err != nil {
+				log.Printf("parse solution message failed:%s", err)
+				continue
+			}
+			solutions = []Solution{one}
+		}
+		now := time.Now()
+		for _, sol := range solutions {
+			if sol.Address == "" {
+				continue
+			}
+			n := int64(1)
+			if sol.Count != nil {
+				n = *sol.Count
+			}
+			s.Add(sol.Address, n, now)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package ingest receives what provers and the pool send to the monitor
+// instead of being polled: agent speeds and the accepted solution feed.
+package ingest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"aleo-prover-monitor/apiclient"
+)
+
+// Report is one agent's measurement, an address may be run by many workers.
+type Report struct {
+	Address string  `json:"address"`
+	Worker  string  `json:"worker"`
+	Speed   float64 `json:"speed"`
+}
+
+type reported struct {
+	speed float64
+	at    time.Time
+}
+
+// Speeds keeps the last report of every address and worker.
+type Speeds struct {
+	// Token, if set, is the bearer token agents must send.
+	Token string
+
+	mu      sync.Mutex
+	reports map[string]map[string]reported
+}
+
+func NewSpeeds() *Speeds {
+	return &Speeds{reports: make(map[string]map[string]reported)}
+}
+
+func (s *Speeds) Add(r Report, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	addr := apiclient.NormalizeAddress(r.Address)
+	if s.reports[addr] == nil {
+		s.reports[addr] = make(map[string]reported)
+	}
+	s.reports[addr][r.Worker] = reported{speed: r.Speed, at: at}
+}
+
+// Totals sums the speeds of the workers of every address that reported
+// within maxAge before now, older reports are dropped.
+func (s *Speeds) Totals(now time.Time, maxAge time.Duration) map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totals := make(map[string]float64)
+	for addr, workers := range s.reports {
+		for worker, r := range workers {
+			if now.Sub(r.at) > maxAge {
+				delete(workers, worker)
+				continue
+			}
+			totals[addr] += r.speed
+		}
+		if len(workers) == 0 {
+			delete(s.reports, addr)
+		}
+	}
+	return totals
+}
+
+// ServeHTTP accepts a POSTed Report or a JSON array of them.
+func (s *Speeds) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var reports []Report
+	var body json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		http.Error(w, "bad report: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := json.Unmarshal(body, &reports); err != nil {
+		var one Report
+		if err := json.Unmarshal(body, &one); err != nil {
+			http.Error(w, `want {"address": addr, "worker": name, "speed": value} or a list of them`, http.StatusBadRequest)
+			return
+		}
+		reports = []Report{one}
+	}
+
+	now := time.Now()
+	for _, report := range reports {
+		if report.Address == "" || report.Speed < 0 {
+			http.Error(w, "report needs an address and a speed >= 0", http.StatusBadRequest)
+			return
+		}
+	}
+	for _, report := range reports {
+		s.Add(report, now)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,128 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Lookup resolves addresses to labels like rack, site and owner from a CMDB
+// or inventory HTTP API. Answers are cached in CachePath, so addresses keep
+// their labels while the API is unreachable.
+type Lookup struct {
+	// URL is queried per address with {addr} replaced, the answer is a JSON
+	// object whose Keys become labels.
+	URL       string
+	Keys      []string
+	Client    *http.Client
+	CachePath string
+
+	mu    sync.Mutex
+	cache map[string]map[string]string
+}
+
+// Resolve returns the labels of every address it could resolve, from the API
+// or else from the cache.
+func (l *Lookup) Resolve(ctx context.Context, addresses []string) map[string]map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.load()
+
+	resolved := make(map[string]map[string]string, len(addresses))
+	changed := false
+	for _, addr := range addresses {
+		labels, err := l.query(ctx, addr)
+		if err != nil {
+			log.Printf("inventory lookup %s failed:%s", addr, err)
+			if cached, ok := l.cache[addr]; ok {
+				resolved[addr] = cached
+			}
+			continue
+		}
+		resolved[addr] = labels
+		l.cache[addr] = labels
+		changed = true
+	}
+
+	if changed && l.CachePath != "" {
+		if err := l.save(); err != nil {
+			log.Printf("save inventory cache %s failed:%s", l.CachePath, err)
+		}
+	}
+	return resolved
+}
+
+func (l *Lookup) query(ctx context.Context, addr string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.ReplaceAll(l.URL, "{addr}", url.PathEscape(addr)), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(body, &record); err != nil {
+		return nil, err
+	}
+	labels := make(map[string]string, len(l.Keys))
+	for _, key := range l.Keys {
+		if v, ok := record[key]; ok && v != nil {
+			labels[key] = fmt.Sprint(v)
+		}
+	}
+	return labels, nil
+}
+
+func (l *Lookup) load() {
+	if l.cache != nil {
+		return
+	}
+	l.cache = make(map[string]map[string]string)
+	if l.CachePath == "" {
+		return
+	}
+	data, err := os.ReadFile(l.CachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &l.cache)
+	}
+	if err != nil {
+		log.Printf("load inventory cache %s failed:%s", l.CachePath, err)
+	}
+}
+
+func (l *Lookup) save() error {
+	data, err := json.Marshal(l.cache)
+	if err != nil {
+		return err
+	}
+	tmp := l.CachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.CachePath)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"aleo-prover-monitor/prometh"
+)
+
+// runLoadtest pushes synthetic fleet data to the configured sink and reports
+// push throughput and latency, to size the backend before growing the fleet.
+func runLoadtest(args []string) int {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	n := fs.Int("addresses", 1000, "number of synthetic prover addresses")
+	cycles := fs.Int("cycles", 10, "number of cycles to push")
+	windows := fs.String("windows", "15,60,1440", "comma separated speed duration windows per address")
+	mock := fs.Bool("mock", false, "push into an in-memory gateway instead of the configured sink, measuring the monitor's own overhead")
+	parseConfig(fs, args)
+
+	if *n <= 0 || *cycles <= 0 {
+		fmt.Fprintln(os.Stderr, "loadtest: -addresses and -cycles must be positive")
+		return 2
+	}
+	var durations []int
+	for d := range listSet(*windows) {
+		di, err := strconv.Atoi(d)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "loadtest: wrong window %q\n", d)
+			return 2
+		}
+		durations = append(durations, di)
+	}
+	sort.Ints(durations)
+
+	addresses := make([]string, *n)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf("aleo1loadtest%06d", i)
+	}
+
+	// The synthetic groups carry an extra grouping label so they never
+	// replace the real fleet's data on a shared Pushgateway.
+	var gw prometh.Gateway = prometh.NewFakeGateway()
+	if !*mock {
+		gw = &prometh.WithGrouping{Next: newSink(context.Background(), http.DefaultClient), Extra: map[string]string{"loadtest": cfg.Instance}}
+	}
+	gw = withTransforms(gw)
+
+	var latencies, cycleTimes []time.Duration
+	pushes, failed, series := 0, 0, 0
+	start := time.Now()
+	for c := 0; c < *cycles; c++ {
+		b, count := syntheticBatch(addresses, durations, c)
+		b.Sequence = uint64(c + 1)
+		cycleStart := time.Now()
+		failed += b.Flush(gw)
+		cycleTimes = append(cycleTimes, time.Since(cycleStart))
+		for _, d := range b.PushTimes {
+			latencies = append(latencies, d)
+		}
+		pushes += len(b.PushTimes)
+		series += count
+	}
+	elapsed := time.Since(start)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "addresses\t%d\n", *n)
+	fmt.Fprintf(w, "cycles\t%d\n", *cycles)
+	fmt.Fprintf(w, "pushes\t%d (%d failed)\n", pushes, failed)
+	fmt.Fprintf(w, "series\t%d\n", series)
+	fmt.Fprintf(w, "elapsed\t%s\n", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "throughput\t%.1f pushes/s, %.0f series/s\n", float64(pushes)/elapsed.Seconds(), float64(series)/elapsed.Seconds())
+	fmt.Fprintf(w, "push latency\t%s\n", percentiles(latencies))
+	fmt.Fprintf(w, "cycle flush\t%s\n", percentiles(cycleTimes))
+	w.Flush()
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// syntheticBatch builds one cycle of plausible fleet data and returns it with
+// the number of series it holds.
+func syntheticBatch(addresses []string, durations []int, cycle int) (*prometh.Batch, int) {
+	b := prometh.NewBatch()
+	height := 1000000 + cycle
+	totals := make([]float64, len(durations))
+	var totalReward float64
+	lags := make([]int, 0, len(addresses))
+	for _, addr := range addresses {
+		base := 50 + rand.Float64()*150
+		for i, d := range durations {
+			speed := base * (0.9 + rand.Float64()*0.2)
+			totals[i] += speed
+			prometh.SpeedPush(b, addr, d, strconv.FormatFloat(speed, 'f', 2, 64))
+		}
+		reward := float64(cycle+1) * base * 0.01
+		totalReward += reward
+		prometh.RewardPush(b, addr, strconv.FormatFloat(reward, 'f', 6, 64))
+
+		lag := rand.Intn(4)
+		lags = append(lags, lag)
+		prometh.HeightPush(b, addr, height-lag)
+		prometh.HeightLagPush(b, addr, lag)
+	}
+	for i, d := range durations {
+		prometh.TotalSpeedPush(b, d, strconv.FormatFloat(totals[i], 'f', 2, 64))
+	}
+	prometh.TotalRewardPush(b, strconv.FormatFloat(totalReward, 'f', 6, 64))
+	prometh.HeightLagBucketsPush(b, lags)
+	prometh.BlockPush(b, height, "1000000", "2000000")
+	// per address speeds, reward, height and lag, then the fleet totals, the
+	// four lag buckets and the block height, proof and reward
+	series := len(addresses)*(len(durations)+3) + len(durations) + 1 + 4 + 3
+	return b, series
+}
+
+func percentiles(d []time.Duration) string {
+	if len(d) == 0 {
+		return "-"
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	at := func(p float64) time.Duration {
+		return d[int(p*float64(len(d)-1))].Round(time.Microsecond)
+	}
+	return fmt.Sprintf("p50 %s, p95 %s, p99 %s, max %s", at(0.5), at(0.95), at(0.99), d[len(d)-1].Round(time.Microsecond))
+}
@@ -2,98 +2,106 @@ package main
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"aleo-prover-monitor/alert"
+	"aleo-prover-monitor/apiclient"
+	"aleo-prover-monitor/collect"
+	"aleo-prover-monitor/config"
+	"aleo-prover-monitor/derive"
+	"aleo-prover-monitor/ingest"
 	"aleo-prover-monitor/prometh"
+	"aleo-prover-monitor/store"
 )
 
-var apiBaseURL = flag.String("api", "http://localhost:8088", "Base URL of the API")
-var pushGatewayAddr = flag.String("pushGateway", "http://pushgateway:9091", "pushgateway addr")
-var interval = flag.Int("interval", 5, "check interval(min)")
-var addressFile = flag.String("addrFile", "", "addressFile")
-var durationFile = flag.String("durFile", "", "durationFile")
+var cfg = config.Default()
 
-type SpeedRequestPayload struct {
-	Address  []string `json:"address"`
-	Duration int      `json:"duration"`
-}
-
-type SpeedResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    struct {
-		List []struct {
-			Address string `json:"address"`
-			Speed   string `json:"speed"`
-		} `json:"list"`
-		Total string `json:"total"`
-	} `json:"data"`
-}
+// fromEnv lists the environment variables parseConfig applied.
+var fromEnv []string
 
-type RewardRequestPayload struct {
-	Address []string `json:"address"`
-}
+var once = flag.Bool("once", false, "run a single cycle and print its metrics as JSON to stdout instead of pushing them")
 
-type RewardResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    struct {
-		List []struct {
-			Address     string `json:"address"`
-			TotalReward string `json:"total_reward"`
-		} `json:"list"`
-		Total string `json:"total"`
-	} `json:"data"`
-}
+// parseConfig fills cfg from the -config file, the ALEO_MONITOR_ environment
+// variables over it and args over both.
+func parseConfig(fs *flag.FlagSet, args []string) {
+	configFile := fs.String("config", "", "YAML config file, environment variables and flags override its values, $"+config.EnvPrefix+"CONFIG if unset")
+	cfg.RegisterFlags(fs)
+	fs.Parse(args)
+	if *configFile == "" {
+		*configFile = os.Getenv(config.EnvPrefix + "CONFIG")
+	}
+	if *configFile != "" {
+		if err := config.Load(*configFile, &cfg); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+	}
+	config.ResetRepeated(fs)
+	var err error
+	if fromEnv, err = config.LoadEnv(fs, os.LookupEnv); err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	fs.Parse(args)
 
-type HeightRequestPayload struct {
-	Address []string `json:"address"`
+	if cfg.Instance == "" {
+		cfg.Instance, _ = os.Hostname()
+	}
 }
 
-type HeightResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    []struct {
-		Address string `json:"address"`
-		Height  int    `json:"height"`
-	} `json:"data"`
-}
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "conformance":
+			os.Exit(runConformance(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "runs":
+			os.Exit(runRuns(os.Args[2:]))
+		case "history":
+			os.Exit(runHistory(os.Args[2:]))
+		case "loadtest":
+			os.Exit(runLoadtest(os.Args[2:]))
+		case "self-update":
+			os.Exit(runSelfUpdate(os.Args[2:]))
+		}
+	}
 
-type BlockData struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    struct {
-		Height         int    `json:"height"`
-		ProofTarget    string `json:"proof_target"`
-		CoinbaseReward string `json:"coinbase_reward"`
-	} `json:"data"`
-}
+	parseConfig(flag.CommandLine, os.Args[1:])
 
-func main() {
-	flag.Parse()
-	addresses, err := readLinesFromFile(*addressFile)
+	if err := cfg.AddrFiles.Check(); err != nil {
+		log.Fatalf("Error in address files: %v", err)
+	}
+	addresses, sources, err := loadAddressFiles(cfg.AddrFiles)
 	if err != nil {
 		log.Fatalf("Error reading addresses: %v", err)
 	}
 
 	log.Printf("Address: %v", addresses)
 
-	durations, err := readLinesFromFile(*durationFile)
+	durations, err := readLinesFromFile(cfg.DurFile)
 	if err != nil {
 		log.Fatalf("Error reading durations: %v", err)
 	}
 
 	var duration []int
 	for _, d := range durations {
+		d = strings.TrimSpace(strings.TrimPrefix(d, "\ufeff"))
+		if d == "" {
+			continue
+		}
 		di, err := strconv.Atoi(d)
 		if err != nil {
 			log.Fatalf("Wrong fromat:%s", err)
@@ -103,202 +111,625 @@ func main() {
 
 	log.Printf("Duration: %v", duration)
 
-	for {
-		//Speed
-		SpeedURL := *apiBaseURL + "/api/v1/provers/prover_speed_list"
-		for _, d := range duration {
-			speedRespon, err := SpeedSendRequest(SpeedURL, SpeedRequestPayload{addresses, d})
-			if err != nil {
-				log.Printf("%s 请求失败:%s\n", SpeedURL, err)
-				time.Sleep(time.Duration(*interval) * time.Minute)
-				continue
-			}
-			log.Printf("%s 请求成功\n", SpeedURL)
+	shortest := 0
+	for i, d := range duration {
+		if d < duration[shortest] {
+			shortest = i
+		}
+	}
+	efficiency := derive.NewEfficiency(cfg.EfficiencyWindow)
+	prometh.RawValues = cfg.RawValues
+	client := newHTTPClient()
+	prometh.Namespace = cfg.Namespace
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var captured *prometh.FakeGateway
+	var gw prometh.Gateway
+	if *once {
+		captured = prometh.NewFakeGateway()
+		gw = withTransforms(captured)
+	} else {
+		gw = newGateway(ctx, client)
+	}
+	gw, enrich, labels := withInventory(gw, client, addresses)
+	gw, sourceLabels := withSources(gw, sources)
+	if sourceLabels != nil {
+		labels = mergeLabels(labels, sourceLabels.Labels)
+	}
+	var extraGrouping []string
+	if cfg.InstanceLabel {
+		gw = &prometh.WithGrouping{Next: gw, Extra: map[string]string{"instance": cfg.Instance}}
+		extraGrouping = append(extraGrouping, "instance")
+	}
+	if cfg.Migrate && cfg.ExporterListen == "" && !*once {
+		deleted, err := prometh.Migrate(cfg.PushGateway, client, extraGrouping...)
+		if err != nil {
+			log.Printf("migrate pushgateway failed:%s", err)
+		}
+		log.Printf("migrated pushgateway, %d legacy groups deleted", deleted)
+	}
 
-			for _, r := range speedRespon.Data.List {
-				prometh.SpeedPush(*pushGatewayAddr, r.Address, d, r.Speed)
-			}
-			prometh.TotalSpeedPush(*pushGatewayAddr, d, speedRespon.Data.Total)
+	history, err := alert.NewHistory(cfg.AlertHistorySize, cfg.AlertHistoryFile)
+	if err != nil {
+		log.Fatalf("Error loading alert history: %v", err)
+	}
+	rules := []alert.Rule{
+		{Name: "prover_offline", Severity: "critical", Threshold: 0, Below: true},
+		{Name: "chain_height_regression", Severity: "warning", Threshold: 1},
+		{Name: "config_drift", Severity: "warning", Threshold: 2},
+	}
+	if cfg.AlertFlapChanges > 0 {
+		rules = append(rules, alert.Rule{Name: "prover_flapping", Severity: "warning", Threshold: float64(cfg.AlertFlapChanges + 1)})
+	}
+	if cfg.AlertMinSpeed > 0 {
+		rules = append(rules, alert.Rule{Name: "speed_low", Severity: "warning", Threshold: cfg.AlertMinSpeed, Clear: clearAt(cfg.AlertClearSpeed), Below: true})
+	}
+	if cfg.AlertMinTotal > 0 {
+		rules = append(rules, alert.Rule{Name: "fleet_speed_collapse", Severity: "critical", Threshold: cfg.AlertMinTotal, Clear: clearAt(cfg.AlertClearTotal), Below: true})
+	}
+	if cfg.AlertFleetDrop > 0 {
+		rules = append(rules, alert.Rule{Name: "fleet_speed_drop", Severity: "critical", Threshold: cfg.AlertFleetDrop})
+	}
+	if cfg.AlertClockSkew > 0 {
+		rules = append(rules, alert.Rule{Name: "clock_skew", Severity: "warning", Threshold: cfg.AlertClockSkew.Seconds()})
+	}
+	if cfg.AlertAPIDown > 0 {
+		rules = append(rules, alert.Rule{Name: "api_unreachable", Severity: "critical", Threshold: float64(cfg.AlertAPIDown)})
+	}
+	alerts := alert.NewEngine(history, rules...)
+	alerts.Cooldown = cfg.AlertCooldown
+	for _, o := range cfg.AlertOverrides {
+		for i, addr := range o.Addresses {
+			o.Addresses[i] = apiclient.NormalizeAddress(addr)
 		}
+	}
+	if err := alerts.SetOverrides(cfg.AlertOverrides, labels); err != nil {
+		log.Fatalf("Error in alert overrides: %v", err)
+	}
+	for _, sil := range cfg.Silences {
+		for i, addr := range sil.Addresses {
+			sil.Addresses[i] = apiclient.NormalizeAddress(addr)
+		}
+	}
+	silences, err := alert.NewSilences(cfg.Silences, cfg.SilenceFile)
+	if err != nil {
+		log.Fatalf("Error loading silences: %v", err)
+	}
+	silences.Labels = labels
+	alerts.Silences = silences
+	notifiers := newNotifiers(client)
+	if notifiers.Len() > 0 && !*once {
+		alerts.Notify = notifiers.Notify
+	}
+	points := newPoints()
+	if cfg.AdminListen != "" && !*once {
+		startAdmin(cfg.AdminListen, history, alerts, silences, points)
+	}
 
-		//Reward
-		RewardURL := *apiBaseURL + "/api/v1/provers/prover_reward_list"
-		rewardRespon, err := RewardSendRequest(RewardURL, RewardRequestPayload{addresses})
-		if err != nil {
-			log.Printf("%s 请求失败:%s", RewardURL, err)
-			time.Sleep(time.Duration(*interval) * time.Minute)
-			continue
+	m := &monitor{
+		api:         newAPI(client),
+		addresses:   addresses,
+		durations:   duration,
+		shortest:    shortest,
+		concurrency: cfg.Concurrency,
+		gw:          gw,
+		alerts:      alerts,
+		points:      points,
+		efficiency:  efficiency,
+		rewardRate:  derive.NewRate(),
+		trend:       derive.NewTrend(cfg.ForecastWindow),
+		speedEMA:    newSpeedEMA(cfg.SpeedEMA),
+		derived:     newDerived(),
+		dedup:       newDedup(client),
+		drift:       newDrift(client),
+		enrich:      enrich,
+		sources:     sourceLabels,
+		agents:      newAgents(),
+		configHash:  config.Hash(cfg),
+		seq:         uint64(time.Now().Unix()),
+		retiring:    newRetiring(),
+		retired:     make(map[string]bool),
+		flap:        derive.NewFlap(cfg.AlertFlapWindow),
+		rates:       make(map[string]float64),
+		rotation:    newRotation(),
+		notes:       newNotes(),
+		restarts:    derive.NewRestartDetector(cfg.RestartDipRatio, cfg.RestartRecoverRatio, cfg.RestartMaxCycles),
+		postgres:    newPostgres(),
+		sqlite:      newSQLite(),
+		clickhouse:  newClickHouse(client),
+		archive:     newArchive(client),
+		priority:    splitList(cfg.Priority),
+		expected:    make(map[string]time.Duration),
+
+		blockRewards:   make(map[blockRewardKey]float64),
+		rewardedBlocks: make(map[blockRewardKey]int),
+		rewardHeight:   make(map[string]int),
+	}
+
+	if *once {
+		m.run(context.Background())
+		if err := writeOnce(os.Stdout, captured); err != nil {
+			log.Fatalf("write result failed: %v", err)
 		}
+		return
+	}
 
-		for _, r := range rewardRespon.Data.List {
-			prometh.RewardPush(*pushGatewayAddr, r.Address, r.TotalReward)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadAddresses(m)
 		}
-		prometh.TotalRewardPush(*pushGatewayAddr, rewardRespon.Data.Total)
+	}()
 
-		//Height
-		HeightURL := *apiBaseURL + "/api/v1/provers/prover_latest_height"
-		heightRespon, err := HeightSendRequest(HeightURL, HeightRequestPayload{addresses})
-		if err != nil {
-			log.Printf("%s 请求失败:%s", HeightURL, err)
-			time.Sleep(time.Duration(*interval) * time.Minute)
-			continue
+	if f := newFeishu(client); f != nil && cfg.FeishuSummaryAt != "" {
+		go runDailySummary(ctx, cfg.FeishuSummaryAt, f.Summary, m, history)
+	}
+	if e := newEmail(); e != nil && cfg.EmailSummaryAt != "" {
+		go runDailySummary(ctx, cfg.EmailSummaryAt, e.Summary, m, history)
+	}
+	if cfg.DigestAt != "" && notifiers.Len() > 0 {
+		notifiers.ReportTo = listSet(cfg.DigestChannels)
+		go runDailySummary(ctx, cfg.DigestAt, notifiers.Report, m, history)
+	}
+
+	if cfg.SolutionsURL != "" {
+		go runSolutions(ctx, gw)
+	}
+
+	if cfg.WatchAddrFile {
+		if err := watchAddresses(ctx, m, cfg.WatchDebounce); err != nil {
+			log.Printf("watch address file failed, use SIGHUP to reload:%s", err)
 		}
-		log.Printf("%s 请求成功\n", HeightURL)
+	}
 
-		for _, r := range heightRespon.Data {
-			prometh.HeightPush(*pushGatewayAddr, r.Address, r.Height)
+	if cfg.WatchdogInterval > 0 {
+		wd := &watchdog{
+			interval:      cfg.WatchdogInterval,
+			maxGoroutines: cfg.WatchdogMaxGoroutines,
+			maxHeapBytes:  uint64(cfg.WatchdogMaxHeapMB) << 20,
+			gw:            gw,
+			cooldown:      time.Duration(cfg.Interval) * time.Minute,
+		}
+		if cfg.WatchdogRestart {
+			wd.restart = m.requestRestart
 		}
+		go wd.run(ctx)
+	}
 
-		//block
-		BlockURL := *apiBaseURL + "/api/v1/chain/latest_block"
-		blockRespon, err := BlockSendRequest(BlockURL)
-		if err != nil {
-			log.Printf("%s 请求失败:%s", BlockURL, err)
-			time.Sleep(time.Duration(*interval) * time.Minute)
-			continue
+	for {
+		if reason := m.takeRestart(); reason != "" {
+			log.Printf("restarting collection subsystem: %s", reason)
+			client.CloseIdleConnections()
+			client = newHTTPClient()
+			m.api = newAPI(client)
+			runtime.GC()
+			debug.FreeOSMemory()
 		}
-		log.Printf("%s 请求成功\n", BlockURL)
 
-		prometh.BlockPush(*pushGatewayAddr, blockRespon.Data.Height, blockRespon.Data.ProofTarget, blockRespon.Data.CoinbaseReward)
+		m.run(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		if m.restartPending() {
+			continue
+		}
 
 		//Sleep
-
-		time.Sleep(time.Duration(*interval) * time.Minute)
+		if !sleep(ctx, time.Duration(cfg.Interval)*time.Minute/time.Duration(max(cfg.Batches, 1))) {
+			break
+		}
 	}
 
+	if m.archive != nil {
+		m.archive.Close()
+	}
+	stop()
+	log.Printf("received shutdown signal, exiting")
 }
 
-func SpeedSendRequest(url string, payload SpeedRequestPayload) (SpeedResponse, error) {
-	var response SpeedResponse
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return response, fmt.Errorf("JSON序列化错误: %v", err)
+// sleep waits for d and reports false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
 	}
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return response, fmt.Errorf("创建请求错误: %v", err)
+func newDedup(client *http.Client) *prometh.Dedup {
+	if cfg.DedupFresh <= 0 || cfg.ExporterListen != "" || *once {
+		return nil
 	}
+	return &prometh.Dedup{URL: cfg.PushGateway, Client: client, Instance: cfg.Instance, Freshness: cfg.DedupFresh}
+}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return response, fmt.Errorf("发送请求错误: %v", err)
+func newDrift(client *http.Client) *prometh.ConfigDrift {
+	if *once {
+		return nil
+	}
+	d := &prometh.ConfigDrift{
+		Client:    client,
+		Instance:  cfg.Instance,
+		Hash:      config.Hash(cfg),
+		Freshness: 3 * time.Duration(cfg.Interval) * time.Minute,
 	}
-	defer resp.Body.Close()
+	if cfg.ExporterListen == "" {
+		d.URL = cfg.PushGateway
+	}
+	return d
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return response, fmt.Errorf("读取响应错误: %v", err)
+// runSolutions counts the pool's solution feed and pushes the counters every
+// SolutionsPush until ctx is done.
+func runSolutions(ctx context.Context, gw prometh.Gateway) {
+	header := make(http.Header)
+	for _, endpoint := range []string{"*", "solutions"} {
+		for name, value := range cfg.Headers[endpoint] {
+			header.Set(name, value)
+		}
 	}
+	solutions := ingest.NewSolutions(cfg.SolutionsURL, header, cfg.SolutionsIdle)
+	go solutions.Run(ctx)
 
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return response, fmt.Errorf("JSON反序列化错误: %v", err)
+	t := time.NewTicker(cfg.SolutionsPush)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		total, last := solutions.Totals()
+		if len(total) == 0 {
+			continue
+		}
+		b := prometh.NewBatch()
+		prometh.SolutionsPush(b, total, last)
+		b.Flush(gw)
 	}
+}
 
-	return response, nil
+// newAgents serves the agent speed endpoint, nil when it is disabled.
+func newAgents() *ingest.Speeds {
+	if cfg.AgentListen == "" || *once {
+		return nil
+	}
+	agents := ingest.NewSpeeds()
+	agents.Token = cfg.AgentToken
+	mux := http.NewServeMux()
+	mux.Handle("/speed", agents)
+	go func() {
+		log.Printf("accepting agent speeds on %s/speed", cfg.AgentListen)
+		if err := http.ListenAndServe(cfg.AgentListen, mux); err != nil {
+			log.Fatalf("agent listen %s failed: %v", cfg.AgentListen, err)
+		}
+	}()
+	return agents
 }
 
-func RewardSendRequest(url string, payload RewardRequestPayload) (RewardResponse, error) {
-	var response RewardResponse
+// newRotation returns the rotation over cfg.Batches slices, nil when every
+// cycle queries all addresses.
+func newRotation() *collect.Rotation {
+	if cfg.Batches <= 1 || *once {
+		return nil
+	}
+	return &collect.Rotation{Slices: cfg.Batches, MaxAge: 2 * time.Duration(cfg.Interval) * time.Minute}
+}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return response, fmt.Errorf("JSON序列化错误: %v", err)
+// clearAt is the clear threshold of a rule, 0 meaning none.
+func clearAt(v float64) *float64 {
+	if v == 0 {
+		return nil
 	}
+	return &v
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+// newPostgres opens the history table of cfg.PostgresDSN, nil without one.
+func newPostgres() *store.Postgres {
+	if cfg.PostgresDSN == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTPTimeout)
+	defer cancel()
+	p, err := store.OpenPostgres(ctx, cfg.PostgresDSN, cfg.PostgresTable, cfg.PostgresTimescale)
 	if err != nil {
-		return response, fmt.Errorf("创建请求错误: %v", err)
+		log.Fatalf("open postgres failed: %v", err)
 	}
+	return p
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// newClickHouse creates the history table of cfg.ClickHouseURL, nil without
+// one.
+func newClickHouse(client *http.Client) *store.ClickHouse {
+	if cfg.ClickHouseURL == "" {
+		return nil
+	}
+	c := &store.ClickHouse{
+		URL:      cfg.ClickHouseURL,
+		Database: cfg.ClickHouseDatabase,
+		Table:    cfg.ClickHouseTable,
+		Username: cfg.ClickHouseUsername,
+		Password: cfg.ClickHousePassword,
+		TTL:      cfg.ClickHouseTTL,
+		Client:   client,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTPTimeout)
+	defer cancel()
+	if err := c.Open(ctx); err != nil {
+		log.Fatalf("open clickhouse failed: %v", err)
+	}
+	return c
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// newArchive opens the snapshot archive in cfg.ArchiveDir, uploading finished
+// files to S3 if a bucket is configured. It is nil without a directory.
+func newArchive(client *http.Client) *store.Archive {
+	if cfg.ArchiveDir == "" {
+		return nil
+	}
+	a, err := store.NewArchive(cfg.ArchiveDir, cfg.ArchiveFormat, cfg.ArchiveRotate)
 	if err != nil {
-		return response, fmt.Errorf("发送请求错误: %v", err)
+		log.Fatalf("open archive %s failed: %v", cfg.ArchiveDir, err)
 	}
-	defer resp.Body.Close()
+	if cfg.ArchiveS3URL != "" {
+		s3 := &store.S3{
+			URL:       cfg.ArchiveS3URL,
+			Region:    cfg.ArchiveS3Region,
+			AccessKey: cfg.ArchiveS3AccessKey,
+			SecretKey: cfg.ArchiveS3SecretKey,
+			Client:    client,
+		}
+		a.Upload = func(ctx context.Context, path string) error {
+			body, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return s3.Put(ctx, filepath.Base(path), body)
+		}
+		a.Remove = cfg.ArchiveS3Remove
+	}
+	return a
+}
 
-	body, err := io.ReadAll(resp.Body)
+// newSQLite opens the cycle database cfg.SQLiteFile, nil without one.
+func newSQLite() *store.SQLite {
+	if cfg.SQLiteFile == "" {
+		return nil
+	}
+	s, err := store.OpenSQLite(cfg.SQLiteFile, cfg.SQLiteRetention)
 	if err != nil {
-		return response, fmt.Errorf("读取响应错误: %v", err)
+		log.Fatalf("open sqlite %s failed: %v", cfg.SQLiteFile, err)
 	}
+	return s
+}
 
-	err = json.Unmarshal(body, &response)
+// newPoints opens the per-address history, nil when it is neither kept in
+// cfg.HistoryFile nor read by the admin listener or a daily summary.
+func newPoints() *store.Store {
+	summaries := cfg.FeishuSummaryAt != "" || cfg.EmailSummaryAt != "" || cfg.DigestAt != ""
+	if cfg.HistoryFile == "" && (*once || cfg.AdminListen == "" && !summaries) {
+		return nil
+	}
+	points, err := store.Open(cfg.HistoryFile, cfg.HistoryRetention)
 	if err != nil {
-		return response, fmt.Errorf("JSON反序列化错误: %v", err)
+		log.Fatalf("Error loading history: %v", err)
 	}
-
-	return response, nil
+	return points
 }
 
-func HeightSendRequest(url string, payload HeightRequestPayload) (HeightResponse, error) {
-	var response HeightResponse
+func newDerived() map[string]*derive.Expr {
+	derived := make(map[string]*derive.Expr, len(cfg.Derived))
+	for name, src := range cfg.Derived {
+		e, err := derive.ParseExpr(src)
+		if err != nil {
+			log.Fatalf("Wrong derived metric %s: %v", name, err)
+		}
+		derived[name] = e
+	}
+	return derived
+}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return response, fmt.Errorf("JSON序列化错误: %v", err)
+func newNotes() map[string]string {
+	notes := make(map[string]string, len(cfg.Notes))
+	for addr, note := range cfg.Notes {
+		if note != "" {
+			notes[apiclient.NormalizeAddress(addr)] = note
+		}
 	}
+	return notes
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return response, fmt.Errorf("创建请求错误: %v", err)
+// newRetiring maps every retiring address to the end of its grace period.
+func newRetiring() map[string]time.Time {
+	retiring := make(map[string]time.Time, len(cfg.Retiring))
+	for addr, since := range cfg.Retiring {
+		retiring[apiclient.NormalizeAddress(addr)] = since.Add(cfg.RetireGrace)
 	}
+	return retiring
+}
 
-	req.Header.Set("Content-Type", "application/json")
+func newAPI(client *http.Client) apiclient.ProverAPI {
+	newClient := func(baseURL string) *apiclient.Client {
+		c := apiclient.New(baseURL, client)
+		c.Timeouts = cfg.EndpointTimeouts
+		c.Headers = cfg.Headers
+		c.SpeedPaths = cfg.SpeedEndpoints
+		c.Observe = prometh.ObserveAPILatency
+		c.Skew = prometh.ObserveClockSkew
+		c.PoolStatsPath = cfg.PoolStatsPath
+		c.BlockRewardsPath = cfg.BlockRewardsPath
+		return c
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return response, fmt.Errorf("发送请求错误: %v", err)
+	var api apiclient.ProverAPI = newClient(cfg.API)
+	if cfg.FallbackAPI != "" {
+		api = &apiclient.Merged{
+			Primary:        api,
+			Fallback:       newClient(cfg.FallbackAPI),
+			FallbackFor:    listSet(cfg.FallbackFor),
+			PreferFallback: listSet(cfg.PreferFallback),
+		}
+	}
+	if cfg.RequeryTruncated {
+		api = &apiclient.Requery{API: api, Truncated: prometh.CountTruncatedAnswer}
 	}
-	defer resp.Body.Close()
+	return api
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return response, fmt.Errorf("读取响应错误: %v", err)
+func newSpeedEMA(alphas string) []*derive.EMA {
+	var emas []*derive.EMA
+	for _, s := range strings.Split(alphas, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		alpha, err := strconv.ParseFloat(s, 64)
+		if err != nil || alpha <= 0 || alpha > 1 {
+			log.Fatalf("Wrong speed EMA alpha %q, want a number in (0,1]", s)
+		}
+		emas = append(emas, derive.NewEMA(alpha))
 	}
+	return emas
+}
 
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return response, fmt.Errorf("JSON反序列化错误: %v", err)
+func splitList(list string) []string {
+	var items []string
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			items = append(items, s)
+		}
 	}
+	return items
+}
 
-	return response, nil
+func listSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			set[s] = true
+		}
+	}
+	return set
 }
 
-func BlockSendRequest(url string) (BlockData, error) {
-	var response BlockData
+// newGateway builds the sinks, the event log sits inside the transforms so
+// it records the values that are actually pushed.
+func newGateway(ctx context.Context, client *http.Client) prometh.Gateway {
+	gw := newSink(ctx, client)
+	if cfg.EventLog == "" {
+		return withTransforms(gw)
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return response, fmt.Errorf("创建请求错误: %v", err)
+	w := io.Writer(os.Stdout)
+	if cfg.EventLog != "-" {
+		f, err := os.OpenFile(cfg.EventLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatalf("open event log %s failed: %v", cfg.EventLog, err)
+		}
+		w = f
 	}
+	return withTransforms(prometh.NewJSONLog(w, gw))
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return response, fmt.Errorf("发送请求错误: %v", err)
+func withTransforms(gw prometh.Gateway) prometh.Gateway {
+	if len(cfg.Transforms) == 0 {
+		return gw
 	}
-	defer resp.Body.Close()
+	funcs := make(map[string]func(float64) float64, len(cfg.Transforms))
+	for name, t := range cfg.Transforms {
+		funcs[name] = t.Apply
+	}
+	return &prometh.Transform{Next: gw, Funcs: funcs}
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return response, fmt.Errorf("读取响应错误: %v", err)
+// newSink chains the configured metric sinks, ctx cancels pushes to the
+// pushgateway in flight.
+func newSink(ctx context.Context, client *http.Client) prometh.Gateway {
+	var gw prometh.Gateway
+	if !cfg.InfluxOnly && !cfg.StatsDOnly && !cfg.DatadogOnly && !cfg.GraphiteOnly {
+		gw = newPrometheusSink(ctx, client)
+	}
+	if cfg.InfluxURL != "" {
+		gw = newInflux(client, gw)
+	}
+	if cfg.StatsDAddr != "" {
+		statsd, err := prometh.NewStatsD(cfg.StatsDAddr, cfg.StatsDPrefix, cfg.StatsDTags, gw)
+		if err != nil {
+			log.Fatalf("statsd %s failed: %v", cfg.StatsDAddr, err)
+		}
+		gw = statsd
+	}
+	if cfg.DatadogAPIKey != "" {
+		gw = &prometh.Datadog{
+			URL:    cfg.DatadogURL,
+			APIKey: cfg.DatadogAPIKey,
+			Prefix: cfg.DatadogPrefix,
+			Client: client,
+			Next:   gw,
+		}
+	}
+	if cfg.GraphiteAddr != "" {
+		gw = &prometh.Graphite{
+			Addr:      cfg.GraphiteAddr,
+			Prefix:    cfg.GraphitePrefix,
+			Templates: cfg.GraphiteTemplates,
+			Timeout:   cfg.HTTPTimeout,
+			Next:      gw,
+		}
+	}
+	if gw == nil {
+		log.Fatalf("no metrics output left, the -*Only flags need -influxUrl, -statsdAddr, -datadogApiKey or -graphiteAddr")
 	}
+	return gw
+}
 
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return response, fmt.Errorf("JSON反序列化错误: %v", err)
+func newInflux(client *http.Client, next prometh.Gateway) *prometh.Influx {
+	if cfg.InfluxDatabase == "" && cfg.InfluxBucket == "" {
+		log.Fatalf("InfluxDB output needs -influxDatabase or -influxBucket")
+	}
+	return &prometh.Influx{
+		URL:             cfg.InfluxURL,
+		Client:          client,
+		Database:        cfg.InfluxDatabase,
+		RetentionPolicy: cfg.InfluxRetentionPolicy,
+		Username:        cfg.InfluxUsername,
+		Password:        cfg.InfluxPassword,
+		Org:             cfg.InfluxOrg,
+		Bucket:          cfg.InfluxBucket,
+		Token:           cfg.InfluxToken,
+		Next:            next,
+	}
+}
+
+func newPrometheusSink(ctx context.Context, client *http.Client) prometh.Gateway {
+	if cfg.ExporterListen == "" {
+		gw := prometh.NewPushGateway(cfg.PushGateway, client)
+		gw.StreamSeries = cfg.StreamPush
+		gw.Context = ctx
+		if cfg.SpoolDir == "" {
+			return gw
+		}
+		spool, err := prometh.NewSpool(gw, cfg.SpoolDir, int64(cfg.SpoolMaxMB)<<20)
+		if err != nil {
+			log.Fatalf("open spool %s failed: %v", cfg.SpoolDir, err)
+		}
+		return spool
 	}
 
-	return response, nil
+	exporter := prometh.NewExporter()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter.Handler())
+	go func() {
+		log.Printf("serving metrics on %s/metrics", cfg.ExporterListen)
+		if err := http.ListenAndServe(cfg.ExporterListen, mux); err != nil {
+			log.Fatalf("exporter listen %s failed: %v", cfg.ExporterListen, err)
+		}
+	}()
+	return exporter
 }
 
 func readLinesFromFile(filename string) ([]string, error) {
@@ -0,0 +1,898 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"aleo-prover-monitor/alert"
+	"aleo-prover-monitor/apiclient"
+	"aleo-prover-monitor/collect"
+	"aleo-prover-monitor/derive"
+	"aleo-prover-monitor/ingest"
+	"aleo-prover-monitor/prometh"
+	"aleo-prover-monitor/store"
+)
+
+type monitor struct {
+	mu            sync.Mutex
+	cancelCycle   context.CancelFunc
+	restartReason string
+
+	api         apiclient.ProverAPI
+	addresses   []string
+	durations   []int
+	shortest    int
+	concurrency int
+	gw          prometh.Gateway
+	alerts      *alert.Engine
+	points      *store.Store
+	efficiency  *derive.Efficiency
+	rewardRate  *derive.Rate
+	restarts    *derive.RestartDetector
+	flap        *derive.Flap
+	trend       *derive.Trend
+	speedEMA    []*derive.EMA
+	dedup       *prometh.Dedup
+	drift       *prometh.ConfigDrift
+	// agents, if set, holds the speeds prover agents reported themselves.
+	agents *ingest.Speeds
+	// rotation, if set, queries a slice of the addresses per cycle, rates
+	// keeps the last reward rate of the addresses it didn't query.
+	rotation *collect.Rotation
+	rates    map[string]float64
+	// enrich, if set, resolves the inventory labels of added addresses.
+	enrich func([]string)
+	// sources, if set, labels every address with the file it is listed in.
+	sources *prometh.AddressLabels
+	// configHash identifies the effective config, see config.Hash.
+	configHash string
+	// seq numbers the cycles, seeded with the start time in seconds so it
+	// keeps growing across restarts as long as cycles are a second apart.
+	seq uint64
+	// chainHeight is the last chain height seen, regressions counts the
+	// times it went backwards.
+	chainHeight int
+	regressions int
+	proofTarget float64
+	// apiDown counts the consecutive cycles where every query failed.
+	apiDown int
+	// fleetBaseline is the fleet speed fleet_speed_drop compares against,
+	// held at the last total before a drop until the fleet recovers.
+	fleetBaseline float64
+	// missing counts the consecutive cycles each address was absent from the
+	// speed list, only cycles where the speed API answered count.
+	missing map[string]int
+	// retiring holds when each retiring address is dropped, retired the ones
+	// already dropped.
+	retiring map[string]time.Time
+	retired  map[string]bool
+	// notes are the configured notes per address.
+	notes map[string]string
+	// derived are the configured derived metrics, lastReward the reward of
+	// every address in the previous cycle for their reward_delta.
+	derived    map[string]*derive.Expr
+	lastReward map[string]float64
+	// blockRewards sums the rewards of the blocks attributed to every address
+	// (and epoch) since start, rewardedBlocks counts them and rewardHeight is
+	// the last block height counted per address.
+	blockRewards   map[blockRewardKey]float64
+	rewardedBlocks map[blockRewardKey]int
+	rewardHeight   map[string]int
+	// postgres, if set, receives every cycle's speed, reward and height.
+	postgres *store.Postgres
+	// sqlite, if set, records every cycle with the same rows.
+	sqlite     *store.SQLite
+	clickhouse *store.ClickHouse
+	archive    *store.Archive
+	// priority ranks the queries for the cycle deadline, expected holds the
+	// duration estimate of each, kept up by the collector.
+	priority []string
+	expected map[string]time.Duration
+	// strictFailures counts the consecutive cycles failed in strict mode.
+	strictFailures int
+	// churn counts the addresses reloads added and removed since start,
+	// lastChurn those of the last reload.
+	churn     [2]int
+	lastChurn [2]int
+}
+
+// run runs one cycle that requestRestart can cancel.
+func (m *monitor) run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m.mu.Lock()
+	m.cancelCycle = cancel
+	m.mu.Unlock()
+
+	m.cycle(ctx)
+
+	m.mu.Lock()
+	m.cancelCycle = nil
+	m.mu.Unlock()
+}
+
+// requestRestart aborts the running cycle, the main loop then rebuilds the
+// collection subsystem before the next one.
+func (m *monitor) requestRestart(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.restartReason = reason
+	if m.cancelCycle != nil {
+		m.cancelCycle()
+	}
+}
+
+func (m *monitor) addressList() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addresses
+}
+
+// activeAddresses is addressList without the addresses whose retirement
+// grace period is over. They are no longer queried, so the next push
+// replaces their groups without them and their series leave the Pushgateway.
+func (m *monitor) activeAddresses(now time.Time) []string {
+	addresses := m.addressList()
+	if len(m.retiring) == 0 {
+		return addresses
+	}
+	active := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		if drop, ok := m.retiring[addr]; ok && !now.Before(drop) {
+			if !m.retired[addr] {
+				log.Printf("address %s retired, grace period ended at %s", addr, drop.Format(time.RFC3339))
+				m.retired[addr] = true
+			}
+			continue
+		}
+		active = append(active, addr)
+	}
+	return active
+}
+
+// setAddresses replaces the monitored addresses from the next cycle on and
+// returns what changed.
+func (m *monitor) setAddresses(addresses []string) (added []string, removed []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	added, removed = diffAddresses(m.addresses, addresses)
+	m.addresses = addresses
+	m.lastChurn = [2]int{len(added), len(removed)}
+	m.churn[0] += len(added)
+	m.churn[1] += len(removed)
+	return added, removed
+}
+
+func (m *monitor) restartPending() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.restartReason != ""
+}
+
+func (m *monitor) takeRestart() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reason := m.restartReason
+	m.restartReason = ""
+	return reason
+}
+
+// cycle runs one collection round. A cancelled ctx aborts the in-flight
+// requests and skips every push.
+func (m *monitor) cycle(ctx context.Context) {
+	addresses := m.activeAddresses(time.Now())
+	collector := collect.Collector{
+		API:          m.api,
+		Durations:    m.durations,
+		Concurrency:  m.concurrency,
+		PoolStats:    cfg.PoolStatsPath != "",
+		BlockRewards: cfg.BlockRewardsPath != "",
+		Deadline:     cfg.CycleDeadline,
+		Priority:     m.priority,
+		Expected:     m.expected,
+	}
+	var r *collect.Snapshot
+	if m.rotation != nil {
+		m.rotation.Collector = collector
+		r = m.rotation.Collect(ctx, addresses)
+	} else {
+		r = collector.Collect(ctx, addresses)
+	}
+	if ctx.Err() != nil {
+		log.Printf("cycle aborted: %s", ctx.Err())
+		return
+	}
+
+	for _, run := range r.Runs {
+		if m.points != nil {
+			if err := m.points.AddRun(store.Run(run)); err != nil {
+				log.Printf("save collector run failed:%s", err)
+				break
+			}
+		}
+	}
+	for _, run := range r.Runs {
+		if run.Error == collect.ErrSkipped.Error() {
+			log.Printf("%s skipped for the cycle deadline", run.Collector)
+		}
+	}
+
+	processStart := time.Now()
+	m.seq++
+	b := prometh.NewBatch()
+	b.Sequence = m.seq
+
+	//Speed
+	SpeedURL := apiclient.SpeedPath
+	// speeds only holds the values that parse, unparsed the addresses listed
+	// with one that doesn't, they are neither zero nor missing
+	speeds := make(map[string]float64)
+	unparsed := make(map[string]bool)
+	totalSpeed := 0.0
+	speedOK, totalOK := false, false
+	for i, d := range m.durations {
+		speedRespon := r.Speeds[i]
+		if speedRespon.Error != "" {
+			log.Printf("%s 请求失败:%s\n", SpeedURL, speedRespon.Error)
+			continue
+		}
+		log.Printf("%s 请求成功\n", SpeedURL)
+
+		for _, r := range speedRespon.Data.List {
+			prometh.SpeedPush(b, r.Address, d, r.Speed)
+		}
+		prometh.TotalSpeedPush(b, d, speedRespon.Data.Total)
+
+		if i == m.shortest {
+			for _, r := range speedRespon.Data.List {
+				if v, err := strconv.ParseFloat(r.Speed, 64); err == nil {
+					speeds[r.Address] = v
+				} else {
+					unparsed[r.Address] = true
+				}
+			}
+			if v, err := strconv.ParseFloat(speedRespon.Data.Total, 64); err == nil {
+				totalSpeed, totalOK = v, true
+			}
+			speedOK = true
+		}
+	}
+
+	//Forecast
+	if totalOK {
+		now := time.Now()
+		expected, ok := m.trend.Predict(now)
+		m.trend.Observe(now, totalSpeed)
+		if forecast, fok := m.trend.Predict(now.Add(cfg.ForecastHorizon)); ok && fok {
+			prometh.ForecastPush(b, cfg.ForecastHorizon.String(), forecast, expected, totalSpeed)
+		}
+	}
+
+	//Smoothed speed
+	if speedOK {
+		for _, ema := range m.speedEMA {
+			for addr, speed := range speeds {
+				if r.IsFresh(addr) {
+					ema.Observe(addr, speed)
+				}
+				prometh.SpeedEMAPush(b, addr, ema.Alpha, ema.Value(addr))
+			}
+		}
+	}
+
+	//Agent speed
+	if speedOK && m.agents != nil {
+		for addr, reported := range m.agents.Totals(time.Now(), 2*time.Duration(cfg.Interval)*time.Minute) {
+			if _, ok := speeds[addr]; ok {
+				prometh.AgentSpeedPush(b, addr, reported, speeds[addr])
+			}
+		}
+	}
+
+	//Restarts
+	if speedOK {
+		for addr, speed := range speeds {
+			if r.IsFresh(addr) {
+				m.restarts.Observe(addr, speed)
+			}
+			prometh.RestartsPush(b, addr, m.restarts.Count(addr))
+		}
+	}
+
+	//Missing streaks
+	if speedOK {
+		missing := make(map[string]int, len(addresses))
+		for _, addr := range addresses {
+			if _, ok := speeds[addr]; !ok {
+				missing[addr] = m.missing[addr] + 1
+			}
+			if !r.IsFresh(addr) || unparsed[addr] {
+				missing[addr] = m.missing[addr]
+			}
+			prometh.MissingCyclesPush(b, addr, missing[addr])
+			_, present := speeds[addr]
+			prometh.PresencePush(b, addr, present || unparsed[addr])
+		}
+		m.missing = missing
+	}
+
+	//Alerts
+	if speedOK {
+		now := time.Now()
+		for _, addr := range addresses {
+			if _, ok := m.retiring[addr]; ok {
+				m.alerts.Forget(addr)
+				continue
+			}
+			if _, ok := speeds[addr]; !ok && !r.IsFresh(addr) {
+				// not queried by the rotation yet
+				continue
+			}
+			if unparsed[addr] {
+				// listed, but its speed is unknown this cycle
+				continue
+			}
+			flapping := false
+			if cfg.AlertFlapChanges > 0 {
+				changes := m.flap.Observe(addr, speeds[addr] > 0, now)
+				m.alerts.Evaluate("prover_flapping", addr, float64(changes), now)
+				flapping = m.alerts.Firing("prover_flapping", addr)
+				prometh.FlappingPush(b, addr, changes, flapping)
+			}
+			// a flapping prover_offline keeps its state until the flapping stops
+			if !flapping {
+				m.alerts.Evaluate("prover_offline", addr, speeds[addr], now)
+			}
+			if speed, ok := speeds[addr]; ok {
+				// a missing address is prover_offline, not slow
+				m.alerts.Evaluate("speed_low", addr, speed, now)
+			}
+		}
+	}
+	if speedOK && totalOK {
+		now := time.Now()
+		m.alerts.Evaluate("fleet_speed_collapse", "", totalSpeed, now)
+		if cfg.AlertFleetDrop > 0 {
+			drop := 0.0
+			if m.fleetBaseline > 0 {
+				drop = max(0, (m.fleetBaseline-totalSpeed)/m.fleetBaseline*100)
+				m.alerts.Evaluate("fleet_speed_drop", "", drop, now)
+			}
+			if drop < cfg.AlertFleetDrop {
+				m.fleetBaseline = totalSpeed
+			}
+		}
+	}
+	if r.Failed() {
+		m.apiDown++
+	} else {
+		m.apiDown = 0
+	}
+	m.alerts.Evaluate("api_unreachable", "", float64(m.apiDown), time.Now())
+
+	//Reward
+	RewardURL := apiclient.RewardPath
+	if r.RewardsError != "" {
+		log.Printf("%s 请求失败:%s", RewardURL, r.RewardsError)
+	} else {
+		rewardRespon := r.Rewards
+		for _, r := range rewardRespon.Data.List {
+			prometh.RewardPush(b, r.Address, r.TotalReward)
+		}
+		prometh.TotalRewardPush(b, rewardRespon.Data.Total)
+
+		//Efficiency
+		now := time.Now()
+		isFresh := r.IsFresh
+		for _, r := range rewardRespon.Data.List {
+			reward, err := strconv.ParseFloat(r.TotalReward, 64)
+			if err != nil {
+				continue
+			}
+			if isFresh(r.Address) {
+				if speed, ok := speeds[r.Address]; ok {
+					m.efficiency.Observe(r.Address, now, speed, reward)
+				}
+				if v, ok := m.rewardRate.Observe(r.Address, now, reward); ok {
+					m.rates[r.Address] = v
+				} else {
+					delete(m.rates, r.Address)
+				}
+			}
+			if v, ok := m.rates[r.Address]; ok {
+				prometh.RewardRatePush(b, r.Address, v)
+			}
+			if v, ok := m.efficiency.CreditsPerTH(r.Address); ok {
+				prometh.EfficiencyPush(b, r.Address, v)
+			}
+		}
+		if totalReward, err := strconv.ParseFloat(rewardRespon.Data.Total, 64); err == nil {
+			if totalOK {
+				m.efficiency.Observe("", now, totalSpeed, totalReward)
+			}
+			if v, ok := m.rewardRate.Observe("", now, totalReward); ok {
+				prometh.TotalRewardRatePush(b, v)
+			}
+			if v, ok := m.efficiency.CreditsPerTH(""); ok {
+				prometh.TotalEfficiencyPush(b, v)
+			}
+		}
+	}
+
+	//History
+	if m.points != nil && speedOK && r.RewardsError == "" {
+		now := time.Now()
+		rewards := make(map[string]float64)
+		for _, r := range r.Rewards.Data.List {
+			rewards[r.Address], _ = strconv.ParseFloat(r.TotalReward, 64)
+		}
+		for _, addr := range addresses {
+			// an address the API left out has no data, not a zero speed
+			speed, ok := speeds[addr]
+			reward, rok := rewards[addr]
+			if !ok || !rok || !r.IsFresh(addr) {
+				continue
+			}
+			if err := m.points.Add(addr, store.Point{Time: now, Speed: speed, Reward: reward}); err != nil {
+				log.Printf("save history failed:%s", err)
+				break
+			}
+		}
+	}
+	if m.points != nil {
+		m.points.Expire(time.Now())
+	}
+
+	//Height
+	HeightURL := apiclient.HeightPath
+	if r.HeightsError != "" {
+		log.Printf("%s 请求失败:%s", HeightURL, r.HeightsError)
+	} else {
+		log.Printf("%s 请求成功\n", HeightURL)
+		for _, r := range r.Heights.Data {
+			prometh.HeightPush(b, r.Address, r.Height)
+		}
+	}
+	if m.postgres != nil || m.sqlite != nil || m.clickhouse != nil || m.archive != nil {
+		m.writeHistory(ctx, r, historyRows(r, speeds, addresses))
+	}
+
+	//block
+	BlockURL := apiclient.BlockPath
+	if r.BlockError != "" {
+		log.Printf("%s 请求失败:%s", BlockURL, r.BlockError)
+	} else {
+		log.Printf("%s 请求成功\n", BlockURL)
+		prometh.BlockPush(b, r.Block.Data.Height, r.Block.Data.ProofTarget, r.Block.Data.CoinbaseReward)
+
+		depth := 0
+		if height := r.Block.Data.Height; height > 0 {
+			if height < m.chainHeight {
+				depth = m.chainHeight - height
+				m.regressions++
+				log.Printf("chain height went back from %d to %d, depth %d", m.chainHeight, height, depth)
+			}
+			m.chainHeight = height
+		}
+		prometh.HeightRegressionsPush(b, m.regressions)
+
+		if target, err := strconv.ParseFloat(r.Block.Data.ProofTarget, 64); err == nil && target > 0 {
+			if m.proofTarget > 0 {
+				prometh.ProofTargetDeltaPush(b, target-m.proofTarget, (target-m.proofTarget)/m.proofTarget)
+			}
+			m.proofTarget = target
+		}
+
+		if epoch := r.Block.Data.Epoch; epoch != nil {
+			prometh.EpochPush(b, *epoch)
+		} else if cfg.EpochLength > 0 && r.Block.Data.Height > 0 {
+			prometh.EpochPush(b, r.Block.Data.Height/cfg.EpochLength)
+		}
+		m.alerts.Evaluate("chain_height_regression", "", float64(depth), time.Now())
+	}
+
+	//Height lag
+	if r.HeightsError == "" && r.BlockError == "" && r.Block.Data.Height > 0 && len(r.Heights.Data) > 0 {
+		minLag, maxLag := 0, 0
+		weightedLag, weight := 0.0, 0.0
+		lags := make([]int, 0, len(r.Heights.Data))
+		for i, h := range r.Heights.Data {
+			lag := r.Block.Data.Height - h.Height
+			prometh.HeightLagPush(b, h.Address, lag)
+			lags = append(lags, lag)
+
+			if i == 0 || lag < minLag {
+				minLag = lag
+			}
+			if i == 0 || lag > maxLag {
+				maxLag = lag
+			}
+			weightedLag += float64(lag) * speeds[h.Address]
+			weight += speeds[h.Address]
+		}
+		if weight > 0 {
+			weightedLag /= weight
+		}
+		prometh.TotalHeightLagPush(b, weightedLag, weight > 0, minLag, maxLag)
+		prometh.HeightLagBucketsPush(b, lags)
+	}
+
+	//Earnings
+	if speedOK && r.BlockError == "" {
+		proofTarget, perr := strconv.ParseFloat(r.Block.Data.ProofTarget, 64)
+		coinbase, cerr := strconv.ParseFloat(r.Block.Data.CoinbaseReward, 64)
+		if perr == nil && cerr == nil {
+			for addr, speed := range speeds {
+				if v, ok := derive.DailyEarnings(speed, proofTarget, coinbase); ok {
+					prometh.EarningsPush(b, addr, v)
+				}
+			}
+			if v, ok := derive.DailyEarnings(totalSpeed, proofTarget, coinbase); ok && totalOK {
+				prometh.TotalEarningsPush(b, v)
+			}
+		}
+	}
+
+	//Block rewards
+	if r.BlockRewards != nil {
+		if r.BlockRewardsError != "" {
+			log.Printf("%s 请求失败:%s", cfg.BlockRewardsPath, r.BlockRewardsError)
+		} else {
+			log.Printf("%s 请求成功\n", cfg.BlockRewardsPath)
+			m.countBlockRewards(b, r.BlockRewards.Data.List)
+		}
+	}
+	for key, reward := range m.blockRewards {
+		prometh.BlockRewardPush(b, key.addr, key.epoch, reward, m.rewardedBlocks[key])
+	}
+
+	//Pool
+	if r.Pool != nil {
+		if r.PoolError != "" {
+			log.Printf("%s 请求失败:%s", cfg.PoolStatsPath, r.PoolError)
+		} else {
+			log.Printf("%s 请求成功\n", cfg.PoolStatsPath)
+			prometh.PoolStatsPush(b, r.Pool.Data.Fee, r.Pool.Data.Luck, r.Pool.Data.Efficiency)
+		}
+	}
+
+	prometh.LatencyPush(b)
+	if skew, ok := prometh.ClockSkew(); ok {
+		prometh.ClockSkewPush(b, skew)
+		m.alerts.Evaluate("clock_skew", "", math.Abs(skew.Seconds()), time.Now())
+	}
+	prometh.TruncatedPush(b)
+
+	//Runtime info
+	hostname, _ := os.Hostname()
+	prometh.RuntimeInfoPush(b, cfg.Instance, version, hostname, m.configHash)
+
+	//Config drift
+	if m.drift != nil {
+		m.drift.Push(b)
+		hashes, err := m.drift.Hashes(time.Now())
+		if err != nil {
+			log.Printf("read config hashes failed:%s", err)
+		}
+		distinct := make(map[string]bool)
+		for _, hash := range hashes {
+			distinct[hash] = true
+		}
+		if len(distinct) > 1 {
+			log.Printf("config differs between instances: %v", hashes)
+		}
+		m.alerts.Evaluate("config_drift", "", float64(len(distinct)), time.Now())
+	}
+
+	//Derived
+	if len(m.derived) > 0 {
+		for addr, vars := range m.derivedVars(r, addresses) {
+			for name, e := range m.derived {
+				if v, ok := e.Eval(vars); ok {
+					prometh.DerivedPush(b, addr, name, v)
+				}
+			}
+		}
+	}
+
+	//Notes
+	for _, addr := range addresses {
+		if note, ok := m.notes[addr]; ok {
+			prometh.NotePush(b, addr, note)
+		}
+	}
+
+	//Address files
+	listed := m.addressList()
+	for _, f := range cfg.AddrFiles {
+		st, err := statAddressFile(f.Path)
+		if err != nil {
+			log.Printf("stat address file %s failed:%s", f.Path, err)
+			continue
+		}
+		count := len(listed)
+		if m.sources != nil {
+			count = 0
+			for _, addr := range listed {
+				if m.sources.Labels(addr)["source"] == f.Label() {
+					count++
+				}
+			}
+		}
+		prometh.AddressFilePush(b, cfg.Instance, f.Path, st.sha256, st.modified, st.lines, count)
+	}
+
+	//Address churn
+	m.mu.Lock()
+	churn, lastChurn := m.churn, m.lastChurn
+	m.mu.Unlock()
+	prometh.AddressChurnPush(b, churn[0], churn[1], lastChurn[0], lastChurn[1])
+
+	//Alert state
+	prometh.AlertActivePush(b, m.alerts.Active())
+
+	//Push
+	if ctx.Err() != nil {
+		return
+	}
+	if cfg.Strict && !m.strictCheck(r, b) {
+		return
+	}
+	if m.dedup != nil {
+		now := time.Now()
+		active, err := m.dedup.Active(now)
+		if err != nil {
+			log.Printf("check heartbeats failed, pushing anyway:%s", err)
+		}
+		if !active {
+			log.Printf("another instance is active, skipping pushes")
+			return
+		}
+		m.dedup.Beat(b, now)
+	}
+	pushStart := time.Now()
+	if failed := b.Flush(m.gw); failed > 0 {
+		log.Printf("%d of %d pushes failed", failed, len(b.Jobs()))
+	}
+
+	// The push phase is only known now, so the phase durations follow
+	// the batch in a push of their own.
+	phases := r.Phases()
+	phases["push"] = time.Since(pushStart)
+	pb := prometh.NewBatch()
+	pb.Sequence = m.seq
+	prometh.PhaseDurationPush(pb, phases)
+	pb.Flush(m.gw)
+	if total := time.Since(r.Started); cfg.SlowCycle > 0 && total > cfg.SlowCycle {
+		logSlowCycle(total, r, pushStart.Sub(processStart), b.PushTimes)
+	}
+}
+
+// derivedVars returns the values derived expressions can use per address,
+// see config.example.yaml for the names.
+func (m *monitor) derivedVars(r *collect.Snapshot, addresses []string) map[string]map[string]float64 {
+	vars := make(map[string]map[string]float64, len(addresses))
+	for _, addr := range addresses {
+		vars[addr] = make(map[string]float64)
+	}
+	set := func(addr, name, value string) {
+		if v, err := strconv.ParseFloat(value, 64); err == nil && vars[addr] != nil {
+			vars[addr][name] = v
+		}
+	}
+
+	var fleet []struct{ name, value string }
+	for i, sp := range r.Speeds {
+		if sp.Error != "" {
+			continue
+		}
+		for _, item := range sp.Data.List {
+			set(item.Address, "speed_"+strconv.Itoa(sp.Duration)+"m", item.Speed)
+			if i == m.shortest {
+				set(item.Address, "speed", item.Speed)
+			}
+		}
+		if i == m.shortest {
+			fleet = append(fleet, struct{ name, value string }{"total_speed", sp.Data.Total})
+		}
+	}
+	if r.RewardsError == "" {
+		if m.lastReward == nil {
+			m.lastReward = make(map[string]float64)
+		}
+		for _, item := range r.Rewards.Data.List {
+			set(item.Address, "reward", item.TotalReward)
+			reward, ok := vars[item.Address]["reward"]
+			if !ok || !r.IsFresh(item.Address) {
+				continue
+			}
+			if last, ok := m.lastReward[item.Address]; ok && reward >= last {
+				vars[item.Address]["reward_delta"] = reward - last
+			}
+			m.lastReward[item.Address] = reward
+		}
+		fleet = append(fleet, struct{ name, value string }{"total_reward", r.Rewards.Data.Total})
+	}
+	for addr, v := range m.rates {
+		if vars[addr] != nil {
+			vars[addr]["reward_rate"] = v
+		}
+	}
+	if r.HeightsError == "" {
+		for _, item := range r.Heights.Data {
+			if vars[item.Address] == nil {
+				continue
+			}
+			vars[item.Address]["height"] = float64(item.Height)
+			if r.BlockError == "" && r.Block.Data.Height > 0 {
+				vars[item.Address]["height_lag"] = float64(r.Block.Data.Height - item.Height)
+			}
+		}
+	}
+	for _, f := range fleet {
+		for _, addr := range addresses {
+			set(addr, f.name, f.value)
+		}
+	}
+	return vars
+}
+
+// blockRewardKey identifies the rewards of an address in one epoch.
+type blockRewardKey struct {
+	addr  string
+	epoch string
+}
+
+// countBlockRewards adds the rewards of the blocks above the last height
+// counted for each address, whatever order the API lists them in.
+func (m *monitor) countBlockRewards(b *prometh.Batch, items []apiclient.BlockRewardItem) {
+	last := make(map[string]int, len(m.rewardHeight))
+	for addr, height := range m.rewardHeight {
+		last[addr] = height
+	}
+	for _, item := range items {
+		if item.Height <= last[item.Address] {
+			continue
+		}
+		reward, err := strconv.ParseFloat(item.Reward, 64)
+		if err != nil {
+			log.Printf("parse block reward %s failed:%s", item.Reward, err)
+			prometh.RawValuePush(b, "aleo_prover_block_reward_total", item.Address, item.Reward)
+			continue
+		}
+		key := blockRewardKey{addr: item.Address}
+		if cfg.BlockRewardsByEpoch && item.Epoch != nil {
+			key.epoch = strconv.Itoa(*item.Epoch)
+		}
+		m.blockRewards[key] += reward
+		m.rewardedBlocks[key]++
+		m.rewardHeight[item.Address] = max(m.rewardHeight[item.Address], item.Height)
+	}
+}
+
+// historyRows has a row per fresh address with whatever of speed, reward
+// and height the API answered for it.
+func historyRows(r *collect.Snapshot, speeds map[string]float64, addresses []string) []store.Row {
+	rows := make(map[string]*store.Row)
+	row := func(addr string) *store.Row {
+		if rows[addr] == nil {
+			rows[addr] = &store.Row{Time: r.Finished, Addr: addr}
+		}
+		return rows[addr]
+	}
+	for addr, speed := range speeds {
+		speed := speed
+		row(addr).Speed = &speed
+	}
+	if r.RewardsError == "" {
+		for _, item := range r.Rewards.Data.List {
+			if reward, err := strconv.ParseFloat(item.TotalReward, 64); err == nil {
+				row(item.Address).Reward = &reward
+			}
+		}
+	}
+	if r.HeightsError == "" {
+		for _, item := range r.Heights.Data {
+			height := item.Height
+			row(item.Address).Height = &height
+		}
+	}
+
+	var list []store.Row
+	for _, addr := range addresses {
+		if rows[addr] != nil && r.IsFresh(addr) {
+			list = append(list, *rows[addr])
+		}
+	}
+	return list
+}
+
+func (m *monitor) writeHistory(ctx context.Context, r *collect.Snapshot, rows []store.Row) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.HTTPTimeout)
+	defer cancel()
+	if m.postgres != nil && len(rows) > 0 {
+		if err := m.postgres.Write(ctx, rows); err != nil {
+			log.Printf("write postgres failed:%s", err)
+		}
+	}
+	if m.clickhouse != nil && len(rows) > 0 {
+		if err := m.clickhouse.Write(ctx, rows); err != nil {
+			log.Printf("write clickhouse failed:%s", err)
+		}
+	}
+	if m.archive != nil {
+		if err := m.archive.Write(rows); err != nil {
+			log.Printf("write archive failed:%s", err)
+		}
+	}
+	if m.sqlite != nil {
+		c := store.Cycle{Started: r.Started, Finished: r.Finished, Addresses: len(r.Addresses)}
+		for _, run := range r.Runs {
+			if run.Error != "" {
+				c.Errors = append(c.Errors, run.Collector+": "+run.Error)
+			}
+		}
+		if err := m.sqlite.Write(ctx, c, rows); err != nil {
+			log.Printf("write sqlite failed:%s", err)
+		}
+	}
+}
+
+// strictCheck reports whether the cycle may push in strict mode, a cycle
+// with malformed answers or unparsable values pushes nothing. It exits once
+// StrictFailures cycles in a row failed.
+func (m *monitor) strictCheck(r *collect.Snapshot, b *prometh.Batch) bool {
+	if len(r.Malformed) == 0 && b.ParseErrors == 0 {
+		m.strictFailures = 0
+		return true
+	}
+	m.strictFailures++
+	log.Printf("strict mode: cycle failed, skipping pushes: malformed answers %v, %d unparsable values", r.Malformed, b.ParseErrors)
+	if cfg.StrictFailures > 0 && m.strictFailures >= cfg.StrictFailures {
+		log.Fatalf("strict mode: %d consecutive cycles failed", m.strictFailures)
+	}
+	return false
+}
+
+type slowCycle struct {
+	Event   string             `json:"event"`
+	Total   float64            `json:"total_seconds"`
+	Collect float64            `json:"collect_seconds"`
+	Process float64            `json:"process_seconds"`
+	Queries map[string]float64 `json:"queries"`
+	Pushes  map[string]float64 `json:"pushes"`
+}
+
+// logSlowCycle logs where a slow cycle spent its time as one JSON object.
+func logSlowCycle(total time.Duration, r *collect.Snapshot, process time.Duration, pushes map[string]time.Duration) {
+	ev := slowCycle{
+		Event:   "slow_cycle",
+		Total:   total.Seconds(),
+		Collect: r.Finished.Sub(r.Started).Seconds(),
+		Process: process.Seconds(),
+		Queries: make(map[string]float64, len(r.Runs)),
+		Pushes:  make(map[string]float64, len(pushes)),
+	}
+	for _, run := range r.Runs {
+		ev.Queries[run.Collector] = run.End.Sub(run.Start).Seconds()
+	}
+	for job, d := range pushes {
+		ev.Pushes[job] = d.Seconds()
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("encode slow cycle failed:%s", err)
+		return
+	}
+	log.Printf("slow cycle: %s", data)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"aleo-prover-monitor/alert"
+)
+
+func newNotifiers(client *http.Client) *alert.Dispatcher {
+	d := alert.NewDispatcher(30 * time.Second)
+	add := func(name string, n alert.Notifier) {
+		if q, ok := cfg.QuietHours[name]; ok {
+			if err := q.Validate(); err != nil {
+				log.Fatalf("Wrong quiet hours of %s: %v", name, err)
+			}
+			n = &alert.Quiet{Notifier: n, Hours: q}
+		}
+		d.Add(name, n)
+	}
+	if cfg.TelegramToken != "" && cfg.TelegramChatID != "" {
+		add("telegram", &alert.Telegram{Token: cfg.TelegramToken, ChatID: cfg.TelegramChatID, Client: client})
+	}
+	if cfg.SlackWebhook != "" {
+		tmpl, err := alert.ParseTemplate("slack", cfg.SlackTemplate)
+		if err != nil {
+			log.Fatalf("Error parsing slack template: %v", err)
+		}
+		add("slack", &alert.Slack{WebhookURL: cfg.SlackWebhook, Template: tmpl, Client: client})
+	}
+	if cfg.DiscordWebhook != "" {
+		add("discord", &alert.Discord{WebhookURL: cfg.DiscordWebhook, Client: client})
+	}
+	if cfg.PagerDutyKey != "" {
+		add("pagerduty", &alert.PagerDuty{RoutingKey: cfg.PagerDutyKey, Source: cfg.Instance, Severities: listSet(cfg.PagerDutySeverities), Client: client, URL: cfg.PagerDutyURL})
+	}
+	if cfg.OpsgenieKey != "" {
+		add("opsgenie", &alert.Opsgenie{APIKey: cfg.OpsgenieKey, Source: cfg.Instance, Severities: listSet(cfg.OpsgenieSeverities), Client: client, URL: cfg.OpsgenieURL})
+	}
+	if cfg.AlertmanagerURL != "" {
+		am := &alert.Alertmanager{URL: cfg.AlertmanagerURL, Labels: cfg.AlertmanagerLabels, Annotations: cfg.AlertmanagerAnnotations, Client: client}
+		go am.Run(context.Background(), time.Minute)
+		add("alertmanager", am)
+	}
+	if cfg.DingTalkWebhook != "" {
+		add("dingtalk", &alert.DingTalk{WebhookURL: cfg.DingTalkWebhook, Secret: cfg.DingTalkSecret, Client: client})
+	}
+	if cfg.WeComWebhook != "" {
+		add("wecom", &alert.WeCom{WebhookURL: cfg.WeComWebhook, Client: client})
+	}
+	if cfg.Webhook != "" {
+		webhook := &alert.Webhook{URL: cfg.Webhook, Client: client}
+		if cfg.WebhookTemplate != "" {
+			tmpl, err := alert.ParseTemplate("webhook", cfg.WebhookTemplate)
+			if err != nil {
+				log.Fatalf("Error parsing webhook template: %v", err)
+			}
+			webhook.Template = tmpl
+		}
+		add("webhook", webhook)
+	}
+	if f := newFeishu(client); f != nil {
+		add("feishu", f)
+	}
+	if e := newEmail(); e != nil {
+		add("email", e)
+	}
+	if cfg.TwilioSID != "" {
+		var to []string
+		for number := range listSet(cfg.TwilioTo) {
+			to = append(to, number)
+		}
+		if cfg.TwilioFrom == "" || len(to) == 0 {
+			log.Fatalf("SMS alerts need -twilioFrom and -twilioTo")
+		}
+		sort.Strings(to)
+		add("twilio", &alert.Twilio{AccountSID: cfg.TwilioSID, AuthToken: cfg.TwilioToken, From: cfg.TwilioFrom, To: to, Severities: listSet(cfg.TwilioSeverities), Client: client})
+	}
+	for name := range cfg.QuietHours {
+		if !d.Has(name) {
+			log.Printf("quiet hours set for %s, which is not configured", name)
+		}
+	}
+	return d
+}
+
+func newEmail() *alert.Email {
+	if cfg.SMTPAddr == "" {
+		return nil
+	}
+	var to []string
+	for addr := range listSet(cfg.SMTPTo) {
+		to = append(to, addr)
+	}
+	if cfg.SMTPFrom == "" || len(to) == 0 {
+		log.Fatalf("email alerts need -smtpFrom and -smtpTo")
+	}
+	sort.Strings(to)
+	return &alert.Email{Addr: cfg.SMTPAddr, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword, From: cfg.SMTPFrom, To: to, TLS: cfg.SMTPTLS}
+}
+
+func newFeishu(client *http.Client) *alert.Feishu {
+	if cfg.FeishuWebhook == "" {
+		return nil
+	}
+	return &alert.Feishu{WebhookURL: cfg.FeishuWebhook, Secret: cfg.FeishuSecret, Client: client}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"aleo-prover-monitor/prometh"
+)
+
+type onceMetric struct {
+	Job string `json:"job"`
+	prometh.Sample
+}
+
+type onceResult struct {
+	Time    time.Time    `json:"time"`
+	Metrics []onceMetric `json:"metrics"`
+}
+
+// writeOnce prints everything a -once cycle would have pushed as one JSON document.
+func writeOnce(w io.Writer, captured *prometh.FakeGateway) error {
+	result := onceResult{Time: time.Now().UTC(), Metrics: []onceMetric{}}
+	for _, group := range captured.Groups() {
+		for _, s := range prometh.Samples(group.Grouping, group.Families) {
+			result.Metrics = append(result.Metrics, onceMetric{Job: group.Job, Sample: s})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
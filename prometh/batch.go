@@ -0,0 +1,101 @@
+package prometh
+
+import (
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var cluster = map[string]string{"module": "cluster"}
+
+type jobBatch struct {
+	grouping  map[string]string
+	collector prometheus.Collector
+}
+
+// Batch collects one cycle's metrics as vectors per job, Flush then pushes
+// every job once instead of once per address and duration.
+type Batch struct {
+	jobs  map[string]*jobBatch
+	order []string
+	// PushTimes is how long each job's push took in the last Flush.
+	PushTimes map[string]time.Duration
+	// Sequence, if set, is pushed with every job as aleo_monitor_cycle_sequence
+	// so consumers can drop duplicate or out-of-order cycle data.
+	Sequence uint64
+	// ParseErrors counts the API values that were dropped for not being
+	// numbers.
+	ParseErrors int
+}
+
+func NewBatch() *Batch {
+	return &Batch{jobs: make(map[string]*jobBatch)}
+}
+
+func (b *Batch) GaugeVec(job string, grouping map[string]string, labels ...string) *prometheus.GaugeVec {
+	if jb, ok := b.jobs[job]; ok {
+		return jb.collector.(*prometheus.GaugeVec)
+	}
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: job}, labels)
+	b.add(job, grouping, vec)
+	return vec
+}
+
+func (b *Batch) CounterVec(job string, grouping map[string]string, labels ...string) *prometheus.CounterVec {
+	if jb, ok := b.jobs[job]; ok {
+		return jb.collector.(*prometheus.CounterVec)
+	}
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: job}, labels)
+	b.add(job, grouping, vec)
+	return vec
+}
+
+// Collector adds a collector owned by the caller, e.g. one that accumulates
+// across cycles.
+func (b *Batch) Collector(job string, grouping map[string]string, c prometheus.Collector) {
+	if _, ok := b.jobs[job]; ok {
+		return
+	}
+	b.add(job, grouping, c)
+}
+
+func (b *Batch) add(job string, grouping map[string]string, c prometheus.Collector) {
+	b.jobs[job] = &jobBatch{grouping: grouping, collector: c}
+	b.order = append(b.order, job)
+}
+
+func (b *Batch) Jobs() []string {
+	return append([]string(nil), b.order...)
+}
+
+// Flush pushes every job of the batch and returns the number of failed pushes.
+func (b *Batch) Flush(gw Gateway) int {
+	failed := 0
+	b.PushTimes = make(map[string]time.Duration, len(b.order))
+	for _, job := range b.order {
+		jb := b.jobs[job]
+		collectors := []prometheus.Collector{jb.collector}
+		if b.Sequence > 0 {
+			collectors = append(collectors, sequenceGauge(job, b.Sequence))
+		}
+		start := time.Now()
+		if err := gw.Push(job, jb.grouping, collectors...); err != nil {
+			log.Printf("push prometheus %s failed:%s", job, err)
+			failed++
+		}
+		b.PushTimes[job] = time.Since(start)
+	}
+	return failed
+}
+
+// sequenceGauge labels the sequence with its job, in exporter mode all jobs
+// end up in one scrape and would collide otherwise.
+func sequenceGauge(job string, seq uint64) prometheus.Collector {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "aleo_monitor_cycle_sequence",
+		ConstLabels: prometheus.Labels{"source_job": job},
+	})
+	g.Set(float64(seq))
+	return g
+}
@@ -0,0 +1,31 @@
+package prometh
+
+import (
+	"sync"
+	"time"
+)
+
+// clockSkew is the last skew of the local clock against an API server.
+var clockSkew struct {
+	sync.Mutex
+	skew time.Duration
+	ok   bool
+}
+
+func ObserveClockSkew(skew time.Duration) {
+	clockSkew.Lock()
+	clockSkew.skew, clockSkew.ok = skew, true
+	clockSkew.Unlock()
+}
+
+// ClockSkew returns the last observed skew, positive when the local clock is
+// ahead, and whether any was observed yet.
+func ClockSkew() (time.Duration, bool) {
+	clockSkew.Lock()
+	defer clockSkew.Unlock()
+	return clockSkew.skew, clockSkew.ok
+}
+
+func ClockSkewPush(b *Batch, skew time.Duration) {
+	b.GaugeVec("aleo_monitor_clock_skew_seconds", nil).WithLabelValues().Set(skew.Seconds())
+}
@@ -0,0 +1,121 @@
+package prometh
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// datadogBatch bounds the series per request, far below the payload limit
+// of the intake.
+const datadogBatch = 1000
+
+// Datadog submits every pushed sample as a gauge to the Datadog v2 series
+// API, with the labels as name:value tags, before handing the push to Next,
+// which may be nil to only submit.
+type Datadog struct {
+	URL    string
+	APIKey string
+	Prefix string
+	Client *http.Client
+	Next   Gateway
+}
+
+type datadogPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+type datadogSeries struct {
+	Metric string         `json:"metric"`
+	Type   int            `json:"type"`
+	Points []datadogPoint `json:"points"`
+	Tags   []string       `json:"tags,omitempty"`
+}
+
+// datadogGauge is the gauge type of the v2 series API.
+const datadogGauge = 3
+
+func (d *Datadog) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	ts := time.Now().Unix()
+	var series []datadogSeries
+	for _, s := range Samples(grouping, families) {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		series = append(series, datadogSeries{
+			Metric: d.Prefix + s.Name,
+			Type:   datadogGauge,
+			Points: []datadogPoint{{Timestamp: ts, Value: s.Value}},
+			Tags:   datadogTags(s.Labels),
+		})
+	}
+	var submitErr error
+	for len(series) > 0 {
+		n := min(len(series), datadogBatch)
+		if err := d.submit(series[:n]); err != nil {
+			submitErr = fmt.Errorf("submit %s to datadog: %v", job, err)
+			break
+		}
+		series = series[n:]
+	}
+
+	// a Datadog error or rate limit must not hold back the other sinks
+	if d.Next == nil {
+		return submitErr
+	}
+	return errors.Join(submitErr, d.Next.Push(job, grouping, collectors...))
+}
+
+func (d *Datadog) submit(series []datadogSeries) error {
+	body, err := json.Marshal(map[string][]datadogSeries{"series": series})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", strings.TrimRight(d.URL, "/")+"/api/v2/series", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", d.APIKey)
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func datadogTags(labels map[string]string) []string {
+	tags := make([]string, 0, len(labels))
+	for name, value := range labels {
+		if value != "" {
+			tags = append(tags, name+":"+value)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,57 @@
+package prometh
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDatadogSeries(t *testing.T) {
+	var req *http.Request
+	var body map[string][]datadogSeries
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	next := NewFakeGateway()
+	d := &Datadog{URL: srv.URL + "/", APIKey: "key", Prefix: "aleo.", Next: next}
+	if err := d.Push("aleo_prover_speed", cluster, speedSample()); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/api/v2/series" || req.Header.Get("DD-API-KEY") != "key" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s %v", req.URL, req.Header)
+	}
+	if len(body["series"]) != 1 {
+		t.Fatalf("series = %+v", body)
+	}
+	s := body["series"][0]
+	if s.Metric != "aleo.aleo_prover_speed" || s.Type != datadogGauge || len(s.Points) != 1 || s.Points[0].Value != 12.5 || s.Points[0].Timestamp == 0 {
+		t.Errorf("series = %+v", s)
+	}
+	if want := []string{"addr:aleo1abc", "duration:15", "module:cluster"}; !reflect.DeepEqual(s.Tags, want) {
+		t.Errorf("tags = %v, want %v", s.Tags, want)
+	}
+	checkForwarded(t, next)
+}
+
+func TestDatadogForwardsOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	next := NewFakeGateway()
+	d := &Datadog{URL: srv.URL, APIKey: "key", Next: next}
+	if err := d.Push("aleo_prover_speed", cluster, speedSample()); err == nil {
+		t.Error("failed submit not reported")
+	}
+	checkForwarded(t, next)
+}
@@ -0,0 +1,56 @@
+package prometh
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const configJob = "aleo_monitor_config_info"
+
+// ConfigDrift publishes the hash of this instance's effective config and
+// reads back the hashes of the other instances sharing the Pushgateway.
+type ConfigDrift struct {
+	URL      string
+	Client   *http.Client
+	Instance string
+	Hash     string
+	// Freshness ignores instances that haven't pushed within it, e.g. ones
+	// that were shut down.
+	Freshness time.Duration
+}
+
+func (d *ConfigDrift) Push(b *Batch) {
+	b.GaugeVec(configJob, map[string]string{"instance": d.Instance}, "hash").WithLabelValues(d.Hash).Set(1)
+}
+
+// Hashes returns the config hash of every instance that pushed recently,
+// including this one.
+func (d *ConfigDrift) Hashes(now time.Time) (map[string]string, error) {
+	hashes := map[string]string{d.Instance: d.Hash}
+	if d.URL == "" {
+		return hashes, nil
+	}
+
+	groups, err := listGroups(d.URL, d.Client)
+	if err != nil {
+		return hashes, err
+	}
+	for _, g := range groups.Data {
+		var labels map[string]string
+		if err := json.Unmarshal(g["labels"], &labels); err != nil || labels["job"] != Namespace+configJob {
+			continue
+		}
+		if at, ok := familyValue(g["push_time_seconds"]); !ok || now.Sub(time.Unix(int64(at), 0)) > d.Freshness {
+			continue
+		}
+		var family heartbeatFamily
+		if err := json.Unmarshal(g[configJob], &family); err != nil || len(family.Metrics) == 0 {
+			continue
+		}
+		if hash := family.Metrics[0].Labels["hash"]; hash != "" && labels["instance"] != d.Instance {
+			hashes[labels["instance"]] = hash
+		}
+	}
+	return hashes, nil
+}
@@ -0,0 +1,62 @@
+package prometh
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Exporter is a Gateway for pull mode, it holds the latest push of every
+// group and serves them for scraping with the grouping turned into labels.
+type Exporter struct {
+	store groupStore
+}
+
+func NewExporter() *Exporter {
+	return &Exporter{store: newGroupStore()}
+}
+
+func (e *Exporter) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	return e.store.put(job, grouping, collectors...)
+}
+
+func (e *Exporter) Gather() ([]*dto.MetricFamily, error) {
+	byName := make(map[string]*dto.MetricFamily)
+	for _, group := range e.store.list() {
+		for _, mf := range group.Families {
+			merged, ok := byName[mf.GetName()]
+			if !ok {
+				merged = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+				byName[mf.GetName()] = merged
+			}
+			for _, m := range mf.Metric {
+				m = proto.Clone(m).(*dto.Metric)
+				for name, value := range group.Grouping {
+					m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+				}
+				sort.Slice(m.Label, func(i, j int) bool { return m.Label[i].GetName() < m.Label[j].GetName() })
+				merged.Metric = append(merged.Metric, m)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		families = append(families, byName[name])
+	}
+	return families, nil
+}
+
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e, promhttp.HandlerOpts{})
+}
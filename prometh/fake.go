@@ -0,0 +1,51 @@
+package prometh
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// FakeGateway is an in-memory Gateway keeping the last push of every group,
+// so tests can assert what the Push functions emit without a Pushgateway.
+type FakeGateway struct {
+	store  groupStore
+	pushes atomic.Int64
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{store: newGroupStore()}
+}
+
+func (f *FakeGateway) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	f.pushes.Add(1)
+	return f.store.put(job, grouping, collectors...)
+}
+
+func (f *FakeGateway) Groups() []Group {
+	return f.store.list()
+}
+
+// Value returns the value of the first sample of metric name in the group.
+func (f *FakeGateway) Value(job string, grouping map[string]string, name string) (float64, bool) {
+	group, ok := f.store.get(job, grouping)
+	if !ok {
+		return 0, false
+	}
+	for _, mf := range group.Families {
+		if mf.GetName() != name || len(mf.Metric) == 0 {
+			continue
+		}
+		return metricValue(mf.Metric[0]), true
+	}
+	return 0, false
+}
+
+func (f *FakeGateway) Pushes() int {
+	return int(f.pushes.Load())
+}
+
+func (f *FakeGateway) Reset() {
+	f.store.reset()
+	f.pushes.Store(0)
+}
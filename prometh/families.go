@@ -0,0 +1,58 @@
+package prometh
+
+import (
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// familyCollector turns gathered families back into a collector, so
+// decorators can rewrite samples and still hand a push to the next gateway.
+// It describes nothing and is registered unchecked.
+type familyCollector []*dto.MetricFamily
+
+func (f familyCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (f familyCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, mf := range f {
+		for _, m := range mf.Metric {
+			names := make([]string, 0, len(m.Label))
+			values := make([]string, 0, len(m.Label))
+			for _, lp := range m.Label {
+				names = append(names, lp.GetName())
+				values = append(values, lp.GetValue())
+			}
+			desc := prometheus.NewDesc(mf.GetName(), mf.GetHelp(), names, nil)
+
+			metric, err := constMetric(desc, mf.GetType(), m, values)
+			if err != nil {
+				log.Printf("rebuild metric %s failed:%s", mf.GetName(), err)
+				continue
+			}
+			ch <- metric
+		}
+	}
+}
+
+func constMetric(desc *prometheus.Desc, typ dto.MetricType, m *dto.Metric, values []string) (prometheus.Metric, error) {
+	switch typ {
+	case dto.MetricType_COUNTER:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, m.Counter.GetValue(), values...)
+	case dto.MetricType_GAUGE:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.Gauge.GetValue(), values...)
+	case dto.MetricType_HISTOGRAM:
+		buckets := make(map[float64]uint64, len(m.Histogram.Bucket))
+		for _, b := range m.Histogram.Bucket {
+			buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+		return prometheus.NewConstHistogram(desc, m.Histogram.GetSampleCount(), m.Histogram.GetSampleSum(), buckets, values...)
+	case dto.MetricType_SUMMARY:
+		quantiles := make(map[float64]float64, len(m.Summary.Quantile))
+		for _, q := range m.Summary.Quantile {
+			quantiles[q.GetQuantile()] = q.GetValue()
+		}
+		return prometheus.NewConstSummary(desc, m.Summary.GetSampleCount(), m.Summary.GetSampleSum(), quantiles, values...)
+	}
+	return prometheus.NewConstMetric(desc, prometheus.UntypedValue, metricValue(m), values...)
+}
@@ -0,0 +1,68 @@
+package prometh
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Gateway is where the Push functions deliver their collectors, one call per
+// job and grouping, replacing whatever was stored for that group before.
+type Gateway interface {
+	Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error
+}
+
+// Namespace prefixes every job name on the Pushgateway, so monitors of
+// several teams can share one without touching each other's groups.
+var Namespace string
+
+type PushGateway struct {
+	URL    string
+	Client *http.Client
+	// StreamSeries, if positive, streams jobs with more series than this
+	// instead of encoding them in memory first.
+	StreamSeries int
+	// Context, if set, cancels the pushes in flight once done, e.g. on
+	// shutdown.
+	Context context.Context
+}
+
+func NewPushGateway(url string, client *http.Client) *PushGateway {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &PushGateway{URL: url, Client: client}
+}
+
+func (g *PushGateway) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	job = Namespace + job
+	pusher := push.New(g.URL, job).Client(g.Client)
+	for name, value := range grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	if g.StreamSeries <= 0 {
+		for _, c := range collectors {
+			pusher = pusher.Collector(c)
+		}
+		return pusher.PushContext(g.context())
+	}
+
+	mfs, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+	if seriesCount(mfs) > g.StreamSeries {
+		return g.stream(job, grouping, mfs)
+	}
+	return pusher.Gatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return mfs, nil })).PushContext(g.context())
+}
+
+func (g *PushGateway) context() context.Context {
+	if g.Context == nil {
+		return context.Background()
+	}
+	return g.Context
+}
@@ -0,0 +1,151 @@
+package prometh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Graphite writes every pushed sample to Carbon in the plaintext protocol
+// over TCP before handing the push to Next, which may be nil to only write.
+// The path of a sample comes from the template of its metric in Templates,
+// "*" is the template of all others, and without one it is the metric name
+// followed by the label values sorted by label name. Templates name the
+// metric as {name} and a label as {label}, e.g. provers.{addr}.speed.{duration},
+// empty path components of missing labels are dropped.
+type Graphite struct {
+	Addr      string
+	Prefix    string
+	Templates map[string]string
+	Timeout   time.Duration
+	Next      Gateway
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (g *Graphite) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	for _, s := range Samples(grouping, families) {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		body.WriteString(g.path(s))
+		body.WriteByte(' ')
+		body.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+		body.WriteByte(' ')
+		body.WriteString(ts)
+		body.WriteByte('\n')
+	}
+	var writeErr error
+	if body.Len() > 0 {
+		if err := g.write(body.Bytes()); err != nil {
+			writeErr = fmt.Errorf("write %s to graphite: %v", job, err)
+		}
+	}
+
+	// a Graphite outage must not hold back the other sinks
+	if g.Next == nil {
+		return writeErr
+	}
+	return errors.Join(writeErr, g.Next.Push(job, grouping, collectors...))
+}
+
+// write sends body over the open connection, dialing again once when it
+// broke since the last write.
+func (g *Graphite) write(body []byte) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if g.conn == nil {
+			g.conn, err = net.DialTimeout("tcp", g.Addr, g.timeout())
+			if err != nil {
+				return err
+			}
+		}
+		g.conn.SetWriteDeadline(time.Now().Add(g.timeout()))
+		if _, err = g.conn.Write(body); err == nil {
+			return nil
+		}
+		g.conn.Close()
+		g.conn = nil
+	}
+	return err
+}
+
+func (g *Graphite) timeout() time.Duration {
+	if g.Timeout > 0 {
+		return g.Timeout
+	}
+	return 10 * time.Second
+}
+
+func (g *Graphite) path(s Sample) string {
+	var parts []string
+	if tmpl, ok := g.Templates[s.Name]; ok {
+		parts = strings.Split(expandPath(tmpl, s), ".")
+	} else if tmpl, ok := g.Templates["*"]; ok {
+		parts = strings.Split(expandPath(tmpl, s), ".")
+	} else {
+		names := make([]string, 0, len(s.Labels))
+		for name := range s.Labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts = append(parts, s.Name)
+		for _, name := range names {
+			parts = append(parts, graphiteEscaper.Replace(s.Labels[name]))
+		}
+	}
+
+	var path []string
+	for _, part := range append(strings.Split(g.Prefix, "."), parts...) {
+		if part != "" {
+			path = append(path, part)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+// expandPath replaces {name} with the metric name and {label} with the
+// escaped label value, unknown labels with nothing.
+func expandPath(tmpl string, s Sample) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		end := strings.IndexByte(tmpl[start+1:], '}')
+		if start < 0 || end < 0 {
+			b.WriteString(tmpl)
+			return b.String()
+		}
+		b.WriteString(tmpl[:start])
+		key := tmpl[start+1 : start+1+end]
+		if key == "name" {
+			b.WriteString(s.Name)
+		} else {
+			b.WriteString(graphiteEscaper.Replace(s.Labels[key]))
+		}
+		tmpl = tmpl[start+end+2:]
+	}
+}
+
+// graphiteEscaper replaces the characters separating path components and
+// the fields of a line.
+var graphiteEscaper = strings.NewReplacer(".", "_", " ", "_", "\t", "_", "\n", "_", "/", "_")
@@ -0,0 +1,75 @@
+package prometh
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestGraphitePaths(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					lines <- sc.Text()
+				}
+			}()
+		}
+	}()
+
+	tests := []struct {
+		templates map[string]string
+		want      string
+	}{
+		{nil, `^aleo\.aleo_prover_speed\.aleo1abc\.15\.cluster 12\.5 \d+$`},
+		{map[string]string{"aleo_prover_speed": "provers.{addr}.speed.{duration}"}, `^aleo\.provers\.aleo1abc\.speed\.15 12\.5 \d+$`},
+		{map[string]string{"*": "{name}.{missing}.{addr}"}, `^aleo\.aleo_prover_speed\.aleo1abc 12\.5 \d+$`},
+	}
+	for _, tt := range tests {
+		next := NewFakeGateway()
+		g := &Graphite{Addr: ln.Addr().String(), Prefix: "aleo.", Templates: tt.templates, Timeout: time.Second, Next: next}
+		if err := g.Push("aleo_prover_speed", cluster, speedSample()); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case line := <-lines:
+			if !regexp.MustCompile(tt.want).MatchString(line) {
+				t.Errorf("templates %v: line = %q, want %s", tt.templates, line, tt.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no line received")
+		}
+		checkForwarded(t, next)
+		g.conn.Close()
+	}
+}
+
+func TestGraphiteForwardsOnFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	next := NewFakeGateway()
+	g := &Graphite{Addr: addr, Timeout: time.Second, Next: next}
+	if err := g.Push("aleo_prover_speed", cluster, speedSample()); err == nil {
+		t.Error("failed write not reported")
+	}
+	checkForwarded(t, next)
+}
@@ -0,0 +1,134 @@
+package prometh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Influx writes every pushed sample to InfluxDB in line protocol, with the
+// sample name as measurement, the labels as tags and the value as the value
+// field, before handing the push to Next, which may be nil to only write.
+// Bucket selects the v2 API with token auth, Database the v1 API.
+type Influx struct {
+	URL    string
+	Client *http.Client
+	Next   Gateway
+
+	// v1
+	Database        string
+	RetentionPolicy string
+	Username        string
+	Password        string
+
+	// v2
+	Org    string
+	Bucket string
+	Token  string
+}
+
+func (i *Influx) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	ts := strconv.FormatInt(time.Now().UnixNano(), 10)
+	for _, s := range Samples(grouping, families) {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		writeLine(&body, s, ts)
+	}
+	var writeErr error
+	if body.Len() > 0 {
+		if err := i.write(&body); err != nil {
+			writeErr = fmt.Errorf("write %s to influxdb: %v", job, err)
+		}
+	}
+
+	// an InfluxDB outage must not hold back the other sinks
+	if i.Next == nil {
+		return writeErr
+	}
+	return errors.Join(writeErr, i.Next.Push(job, grouping, collectors...))
+}
+
+func (i *Influx) write(body io.Reader) error {
+	q := url.Values{"precision": {"ns"}}
+	path := "/write"
+	if i.Bucket != "" {
+		path = "/api/v2/write"
+		q.Set("org", i.Org)
+		q.Set("bucket", i.Bucket)
+	} else {
+		q.Set("db", i.Database)
+		if i.RetentionPolicy != "" {
+			q.Set("rp", i.RetentionPolicy)
+		}
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimRight(i.URL, "/")+path+"?"+q.Encode(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.Token != "" {
+		req.Header.Set("Authorization", "Token "+i.Token)
+	} else if i.Username != "" {
+		req.SetBasicAuth(i.Username, i.Password)
+	}
+
+	client := i.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+func writeLine(w *bytes.Buffer, s Sample, ts string) {
+	w.WriteString(measurementEscaper.Replace(s.Name))
+	names := make([]string, 0, len(s.Labels))
+	for name, value := range s.Labels {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w.WriteByte(',')
+		w.WriteString(tagEscaper.Replace(name))
+		w.WriteByte('=')
+		w.WriteString(tagEscaper.Replace(s.Labels[name]))
+	}
+	w.WriteString(" value=")
+	w.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+	w.WriteByte(' ')
+	w.WriteString(ts)
+	w.WriteByte('\n')
+}
@@ -0,0 +1,89 @@
+package prometh
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// speedSample is one aleo_prover_speed series as the speed push emits it,
+// pushed with the cluster grouping.
+func speedSample() prometheus.Collector {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "aleo_prover_speed"}, []string{"addr", "duration"})
+	vec.WithLabelValues("aleo1abc", "15").Set(12.5)
+	return vec
+}
+
+// checkForwarded asserts that next got the speed sample.
+func checkForwarded(t *testing.T, next *FakeGateway) {
+	t.Helper()
+	if v, ok := next.Value("aleo_prover_speed", cluster, "aleo_prover_speed"); !ok || v != 12.5 {
+		t.Errorf("next got %v, %v, want 12.5", v, ok)
+	}
+}
+
+func TestInfluxWriteV1(t *testing.T) {
+	var req *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		req, body = r, string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	next := NewFakeGateway()
+	i := &Influx{URL: srv.URL, Database: "aleo", RetentionPolicy: "week", Username: "u", Password: "p", Next: next}
+	if err := i.Push("aleo_prover_speed", cluster, speedSample()); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/write" || req.URL.Query().Get("db") != "aleo" || req.URL.Query().Get("rp") != "week" || req.URL.Query().Get("precision") != "ns" {
+		t.Errorf("url = %s", req.URL)
+	}
+	if user, pass, ok := req.BasicAuth(); !ok || user != "u" || pass != "p" {
+		t.Errorf("basic auth = %q, %q, %v", user, pass, ok)
+	}
+	if !regexp.MustCompile(`^aleo_prover_speed,addr=aleo1abc,duration=15,module=cluster value=12.5 \d+\n$`).MatchString(body) {
+		t.Errorf("body = %q", body)
+	}
+	checkForwarded(t, next)
+}
+
+func TestInfluxWriteV2(t *testing.T) {
+	var req *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	i := &Influx{URL: srv.URL, Org: "ops", Bucket: "aleo", Token: "secret"}
+	if err := i.Push("aleo_prover_speed", cluster, speedSample()); err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.Path != "/api/v2/write" || req.URL.Query().Get("org") != "ops" || req.URL.Query().Get("bucket") != "aleo" {
+		t.Errorf("url = %s", req.URL)
+	}
+	if got := req.Header.Get("Authorization"); got != "Token secret" {
+		t.Errorf("authorization = %q", got)
+	}
+}
+
+func TestInfluxForwardsOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	next := NewFakeGateway()
+	i := &Influx{URL: srv.URL, Database: "aleo", Next: next}
+	if err := i.Push("aleo_prover_speed", cluster, speedSample()); err == nil {
+		t.Error("failed write not reported")
+	}
+	checkForwarded(t, next)
+}
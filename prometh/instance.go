@@ -0,0 +1,126 @@
+package prometh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const heartbeatJob = "aleo_monitor_heartbeat_timestamp_seconds"
+
+// WithGrouping adds extra grouping labels, e.g. the instance, to every push.
+type WithGrouping struct {
+	Next  Gateway
+	Extra map[string]string
+}
+
+func (g *WithGrouping) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	merged := make(map[string]string, len(grouping)+len(g.Extra))
+	for k, v := range grouping {
+		merged[k] = v
+	}
+	for k, v := range g.Extra {
+		merged[k] = v
+	}
+	return g.Next.Push(job, merged, collectors...)
+}
+
+// Dedup lets redundant monitors share one Pushgateway: the active instance
+// pushes a heartbeat, the others stay passive while it is fresh. When two
+// instances are active at once the lower name wins.
+type Dedup struct {
+	URL       string
+	Client    *http.Client
+	Instance  string
+	Freshness time.Duration
+}
+
+type heartbeatGroups struct {
+	Data []map[string]json.RawMessage `json:"data"`
+}
+
+type heartbeatFamily struct {
+	Metrics []struct {
+		Labels map[string]string `json:"labels"`
+		Value  string            `json:"value"`
+	} `json:"metrics"`
+}
+
+// Active reports whether this instance should push this cycle.
+func (d *Dedup) Active(now time.Time) (bool, error) {
+	beats, err := d.heartbeats()
+	if err != nil {
+		return true, err
+	}
+	for instance, at := range beats {
+		if instance == d.Instance || now.Sub(at) > d.Freshness {
+			continue
+		}
+		if _, self := beats[d.Instance]; !self || instance < d.Instance {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (d *Dedup) Beat(b *Batch, now time.Time) {
+	b.GaugeVec(heartbeatJob, map[string]string{"instance": d.Instance}).WithLabelValues().Set(float64(now.Unix()))
+}
+
+func (d *Dedup) heartbeats() (map[string]time.Time, error) {
+	groups, err := listGroups(d.URL, d.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	beats := make(map[string]time.Time)
+	for _, g := range groups.Data {
+		var labels map[string]string
+		if err := json.Unmarshal(g["labels"], &labels); err != nil || labels["job"] != Namespace+heartbeatJob {
+			continue
+		}
+		if at, ok := familyValue(g[heartbeatJob]); ok {
+			beats[labels["instance"]] = time.Unix(int64(at), 0)
+		}
+	}
+	return beats, nil
+}
+
+// familyValue parses the value of the first metric of a family as listed by
+// the Pushgateway API.
+func familyValue(raw json.RawMessage) (float64, bool) {
+	var family heartbeatFamily
+	if err := json.Unmarshal(raw, &family); err != nil || len(family.Metrics) == 0 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(family.Metrics[0].Value, 64)
+	return v, err == nil
+}
+
+func listGroups(url string, client *http.Client) (*heartbeatGroups, error) {
+	resp, err := client.Get(strings.TrimRight(url, "/") + "/api/v1/metrics")
+	if err != nil {
+		return nil, fmt.Errorf("list pushgateway groups: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read pushgateway groups: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list pushgateway groups: %s", resp.Status)
+	}
+
+	var groups heartbeatGroups
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return nil, fmt.Errorf("decode pushgateway groups: %v", err)
+	}
+	return &groups, nil
+}
@@ -0,0 +1,51 @@
+package prometh
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type jsonEvent struct {
+	Time string `json:"time"`
+	Job  string `json:"job"`
+	Sample
+}
+
+// JSONLog writes every pushed sample as a JSON line before handing the push
+// to Next, which may be nil to only log.
+type JSONLog struct {
+	mu   sync.Mutex
+	w    io.Writer
+	Next Gateway
+}
+
+func NewJSONLog(w io.Writer, next Gateway) *JSONLog {
+	return &JSONLog{w: w, Next: next}
+}
+
+func (j *JSONLog) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	j.mu.Lock()
+	enc := json.NewEncoder(j.w)
+	for _, s := range Samples(grouping, families) {
+		if err := enc.Encode(jsonEvent{Time: now, Job: job, Sample: s}); err != nil {
+			j.mu.Unlock()
+			return err
+		}
+	}
+	j.mu.Unlock()
+
+	if j.Next == nil {
+		return nil
+	}
+	return j.Next.Push(job, grouping, collectors...)
+}
@@ -0,0 +1,79 @@
+package prometh
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// AddressLabels adds per-address labels, e.g. from an inventory, to every
+// series carrying an addr label before handing the push to Next. Addresses
+// without labels get the Names set empty, so a family keeps one label set.
+type AddressLabels struct {
+	Next  Gateway
+	Names []string
+
+	mu     sync.RWMutex
+	labels map[string]map[string]string
+}
+
+// Set merges labels by address over the ones known so far.
+func (a *AddressLabels) Set(labels map[string]map[string]string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.labels == nil {
+		a.labels = make(map[string]map[string]string)
+	}
+	for addr, l := range labels {
+		a.labels[addr] = l
+	}
+}
+
+// Labels returns the labels known for addr.
+func (a *AddressLabels) Labels(addr string) map[string]string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.labels[addr]
+}
+
+func (a *AddressLabels) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	changed := false
+	for _, mf := range families {
+		for _, m := range mf.Metric {
+			addr, ok := labelValue(m, "addr")
+			if !ok {
+				continue
+			}
+			for _, name := range a.Names {
+				if _, taken := labelValue(m, name); taken {
+					continue
+				}
+				m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(name), Value: proto.String(a.labels[addr][name])})
+			}
+			changed = true
+		}
+	}
+	a.mu.RUnlock()
+
+	if !changed {
+		return a.Next.Push(job, grouping, collectors...)
+	}
+	return a.Next.Push(job, grouping, familyCollector(families))
+}
+
+func labelValue(m *dto.Metric, name string) (string, bool) {
+	for _, lp := range m.Label {
+		if lp.GetName() == name {
+			return lp.GetValue(), true
+		}
+	}
+	return "", false
+}
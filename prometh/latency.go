@@ -0,0 +1,37 @@
+package prometh
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const latencyJob = "aleo_monitor_api_request_duration_seconds"
+
+// apiLatency lives for the whole process so the histogram stays cumulative
+// across cycles.
+var apiLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    latencyJob,
+	Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+}, []string{"endpoint"})
+
+func ObserveAPILatency(endpoint string, elapsed time.Duration) {
+	apiLatency.WithLabelValues(endpoint).Observe(elapsed.Seconds())
+}
+
+func LatencyPush(b *Batch) {
+	b.Collector(latencyJob, nil, apiLatency)
+}
+
+const truncatedJob = "aleo_monitor_api_truncated_total"
+
+// apiTruncated counts the API answers whose list fell short of their total.
+var apiTruncated = prometheus.NewCounterVec(prometheus.CounterOpts{Name: truncatedJob}, []string{"endpoint"})
+
+func CountTruncatedAnswer(endpoint string) {
+	apiTruncated.WithLabelValues(endpoint).Inc()
+}
+
+func TruncatedPush(b *Batch) {
+	b.Collector(truncatedJob, nil, apiTruncated)
+}
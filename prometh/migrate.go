@@ -0,0 +1,146 @@
+package prometh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Schema is the grouping layout every job is pushed with by this version.
+// Groups of these jobs stored under any other layout come from older
+// versions and are removed by Migrate.
+var Schema = map[string][]string{
+	"aleo_prover_speed":                           {"module"},
+	"aleo_prover_speed_ema":                       {"module"},
+	"aleo_prover_agent_speed":                     {"module"},
+	"aleo_prover_total_speed":                     {},
+	"aleo_prover_reward":                          {"module"},
+	"aleo_prover_total_reward":                    {},
+	"aleo_prover_latest_height":                   {"module"},
+	"aleo_prover_height_lag":                      {"module"},
+	"aleo_prover_height_lag_bucket":               {},
+	"aleo_prover_total_height_lag":                {},
+	"aleo_chain_proof_target_delta":               {},
+	"aleo_chain_epoch":                            {},
+	"aleo_chain_height_regressions_total":         {},
+	"aleo_prover_latest_block":                    {},
+	"aleo_prover_reward_rate":                     {"module"},
+	"aleo_prover_total_reward_rate":               {},
+	"aleo_prover_estimated_daily_earnings":        {"module"},
+	"aleo_prover_total_estimated_daily_earnings":  {},
+	"aleo_prover_credits_per_th":                  {"module"},
+	"aleo_prover_total_credits_per_th":            {},
+	"aleo_pool_stats":                             {},
+	"aleo_prover_restarts_detected_total":         {"module"},
+	"aleo_prover_raw_value_info":                  {},
+	"aleo_prover_consecutive_missing_cycles":      {"module"},
+	"aleo_prover_present":                         {"module"},
+	"aleo_prover_flapping":                        {"module"},
+	"aleo_prover_note_info":                       {"module"},
+	"aleo_prover_derived":                         {"module"},
+	"aleo_prover_solutions_total":                 {"module"},
+	"aleo_prover_last_solution_timestamp_seconds": {"module"},
+	"aleo_prover_block_reward_total":              {"module"},
+	"aleo_prover_rewarded_blocks_total":           {"module"},
+	"aleo_prover_total_speed_forecast":            {},
+	"aleo_monitor_runtime":                        {},
+	"aleo_monitor_phase_duration_seconds":         {},
+	"aleo_monitor_alert_active":                   {},
+	"aleo_monitor_address_churn_total":            {},
+	"aleo_monitor_address_churn_last_reload":      {},
+	latencyJob:                                    {},
+	"aleo_monitor_clock_skew_seconds":             {},
+	truncatedJob:                                  {},
+	"aleo_prover_parse_failures_total":            {},
+	"aleo_monitor_runtime_info":                   {"instance"},
+	"aleo_monitor_address_file":                   {"instance"},
+}
+
+type gatewayGroups struct {
+	Status string `json:"status"`
+	Data   []struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"data"`
+}
+
+// Migrate deletes the groups of known jobs whose grouping layout differs from
+// Schema, so upgrades don't leave stale series next to the new ones. Extra
+// names grouping labels added on top of Schema, like the instance. Only jobs
+// of the Namespace are looked at.
+func Migrate(url string, client *http.Client, extra ...string) (int, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(strings.TrimRight(url, "/") + "/api/v1/metrics")
+	if err != nil {
+		return 0, fmt.Errorf("list pushgateway groups: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("read pushgateway groups: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("list pushgateway groups: %s", resp.Status)
+	}
+
+	var groups gatewayGroups
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return 0, fmt.Errorf("decode pushgateway groups: %v", err)
+	}
+
+	deleted := 0
+	for _, g := range groups.Data {
+		job := g.Labels["job"]
+		if !strings.HasPrefix(job, Namespace) {
+			continue
+		}
+		want, ok := Schema[strings.TrimPrefix(job, Namespace)]
+		if !ok {
+			continue
+		}
+
+		var names, layout []string
+		for name, value := range g.Labels {
+			if name == "job" || value == "" {
+				continue
+			}
+			names = append(names, name)
+			if !contains(extra, name) || contains(want, name) {
+				layout = append(layout, name)
+			}
+		}
+		sort.Strings(names)
+		sort.Strings(layout)
+		if strings.Join(layout, ",") == strings.Join(want, ",") {
+			continue
+		}
+
+		pusher := push.New(url, job).Client(client)
+		for _, name := range names {
+			pusher = pusher.Grouping(name, g.Labels[name])
+		}
+		if err := pusher.Delete(); err != nil {
+			return deleted, fmt.Errorf("delete legacy group %v: %v", g.Labels, err)
+		}
+		log.Printf("deleted legacy pushgateway group %v", g.Labels)
+		deleted++
+	}
+	return deleted, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package prometh
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestSchemaMatchesPushes keeps Schema in line with the grouping the Push
+// functions actually use, a drift would make Migrate delete live groups.
+func TestSchemaMatchesPushes(t *testing.T) {
+	for _, tt := range pushTests {
+		b := NewBatch()
+		tt.push(b)
+		fake := NewFakeGateway()
+		b.Flush(fake)
+		for _, g := range fake.Groups() {
+			want, ok := Schema[g.Job]
+			if !ok {
+				t.Errorf("%s: job %s missing from Schema", tt.name, g.Job)
+				continue
+			}
+			var names []string
+			for name := range g.Grouping {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(want, ",") {
+				t.Errorf("%s: job %s pushed with grouping %v, Schema has %v", tt.name, g.Job, names, want)
+			}
+		}
+	}
+}
+
+// pushgateway answers the group listing with groups and records the
+// groups deleted.
+func pushgateway(t *testing.T, groups ...map[string]string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/metrics":
+			var resp gatewayGroups
+			resp.Status = "success"
+			for _, labels := range groups {
+				resp.Data = append(resp.Data, struct {
+					Labels map[string]string `json:"labels"`
+				}{labels})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/metrics/"):
+			mu.Lock()
+			deleted = append(deleted, r.URL.Path)
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(deleted)
+		return deleted
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	srv, deleted := pushgateway(t,
+		// current layouts
+		map[string]string{"job": "aleo_prover_speed", "module": "cluster"},
+		map[string]string{"job": "aleo_prover_total_speed"},
+		map[string]string{"job": "aleo_prover_reward", "module": "cluster", "instance": "vm1"},
+		map[string]string{"job": "aleo_prover_total_reward", "instance": "vm1"},
+		map[string]string{"job": "aleo_monitor_runtime_info", "instance": "vm1"},
+		// legacy layouts
+		map[string]string{"job": "aleo_prover_speed", "module": "cluster", "addr": "aleo1abc"},
+		map[string]string{"job": "aleo_prover_total_speed", "module": "cluster"},
+		map[string]string{"job": "aleo_prover_latest_height", "instance": "vm1"},
+		map[string]string{"job": "aleo_monitor_runtime_info"},
+		// empty labels don't count as grouping
+		map[string]string{"job": "aleo_prover_present", "module": "cluster", "source": ""},
+		// not ours
+		map[string]string{"job": "node_exporter", "instance": "db1"},
+	)
+
+	n, err := Migrate(srv.URL, srv.Client(), "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/metrics/job/aleo_monitor_runtime_info",
+		"/metrics/job/aleo_prover_latest_height/instance/vm1",
+		"/metrics/job/aleo_prover_speed/addr/aleo1abc/module/cluster",
+		"/metrics/job/aleo_prover_total_speed/module/cluster",
+	}
+	got := deleted()
+	if n != len(want) || strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("deleted %d: %v, want %v", n, got, want)
+	}
+}
+
+func TestMigrateNamespace(t *testing.T) {
+	defer func(ns string) { Namespace = ns }(Namespace)
+	Namespace = "team_a_"
+	srv, deleted := pushgateway(t,
+		map[string]string{"job": "team_a_aleo_prover_speed", "module": "cluster"},
+		map[string]string{"job": "team_a_aleo_prover_speed", "addr": "aleo1abc"},
+		map[string]string{"job": "team_b_aleo_prover_speed", "addr": "aleo1abc"},
+		map[string]string{"job": "aleo_prover_speed", "addr": "aleo1abc"},
+	)
+
+	if _, err := Migrate(srv.URL, srv.Client()); err != nil {
+		t.Fatal(err)
+	}
+	if got := deleted(); len(got) != 1 || got[0] != "/metrics/job/team_a_aleo_prover_speed/addr/aleo1abc" {
+		t.Errorf("deleted %v, want only the legacy group of the namespace", got)
+	}
+}
+
+func TestMigrateListFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			t.Errorf("deleted %s after a failed listing", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	if _, err := Migrate(srv.URL, srv.Client()); err == nil {
+		t.Error("failed listing not reported")
+	}
+}
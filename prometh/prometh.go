@@ -1,111 +1,376 @@
 package prometh
 
 import (
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/push"
 	"log"
+	"runtime"
 	"strconv"
+	"time"
+
+	"aleo-prover-monitor/alert"
 )
 
-func SpeedPush(url string, addr string, duration int, speed string) {
+func SpeedPush(b *Batch, addr string, duration int, speed string) {
 	job := "aleo_prover_speed"
 	speedFloat, err := strconv.ParseFloat(speed, 64)
 	if err != nil {
 		log.Printf("parse speed %s failed:%s", speed, err)
+		RawValuePush(b, job, addr, speed)
 		return
 	}
 
-	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: job})
-	gauge.Set(speedFloat)
-	err = push.New(url, job).Grouping("module", "cluster").Grouping("addr", addr).Grouping("duration", strconv.Itoa(duration)).Collector(gauge).Push()
-	if err != nil {
-		log.Printf("push prometheus %s failed:%s", url, err)
-	}
+	b.GaugeVec(job, cluster, "addr", "duration").WithLabelValues(addr, strconv.Itoa(duration)).Set(speedFloat)
 }
 
-func TotalSpeedPush(url string, duration int, speed string) {
+// SpeedEMAPush pushes the smoothed speed of addr for one alpha.
+func SpeedEMAPush(b *Batch, addr string, alpha float64, speed float64) {
+	job := "aleo_prover_speed_ema"
+
+	b.GaugeVec(job, cluster, "addr", "alpha").WithLabelValues(addr, strconv.FormatFloat(alpha, 'g', -1, 64)).Set(speed)
+}
+
+func TotalSpeedPush(b *Batch, duration int, speed string) {
 	job := "aleo_prover_total_speed"
 	speedFloat, err := strconv.ParseFloat(speed, 64)
 	if err != nil {
 		log.Printf("parse speed %s failed:%s", speed, err)
+		RawValuePush(b, job, "", speed)
 		return
 	}
 
-	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: job})
-	gauge.Set(speedFloat)
-	err = push.New(url, job).Grouping("duration", strconv.Itoa(duration)).Collector(gauge).Push()
-	if err != nil {
-		log.Printf("push prometheus %s failed:%s", url, err)
-	}
+	b.GaugeVec(job, nil, "duration").WithLabelValues(strconv.Itoa(duration)).Set(speedFloat)
 }
 
-func RewardPush(url string, addr string, reward string) {
+func RewardPush(b *Batch, addr string, reward string) {
 	job := "aleo_prover_reward"
 	rewardFloat, err := strconv.ParseFloat(reward, 64)
 	if err != nil {
 		log.Printf("parse reward %s failed:%s", reward, err)
+		RawValuePush(b, job, addr, reward)
 		return
 	}
 
-	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: job})
-	gauge.Set(rewardFloat)
-	err = push.New(url, job).Grouping("module", "cluster").Grouping("addr", addr).Collector(gauge).Push()
-	if err != nil {
-		log.Printf("push prometheus %s failed:%s", url, err)
-	}
+	b.GaugeVec(job, cluster, "addr").WithLabelValues(addr).Set(rewardFloat)
 }
 
-func TotalRewardPush(url string, reward string) {
+func TotalRewardPush(b *Batch, reward string) {
 	job := "aleo_prover_total_reward"
 	rewardFloat, err := strconv.ParseFloat(reward, 64)
 	if err != nil {
 		log.Printf("parse reward %s failed:%s", reward, err)
+		RawValuePush(b, job, "", reward)
 		return
 	}
 
-	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: job})
-	gauge.Set(rewardFloat)
-	err = push.New(url, job).Collector(gauge).Push()
-	if err != nil {
-		log.Printf("push prometheus %s failed:%s", url, err)
-	}
+	b.GaugeVec(job, nil).WithLabelValues().Set(rewardFloat)
 }
 
-func HeightPush(url string, addr string, height int) {
+func HeightPush(b *Batch, addr string, height int) {
 	job := "aleo_prover_latest_height"
 
-	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: job})
-	gauge.Set(float64(height))
-	err := push.New(url, job).Grouping("module", "cluster").Grouping("addr", addr).Collector(gauge).Push()
-	if err != nil {
-		log.Printf("push prometheus %s failed:%s", url, err)
+	b.GaugeVec(job, cluster, "addr").WithLabelValues(addr).Set(float64(height))
+}
+
+// MissingCyclesPush pushes for how many cycles in a row addr was missing from the API.
+func MissingCyclesPush(b *Batch, addr string, cycles int) {
+	job := "aleo_prover_consecutive_missing_cycles"
+
+	b.GaugeVec(job, cluster, "addr").WithLabelValues(addr).Set(float64(cycles))
+}
+
+// PresencePush pushes 1 when the API returned speed data for addr and 0 when
+// it left the address out. Missing addresses get no speed series, so a gap
+// isn't mistaken for a zero speed.
+func PresencePush(b *Batch, addr string, present bool) {
+	job := "aleo_prover_present"
+
+	v := 0.0
+	if present {
+		v = 1
 	}
+	b.GaugeVec(job, cluster, "addr").WithLabelValues(addr).Set(v)
 }
 
-func BlockPush(url string, height int, proof string, reward string) {
-	job := "aleo_prover_latest_block"
+func EpochPush(b *Batch, epoch int) {
+	job := "aleo_chain_epoch"
 
-	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: job})
-	gauge.Set(float64(height))
-	err := push.New(url, job).Grouping("type", "height").Collector(gauge).Push()
-	if err != nil {
-		log.Printf("push prometheus %s failed:%s", url, err)
+	b.GaugeVec(job, nil).WithLabelValues().Set(float64(epoch))
+}
+
+// ProofTargetDeltaPush pushes the proof target change since the previous
+// cycle, both absolute and relative to the previous target.
+func ProofTargetDeltaPush(b *Batch, delta float64, ratio float64) {
+	job := "aleo_chain_proof_target_delta"
+	vec := b.GaugeVec(job, nil, "type")
+
+	vec.WithLabelValues("absolute").Set(delta)
+	vec.WithLabelValues("relative").Set(ratio)
+}
+
+// HeightRegressionsPush pushes how often the chain height went backwards.
+func HeightRegressionsPush(b *Batch, regressions int) {
+	job := "aleo_chain_height_regressions_total"
+
+	b.CounterVec(job, nil).WithLabelValues().Add(float64(regressions))
+}
+
+// HeightLagPush pushes how many blocks a prover's latest solution is behind the chain.
+func HeightLagPush(b *Batch, addr string, lag int) {
+	job := "aleo_prover_height_lag"
+
+	b.GaugeVec(job, cluster, "addr").WithLabelValues(addr).Set(float64(lag))
+}
+
+// TotalHeightLagPush pushes the fleet height lag weighted by prover speed,
+// along with the smallest and largest lag.
+func TotalHeightLagPush(b *Batch, weighted float64, weightedOK bool, min int, max int) {
+	job := "aleo_prover_total_height_lag"
+	vec := b.GaugeVec(job, nil, "type")
+
+	if weightedOK {
+		vec.WithLabelValues("speed_weighted").Set(weighted)
+	}
+	vec.WithLabelValues("min").Set(float64(min))
+	vec.WithLabelValues("max").Set(float64(max))
+}
+
+// heightLagBuckets are the upper bounds of the fleet lag distribution, the
+// last bucket is open ended.
+var heightLagBuckets = []struct {
+	name string
+	max  int
+}{{"0", 0}, {"1-2", 2}, {"3-10", 10}}
+
+// HeightLagBucketsPush pushes how many provers fall in each lag range.
+func HeightLagBucketsPush(b *Batch, lags []int) {
+	job := "aleo_prover_height_lag_bucket"
+	vec := b.GaugeVec(job, nil, "bucket")
+
+	counts := make([]int, len(heightLagBuckets)+1)
+	for _, lag := range lags {
+		i := 0
+		for i < len(heightLagBuckets) && lag > heightLagBuckets[i].max {
+			i++
+		}
+		counts[i]++
+	}
+	for i, bucket := range heightLagBuckets {
+		vec.WithLabelValues(bucket.name).Set(float64(counts[i]))
 	}
+	vec.WithLabelValues(">10").Set(float64(counts[len(heightLagBuckets)]))
+}
+
+func BlockPush(b *Batch, height int, proof string, reward string) {
+	job := "aleo_prover_latest_block"
+	vec := b.GaugeVec(job, nil, "type")
+
+	vec.WithLabelValues("height").Set(float64(height))
 
 	proofFloat, err := strconv.ParseFloat(proof, 64)
 	if err != nil {
 		log.Printf("parse proof %s failed:%s", proof, err)
+		RawValuePush(b, job+"_proof", "", proof)
 		return
 	}
-	gauge.Set(proofFloat)
-	err = push.New(url, job).Grouping("type", "proof").Collector(gauge).Push()
+	vec.WithLabelValues("proof").Set(proofFloat)
 
 	rewardFloat, err := strconv.ParseFloat(reward, 64)
 	if err != nil {
 		log.Printf("parse reward %s failed:%s", reward, err)
+		RawValuePush(b, job+"_reward", "", reward)
 		return
 	}
-	gauge.Set(rewardFloat)
-	err = push.New(url, job).Grouping("type", "reward").Collector(gauge).Push()
+	vec.WithLabelValues("reward").Set(rewardFloat)
+}
+
+// RewardRatePush pushes the credits per hour earned since the previous cycle.
+func RewardRatePush(b *Batch, addr string, perHour float64) {
+	job := "aleo_prover_reward_rate"
+
+	b.GaugeVec(job, cluster, "addr").WithLabelValues(addr).Set(perHour)
+}
+
+func TotalRewardRatePush(b *Batch, perHour float64) {
+	job := "aleo_prover_total_reward_rate"
+
+	b.GaugeVec(job, nil).WithLabelValues().Set(perHour)
+}
+
+// EarningsPush pushes the estimated credits per day of addr.
+func EarningsPush(b *Batch, addr string, perDay float64) {
+	job := "aleo_prover_estimated_daily_earnings"
+
+	b.GaugeVec(job, cluster, "addr").WithLabelValues(addr).Set(perDay)
+}
+
+func TotalEarningsPush(b *Batch, perDay float64) {
+	job := "aleo_prover_total_estimated_daily_earnings"
+
+	b.GaugeVec(job, nil).WithLabelValues().Set(perDay)
+}
+
+func EfficiencyPush(b *Batch, addr string, creditsPerTH float64) {
+	job := "aleo_prover_credits_per_th"
+
+	b.GaugeVec(job, cluster, "addr").WithLabelValues(addr).Set(creditsPerTH)
+}
+
+func TotalEfficiencyPush(b *Batch, creditsPerTH float64) {
+	job := "aleo_prover_total_credits_per_th"
+
+	b.GaugeVec(job, nil).WithLabelValues().Set(creditsPerTH)
+}
+
+func PoolStatsPush(b *Batch, fee string, luck string, efficiency string) {
+	job := "aleo_pool_stats"
+	stats := []struct{ name, value string }{{"fee", fee}, {"luck", luck}, {"efficiency", efficiency}}
+
+	for _, s := range stats {
+		if s.value == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s.value, 64)
+		if err != nil {
+			log.Printf("parse pool %s %s failed:%s", s.name, s.value, err)
+			RawValuePush(b, job+"_"+s.name, "", s.value)
+			continue
+		}
+		b.GaugeVec(job, nil, "type").WithLabelValues(s.name).Set(v)
+	}
+}
+
+func RestartsPush(b *Batch, addr string, restarts int) {
+	job := "aleo_prover_restarts_detected_total"
+
+	b.CounterVec(job, cluster, "addr").WithLabelValues(addr).Add(float64(restarts))
+}
+
+func BlockRewardPush(b *Batch, addr string, epoch string, reward float64, blocks int) {
+	b.CounterVec("aleo_prover_block_reward_total", cluster, "addr", "epoch").WithLabelValues(addr, epoch).Add(reward)
+	b.CounterVec("aleo_prover_rewarded_blocks_total", cluster, "addr", "epoch").WithLabelValues(addr, epoch).Add(float64(blocks))
+}
+
+func ForecastPush(b *Batch, horizon string, forecast float64, expected float64, actual float64) {
+	job := "aleo_prover_total_speed_forecast"
+	vec := b.GaugeVec(job, nil, "type", "horizon")
+
+	vec.WithLabelValues("forecast", horizon).Set(forecast)
+	vec.WithLabelValues("expected", "0s").Set(expected)
+	if expected > 0 {
+		vec.WithLabelValues("deviation_ratio", "0s").Set((actual - expected) / expected)
+	}
+}
+
+func RuntimePush(b *Batch, goroutines int, heapBytes uint64) {
+	job := "aleo_monitor_runtime"
+	vec := b.GaugeVec(job, nil, "type")
+
+	vec.WithLabelValues("goroutines").Set(float64(goroutines))
+	vec.WithLabelValues("heap_bytes").Set(float64(heapBytes))
+}
+
+// PhaseDurationPush pushes how long each phase of the cycle took.
+func PhaseDurationPush(b *Batch, phases map[string]time.Duration) {
+	job := "aleo_monitor_phase_duration_seconds"
+	vec := b.GaugeVec(job, nil, "phase")
+
+	for phase, d := range phases {
+		vec.WithLabelValues(phase).Set(d.Seconds())
+	}
+}
+
+// AgentSpeedPush pushes the speed the agents of addr reported and how far the
+// pool's speed falls short of it.
+func AgentSpeedPush(b *Batch, addr string, reported float64, pool float64) {
+	job := "aleo_prover_agent_speed"
+	vec := b.GaugeVec(job, cluster, "addr", "type")
+
+	vec.WithLabelValues(addr, "reported").Set(reported)
+	vec.WithLabelValues(addr, "discrepancy").Set(reported - pool)
+	if reported > 0 {
+		vec.WithLabelValues(addr, "discrepancy_ratio").Set((reported - pool) / reported)
+	}
+}
+
+// FlappingPush pushes how often addr went up or down within the flap window
+// and whether it counts as flapping.
+func FlappingPush(b *Batch, addr string, changes int, flapping bool) {
+	job := "aleo_prover_flapping"
+	vec := b.GaugeVec(job, cluster, "addr", "type")
+
+	vec.WithLabelValues(addr, "state_changes").Set(float64(changes))
+	state := 0.0
+	if flapping {
+		state = 1
+	}
+	vec.WithLabelValues(addr, "flapping").Set(state)
+}
+
+// RuntimeInfoPush pushes the build and host this instance runs on as labels,
+// grouped by instance like the config info.
+func RuntimeInfoPush(b *Batch, instance string, version string, hostname string, configHash string) {
+	job := "aleo_monitor_runtime_info"
+	vec := b.GaugeVec(job, map[string]string{"instance": instance}, "version", "go_version", "os", "arch", "hostname", "config_hash")
+
+	vec.WithLabelValues(version, runtime.Version(), runtime.GOOS, runtime.GOARCH, hostname, configHash).Set(1)
+}
+
+// AlertActivePush pushes 1 for every firing alert of the built-in engine,
+// resolved alerts drop out of the group.
+func AlertActivePush(b *Batch, active []alert.Event) {
+	job := "aleo_monitor_alert_active"
+	vec := b.GaugeVec(job, nil, "rule", "addr", "severity")
+
+	for _, ev := range active {
+		vec.WithLabelValues(ev.Rule, ev.Addr, ev.Severity).Set(1)
+	}
+}
+
+// AddressChurnPush pushes how many addresses reloads of the address list
+// added and removed since start, and how many the last reload did.
+func AddressChurnPush(b *Batch, added int, removed int, lastAdded int, lastRemoved int) {
+	total := b.CounterVec("aleo_monitor_address_churn_total", nil, "type")
+	total.WithLabelValues("added").Add(float64(added))
+	total.WithLabelValues("removed").Add(float64(removed))
+
+	last := b.GaugeVec("aleo_monitor_address_churn_last_reload", nil, "type")
+	last.WithLabelValues("added").Set(float64(lastAdded))
+	last.WithLabelValues("removed").Set(float64(lastRemoved))
+}
+
+// NotePush pushes the configured note of addr as a label for dashboards.
+func NotePush(b *Batch, addr string, note string) {
+	job := "aleo_prover_note_info"
+
+	b.GaugeVec(job, cluster, "addr", "note").WithLabelValues(addr, note).Set(1)
+}
+
+// SolutionsPush pushes the accepted solutions per address counted from the
+// pool feed and when the last one arrived.
+func SolutionsPush(b *Batch, total map[string]int64, last map[string]time.Time) {
+	counter := b.CounterVec("aleo_prover_solutions_total", cluster, "addr")
+	seen := b.GaugeVec("aleo_prover_last_solution_timestamp_seconds", cluster, "addr")
+
+	for addr, n := range total {
+		counter.WithLabelValues(addr).Add(float64(n))
+		seen.WithLabelValues(addr).Set(float64(last[addr].UnixNano()) / 1e9)
+	}
+}
+
+// DerivedPush pushes the value of the derived metric name for addr.
+func DerivedPush(b *Batch, addr string, name string, value float64) {
+	job := "aleo_prover_derived"
+
+	b.GaugeVec(job, cluster, "addr", "name").WithLabelValues(addr, name).Set(value)
+}
+
+// AddressFilePush pushes the modification time, line count and checksum of
+// the address file and how many addresses were loaded from it, grouped by
+// instance so the lists of all monitor hosts can be compared.
+func AddressFilePush(b *Batch, instance string, path string, checksum string, modified time.Time, lines int, addresses int) {
+	job := "aleo_monitor_address_file"
+	vec := b.GaugeVec(job, map[string]string{"instance": instance}, "path", "sha256", "type")
 
+	vec.WithLabelValues(path, checksum, "modified_timestamp_seconds").Set(float64(modified.Unix()))
+	vec.WithLabelValues(path, checksum, "lines").Set(float64(lines))
+	vec.WithLabelValues(path, checksum, "addresses").Set(float64(addresses))
 }
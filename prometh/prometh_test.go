@@ -0,0 +1,266 @@
+package prometh
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"aleo-prover-monitor/alert"
+)
+
+// pushed flattens every group of f to "job{grouping} name{labels}" keys,
+// labels sorted, so a test states the exact series a push emits.
+func pushed(f *FakeGateway) map[string]float64 {
+	series := make(map[string]float64)
+	for _, g := range f.Groups() {
+		group := g.Job + labelString(g.Grouping)
+		for _, mf := range g.Families {
+			for _, m := range mf.Metric {
+				labels := make(map[string]string, len(m.Label))
+				for _, lp := range m.Label {
+					labels[lp.GetName()] = lp.GetValue()
+				}
+				series[group+" "+mf.GetName()+labelString(labels)] = metricValue(m)
+			}
+		}
+	}
+	return series
+}
+
+func labelString(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(labels))
+	for name, value := range labels {
+		parts = append(parts, name+"="+value)
+	}
+	sort.Strings(parts)
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var modified = time.Unix(1700000000, 0)
+
+// pushTests lists every Push function with the exact series it emits.
+var pushTests = []struct {
+	name string
+	push func(b *Batch)
+	want map[string]float64
+}{
+	{"SpeedPush", func(b *Batch) { SpeedPush(b, "aleo1abc", 15, "12.5") }, map[string]float64{
+		"aleo_prover_speed{module=cluster} aleo_prover_speed{addr=aleo1abc,duration=15}": 12.5,
+	}},
+	{"SpeedPush unparsable", func(b *Batch) { SpeedPush(b, "aleo1abc", 15, "N/A") }, map[string]float64{}},
+	{"SpeedEMAPush", func(b *Batch) { SpeedEMAPush(b, "aleo1abc", 0.3, 10) }, map[string]float64{
+		"aleo_prover_speed_ema{module=cluster} aleo_prover_speed_ema{addr=aleo1abc,alpha=0.3}": 10,
+	}},
+	{"TotalSpeedPush", func(b *Batch) { TotalSpeedPush(b, 60, "300") }, map[string]float64{
+		"aleo_prover_total_speed aleo_prover_total_speed{duration=60}": 300,
+	}},
+	{"RewardPush", func(b *Batch) { RewardPush(b, "aleo1abc", "7") }, map[string]float64{
+		"aleo_prover_reward{module=cluster} aleo_prover_reward{addr=aleo1abc}": 7,
+	}},
+	{"TotalRewardPush", func(b *Batch) { TotalRewardPush(b, "70") }, map[string]float64{
+		"aleo_prover_total_reward aleo_prover_total_reward": 70,
+	}},
+	{"HeightPush", func(b *Batch) { HeightPush(b, "aleo1abc", 1234) }, map[string]float64{
+		"aleo_prover_latest_height{module=cluster} aleo_prover_latest_height{addr=aleo1abc}": 1234,
+	}},
+	{"MissingCyclesPush", func(b *Batch) { MissingCyclesPush(b, "aleo1abc", 3) }, map[string]float64{
+		"aleo_prover_consecutive_missing_cycles{module=cluster} aleo_prover_consecutive_missing_cycles{addr=aleo1abc}": 3,
+	}},
+	{"PresencePush", func(b *Batch) {
+		PresencePush(b, "aleo1abc", true)
+		PresencePush(b, "aleo1def", false)
+	}, map[string]float64{
+		"aleo_prover_present{module=cluster} aleo_prover_present{addr=aleo1abc}": 1,
+		"aleo_prover_present{module=cluster} aleo_prover_present{addr=aleo1def}": 0,
+	}},
+	{"EpochPush", func(b *Batch) { EpochPush(b, 42) }, map[string]float64{
+		"aleo_chain_epoch aleo_chain_epoch": 42,
+	}},
+	{"ProofTargetDeltaPush", func(b *Batch) { ProofTargetDeltaPush(b, 5, 0.25) }, map[string]float64{
+		"aleo_chain_proof_target_delta aleo_chain_proof_target_delta{type=absolute}": 5,
+		"aleo_chain_proof_target_delta aleo_chain_proof_target_delta{type=relative}": 0.25,
+	}},
+	{"HeightRegressionsPush", func(b *Batch) { HeightRegressionsPush(b, 2) }, map[string]float64{
+		"aleo_chain_height_regressions_total aleo_chain_height_regressions_total": 2,
+	}},
+	{"HeightLagPush", func(b *Batch) { HeightLagPush(b, "aleo1abc", 4) }, map[string]float64{
+		"aleo_prover_height_lag{module=cluster} aleo_prover_height_lag{addr=aleo1abc}": 4,
+	}},
+	{"TotalHeightLagPush", func(b *Batch) { TotalHeightLagPush(b, 1.5, true, 0, 9) }, map[string]float64{
+		"aleo_prover_total_height_lag aleo_prover_total_height_lag{type=speed_weighted}": 1.5,
+		"aleo_prover_total_height_lag aleo_prover_total_height_lag{type=min}":            0,
+		"aleo_prover_total_height_lag aleo_prover_total_height_lag{type=max}":            9,
+	}},
+	{"TotalHeightLagPush unweighted", func(b *Batch) { TotalHeightLagPush(b, 0, false, 1, 2) }, map[string]float64{
+		"aleo_prover_total_height_lag aleo_prover_total_height_lag{type=min}": 1,
+		"aleo_prover_total_height_lag aleo_prover_total_height_lag{type=max}": 2,
+	}},
+	{"HeightLagBucketsPush", func(b *Batch) { HeightLagBucketsPush(b, []int{0, 0, 1, 2, 3, 10, 11, 50}) }, map[string]float64{
+		"aleo_prover_height_lag_bucket aleo_prover_height_lag_bucket{bucket=0}":    2,
+		"aleo_prover_height_lag_bucket aleo_prover_height_lag_bucket{bucket=1-2}":  2,
+		"aleo_prover_height_lag_bucket aleo_prover_height_lag_bucket{bucket=3-10}": 2,
+		"aleo_prover_height_lag_bucket aleo_prover_height_lag_bucket{bucket=>10}":  2,
+	}},
+	{"BlockPush", func(b *Batch) { BlockPush(b, 100, "8.5", "23") }, map[string]float64{
+		"aleo_prover_latest_block aleo_prover_latest_block{type=height}": 100,
+		"aleo_prover_latest_block aleo_prover_latest_block{type=proof}":  8.5,
+		"aleo_prover_latest_block aleo_prover_latest_block{type=reward}": 23,
+	}},
+	{"BlockPush unparsable proof", func(b *Batch) { BlockPush(b, 100, "x", "23") }, map[string]float64{
+		"aleo_prover_latest_block aleo_prover_latest_block{type=height}": 100,
+	}},
+	{"RewardRatePush", func(b *Batch) { RewardRatePush(b, "aleo1abc", 1.25) }, map[string]float64{
+		"aleo_prover_reward_rate{module=cluster} aleo_prover_reward_rate{addr=aleo1abc}": 1.25,
+	}},
+	{"TotalRewardRatePush", func(b *Batch) { TotalRewardRatePush(b, 12.5) }, map[string]float64{
+		"aleo_prover_total_reward_rate aleo_prover_total_reward_rate": 12.5,
+	}},
+	{"EarningsPush", func(b *Batch) { EarningsPush(b, "aleo1abc", 30) }, map[string]float64{
+		"aleo_prover_estimated_daily_earnings{module=cluster} aleo_prover_estimated_daily_earnings{addr=aleo1abc}": 30,
+	}},
+	{"TotalEarningsPush", func(b *Batch) { TotalEarningsPush(b, 300) }, map[string]float64{
+		"aleo_prover_total_estimated_daily_earnings aleo_prover_total_estimated_daily_earnings": 300,
+	}},
+	{"EfficiencyPush", func(b *Batch) { EfficiencyPush(b, "aleo1abc", 0.5) }, map[string]float64{
+		"aleo_prover_credits_per_th{module=cluster} aleo_prover_credits_per_th{addr=aleo1abc}": 0.5,
+	}},
+	{"TotalEfficiencyPush", func(b *Batch) { TotalEfficiencyPush(b, 0.75) }, map[string]float64{
+		"aleo_prover_total_credits_per_th aleo_prover_total_credits_per_th": 0.75,
+	}},
+	{"PoolStatsPush", func(b *Batch) { PoolStatsPush(b, "0.01", "", "bad") }, map[string]float64{
+		"aleo_pool_stats aleo_pool_stats{type=fee}": 0.01,
+	}},
+	{"RestartsPush", func(b *Batch) { RestartsPush(b, "aleo1abc", 2) }, map[string]float64{
+		"aleo_prover_restarts_detected_total{module=cluster} aleo_prover_restarts_detected_total{addr=aleo1abc}": 2,
+	}},
+	{"BlockRewardPush", func(b *Batch) { BlockRewardPush(b, "aleo1abc", "7", 1.5, 3) }, map[string]float64{
+		"aleo_prover_block_reward_total{module=cluster} aleo_prover_block_reward_total{addr=aleo1abc,epoch=7}":       1.5,
+		"aleo_prover_rewarded_blocks_total{module=cluster} aleo_prover_rewarded_blocks_total{addr=aleo1abc,epoch=7}": 3,
+	}},
+	{"ForecastPush", func(b *Batch) { ForecastPush(b, "1h0m0s", 110, 100, 90) }, map[string]float64{
+		"aleo_prover_total_speed_forecast aleo_prover_total_speed_forecast{horizon=1h0m0s,type=forecast}":    110,
+		"aleo_prover_total_speed_forecast aleo_prover_total_speed_forecast{horizon=0s,type=expected}":        100,
+		"aleo_prover_total_speed_forecast aleo_prover_total_speed_forecast{horizon=0s,type=deviation_ratio}": -0.1,
+	}},
+	{"RuntimePush", func(b *Batch) { RuntimePush(b, 12, 1024) }, map[string]float64{
+		"aleo_monitor_runtime aleo_monitor_runtime{type=goroutines}": 12,
+		"aleo_monitor_runtime aleo_monitor_runtime{type=heap_bytes}": 1024,
+	}},
+	{"PhaseDurationPush", func(b *Batch) {
+		PhaseDurationPush(b, map[string]time.Duration{"speed": 1500 * time.Millisecond, "reward": time.Second})
+	}, map[string]float64{
+		"aleo_monitor_phase_duration_seconds aleo_monitor_phase_duration_seconds{phase=speed}":  1.5,
+		"aleo_monitor_phase_duration_seconds aleo_monitor_phase_duration_seconds{phase=reward}": 1,
+	}},
+	{"AgentSpeedPush", func(b *Batch) { AgentSpeedPush(b, "aleo1abc", 100, 80) }, map[string]float64{
+		"aleo_prover_agent_speed{module=cluster} aleo_prover_agent_speed{addr=aleo1abc,type=reported}":          100,
+		"aleo_prover_agent_speed{module=cluster} aleo_prover_agent_speed{addr=aleo1abc,type=discrepancy}":       20,
+		"aleo_prover_agent_speed{module=cluster} aleo_prover_agent_speed{addr=aleo1abc,type=discrepancy_ratio}": 0.2,
+	}},
+	{"FlappingPush", func(b *Batch) { FlappingPush(b, "aleo1abc", 5, true) }, map[string]float64{
+		"aleo_prover_flapping{module=cluster} aleo_prover_flapping{addr=aleo1abc,type=state_changes}": 5,
+		"aleo_prover_flapping{module=cluster} aleo_prover_flapping{addr=aleo1abc,type=flapping}":      1,
+	}},
+	{"RuntimeInfoPush", func(b *Batch) { RuntimeInfoPush(b, "vm1", "v1.2.3", "host1", "abc123") }, map[string]float64{
+		fmt.Sprintf("aleo_monitor_runtime_info{instance=vm1} aleo_monitor_runtime_info{arch=%s,config_hash=abc123,go_version=%s,hostname=host1,os=%s,version=v1.2.3}",
+			runtime.GOARCH, runtime.Version(), runtime.GOOS): 1,
+	}},
+	{"AlertActivePush", func(b *Batch) {
+		AlertActivePush(b, []alert.Event{{Rule: "prover_offline", Addr: "aleo1abc", Severity: "critical"}, {Rule: "fleet_speed_collapse", Severity: "warning"}})
+	}, map[string]float64{
+		"aleo_monitor_alert_active aleo_monitor_alert_active{addr=aleo1abc,rule=prover_offline,severity=critical}": 1,
+		"aleo_monitor_alert_active aleo_monitor_alert_active{addr=,rule=fleet_speed_collapse,severity=warning}":    1,
+	}},
+	{"AddressChurnPush", func(b *Batch) { AddressChurnPush(b, 5, 2, 1, 0) }, map[string]float64{
+		"aleo_monitor_address_churn_total aleo_monitor_address_churn_total{type=added}":               5,
+		"aleo_monitor_address_churn_total aleo_monitor_address_churn_total{type=removed}":             2,
+		"aleo_monitor_address_churn_last_reload aleo_monitor_address_churn_last_reload{type=added}":   1,
+		"aleo_monitor_address_churn_last_reload aleo_monitor_address_churn_last_reload{type=removed}": 0,
+	}},
+	{"NotePush", func(b *Batch) { NotePush(b, "aleo1abc", "PSU replaced") }, map[string]float64{
+		"aleo_prover_note_info{module=cluster} aleo_prover_note_info{addr=aleo1abc,note=PSU replaced}": 1,
+	}},
+	{"SolutionsPush", func(b *Batch) {
+		SolutionsPush(b, map[string]int64{"aleo1abc": 4}, map[string]time.Time{"aleo1abc": modified})
+	}, map[string]float64{
+		"aleo_prover_solutions_total{module=cluster} aleo_prover_solutions_total{addr=aleo1abc}":                                 4,
+		"aleo_prover_last_solution_timestamp_seconds{module=cluster} aleo_prover_last_solution_timestamp_seconds{addr=aleo1abc}": 1700000000,
+	}},
+	{"DerivedPush", func(b *Batch) { DerivedPush(b, "aleo1abc", "speed_per_reward", 2) }, map[string]float64{
+		"aleo_prover_derived{module=cluster} aleo_prover_derived{addr=aleo1abc,name=speed_per_reward}": 2,
+	}},
+	{"AddressFilePush", func(b *Batch) { AddressFilePush(b, "vm1", "/etc/a.txt", "ff00", modified, 10, 8) }, map[string]float64{
+		"aleo_monitor_address_file{instance=vm1} aleo_monitor_address_file{path=/etc/a.txt,sha256=ff00,type=modified_timestamp_seconds}": 1700000000,
+		"aleo_monitor_address_file{instance=vm1} aleo_monitor_address_file{path=/etc/a.txt,sha256=ff00,type=lines}":                      10,
+		"aleo_monitor_address_file{instance=vm1} aleo_monitor_address_file{path=/etc/a.txt,sha256=ff00,type=addresses}":                  8,
+	}},
+}
+
+func TestPushFunctions(t *testing.T) {
+	for _, tt := range pushTests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBatch()
+			tt.push(b)
+			fake := NewFakeGateway()
+			if failed := b.Flush(fake); failed != 0 {
+				t.Fatalf("%d pushes failed", failed)
+			}
+			got := pushed(fake)
+			for key, want := range tt.want {
+				if v, ok := got[key]; !ok || v-want > 1e-9 || want-v > 1e-9 {
+					t.Errorf("%s = %v, %v, want %v", key, v, ok, want)
+				}
+			}
+			for key := range got {
+				if _, ok := tt.want[key]; !ok {
+					t.Errorf("unexpected series %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestRawValuePush(t *testing.T) {
+	defer func(enabled bool) { RawValues = enabled }(RawValues)
+
+	RawValues = false
+	b := NewBatch()
+	SpeedPush(b, "aleo1abc", 15, "N/A")
+	if b.ParseErrors != 1 || len(b.Jobs()) != 0 {
+		t.Errorf("disabled: %d parse errors, jobs %v, want 1 and none", b.ParseErrors, b.Jobs())
+	}
+
+	RawValues = true
+	b = NewBatch()
+	SpeedPush(b, "aleo1abc", 15, "N/A")
+	fake := NewFakeGateway()
+	b.Flush(fake)
+	got := pushed(fake)
+	if got["aleo_prover_raw_value_info aleo_prover_raw_value_info{addr=aleo1abc,field=aleo_prover_speed,value=N/A}"] != 1 {
+		t.Errorf("raw value info missing: %v", got)
+	}
+	if wantJobs := []string{"aleo_prover_raw_value_info", "aleo_prover_parse_failures_total"}; !reflect.DeepEqual(b.Jobs(), wantJobs) {
+		t.Errorf("jobs = %v, want %v", b.Jobs(), wantJobs)
+	}
+}
+
+func TestBatchSequence(t *testing.T) {
+	b := NewBatch()
+	b.Sequence = 9
+	HeightPush(b, "aleo1abc", 1)
+	fake := NewFakeGateway()
+	b.Flush(fake)
+	if v, ok := fake.Value("aleo_prover_latest_height", cluster, "aleo_monitor_cycle_sequence"); !ok || v != 9 {
+		t.Errorf("sequence = %v, %v, want 9", v, ok)
+	}
+	if fake.Pushes() != 1 {
+		t.Errorf("%d pushes, want one per job", fake.Pushes())
+	}
+}
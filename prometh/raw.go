@@ -0,0 +1,34 @@
+package prometh
+
+import (
+	"sync"
+)
+
+// RawValues enables pushing values that failed float parsing as info
+// metrics, instead of only logging them.
+var RawValues bool
+
+var rawFailures = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+// RawValuePush records a non-numeric API value of field (the job it was meant
+// for) with the raw string as a label, and counts the failures per field.
+func RawValuePush(b *Batch, field string, addr string, raw string) {
+	b.ParseErrors++
+	if !RawValues {
+		return
+	}
+
+	rawFailures.Lock()
+	rawFailures.counts[field]++
+	count := rawFailures.counts[field]
+	rawFailures.Unlock()
+
+	b.GaugeVec("aleo_prover_raw_value_info", nil, "field", "addr", "value").WithLabelValues(field, addr, raw).Set(1)
+
+	failures := b.CounterVec("aleo_prover_parse_failures_total", nil, "field")
+	failures.DeleteLabelValues(field)
+	failures.WithLabelValues(field).Add(float64(count))
+}
@@ -0,0 +1,62 @@
+package prometh
+
+import (
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Sample is one flattened series value, the form the non-Prometheus sinks
+// consume. Labels include the push grouping.
+type Sample struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+func Samples(grouping map[string]string, families []*dto.MetricFamily) []Sample {
+	var samples []Sample
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.Metric {
+			labels := make(map[string]string, len(grouping)+len(m.Label))
+			for k, v := range grouping {
+				labels[k] = v
+			}
+			for _, lp := range m.Label {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+
+			switch {
+			case m.Histogram != nil:
+				h := m.Histogram
+				samples = append(samples,
+					Sample{Name: name + "_sum", Labels: labels, Value: h.GetSampleSum()},
+					Sample{Name: name + "_count", Labels: labels, Value: float64(h.GetSampleCount())})
+				for _, b := range h.Bucket {
+					samples = append(samples, Sample{Name: name + "_bucket", Labels: withLabel(labels, "le", strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)), Value: float64(b.GetCumulativeCount())})
+				}
+			case m.Summary != nil:
+				s := m.Summary
+				samples = append(samples,
+					Sample{Name: name + "_sum", Labels: labels, Value: s.GetSampleSum()},
+					Sample{Name: name + "_count", Labels: labels, Value: float64(s.GetSampleCount())})
+				for _, q := range s.Quantile {
+					samples = append(samples, Sample{Name: name, Labels: withLabel(labels, "quantile", strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)), Value: q.GetValue()})
+				}
+			default:
+				samples = append(samples, Sample{Name: name, Labels: labels, Value: metricValue(m)})
+			}
+		}
+	}
+	return samples
+}
+
+func withLabel(labels map[string]string, name string, value string) map[string]string {
+	copied := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		copied[k] = v
+	}
+	copied[name] = value
+	return copied
+}
@@ -0,0 +1,206 @@
+package prometh
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+type spoolHeader struct {
+	Job      string            `json:"job"`
+	Grouping map[string]string `json:"grouping,omitempty"`
+}
+
+// Spool keeps the pushes Next failed in Dir, one file each, and replays them
+// in order once Next takes pushes again. While pushes are spooled every new
+// push is spooled behind them, so the gateway sees them in the order they
+// were made. MaxBytes bounds the directory, the oldest pushes are dropped
+// first.
+type Spool struct {
+	Next     Gateway
+	Dir      string
+	MaxBytes int64
+
+	mu  sync.Mutex
+	seq int
+}
+
+func NewSpool(next Gateway, dir string, maxBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &Spool{Next: next, Dir: dir, MaxBytes: maxBytes}
+	if files, _ := s.files(); len(files) > 0 {
+		log.Printf("spool %s holds %d pushes to replay", dir, len(files))
+	}
+	return s, nil
+}
+
+func (s *Spool) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	pending, err := s.replay()
+	if pending == 0 {
+		if err = s.Next.Push(job, grouping, familyCollector(families)); err == nil {
+			return nil
+		}
+	}
+	if serr := s.write(job, grouping, families); serr != nil {
+		return fmt.Errorf("%v, spooling failed too: %v", err, serr)
+	}
+	return fmt.Errorf("spooled for replay: %v", err)
+}
+
+// replay pushes the spooled files oldest first and returns how many are
+// left, with the error that stopped it.
+func (s *Spool) replay() (int, error) {
+	files, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+	for i, name := range files {
+		path := filepath.Join(s.Dir, name)
+		h, families, err := readSpooled(path)
+		if err != nil {
+			log.Printf("drop unreadable spooled push %s:%s", name, err)
+			os.Remove(path)
+			continue
+		}
+		if err := s.Next.Push(h.Job, h.Grouping, familyCollector(families)); err != nil {
+			return len(files) - i, err
+		}
+		os.Remove(path)
+		if i == len(files)-1 {
+			log.Printf("replayed %d spooled pushes", len(files))
+		}
+	}
+	return 0, nil
+}
+
+func (s *Spool) write(job string, grouping map[string]string, families []*dto.MetricFamily) error {
+	s.seq++
+	name := fmt.Sprintf("%020d-%06d.push", time.Now().UnixNano(), s.seq%1000000)
+	tmp := filepath.Join(s.Dir, name+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = json.NewEncoder(w).Encode(spoolHeader{Job: job, Grouping: grouping})
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	for _, mf := range families {
+		if err != nil {
+			break
+		}
+		err = enc.Encode(mf)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(s.Dir, name))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return s.evict()
+}
+
+// evict removes the oldest spooled pushes until the directory fits MaxBytes.
+func (s *Spool) evict() error {
+	if s.MaxBytes <= 0 {
+		return nil
+	}
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, name := range files {
+		if info, err := os.Stat(filepath.Join(s.Dir, name)); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+	dropped := 0
+	for i := 0; total > s.MaxBytes && i < len(files); i++ {
+		if err := os.Remove(filepath.Join(s.Dir, files[i])); err != nil {
+			return err
+		}
+		total -= sizes[i]
+		dropped++
+	}
+	if dropped > 0 {
+		log.Printf("spool %s full, dropped the %d oldest pushes", s.Dir, dropped)
+	}
+	return nil
+}
+
+// files lists the spooled pushes, oldest first.
+func (s *Spool) files() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".push") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func readSpooled(path string) (spoolHeader, []*dto.MetricFamily, error) {
+	var h spoolHeader
+	f, err := os.Open(path)
+	if err != nil {
+		return h, nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return h, nil, err
+	}
+	if err := json.Unmarshal(line, &h); err != nil {
+		return h, nil, err
+	}
+	dec := expfmt.NewDecoder(r, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	var families []*dto.MetricFamily
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err == io.EOF {
+			break
+		} else if err != nil {
+			return h, nil, err
+		}
+		families = append(families, mf)
+	}
+	return h, families, nil
+}
@@ -0,0 +1,181 @@
+package prometh
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// flakyGateway fails every push while down, and once up the ones past
+// allow unless it is negative. It records aleo_prover_latest_height of every
+// push it took.
+type flakyGateway struct {
+	*FakeGateway
+	down   bool
+	allow  int
+	values []float64
+}
+
+func (g *flakyGateway) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	if g.down || g.allow == 0 {
+		return errors.New("gateway down")
+	}
+	g.allow--
+	if err := g.FakeGateway.Push(job, grouping, collectors...); err != nil {
+		return err
+	}
+	v, _ := g.Value(job, grouping, "aleo_prover_latest_height")
+	g.values = append(g.values, v)
+	return nil
+}
+
+func heightPush(s *Spool, height int) error {
+	b := NewBatch()
+	HeightPush(b, "aleo1abc", height)
+	if b.Flush(s) != 0 {
+		return errors.New("push failed")
+	}
+	return nil
+}
+
+func spooled(t *testing.T, s *Spool) int {
+	t.Helper()
+	files, err := s.files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+func TestSpoolReplaysInOrder(t *testing.T) {
+	gw := &flakyGateway{FakeGateway: NewFakeGateway(), down: true, allow: -1}
+	s, err := NewSpool(gw, t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for height := 1; height <= 3; height++ {
+		if err := heightPush(s, height); err == nil {
+			t.Fatalf("push %d succeeded with the gateway down", height)
+		}
+	}
+	if n := spooled(t, s); n != 3 {
+		t.Fatalf("%d pushes spooled, want 3", n)
+	}
+
+	gw.down = false
+	if err := heightPush(s, 4); err != nil {
+		t.Fatalf("push after recovery: %v", err)
+	}
+	if n := spooled(t, s); n != 0 {
+		t.Errorf("%d pushes left spooled after recovery", n)
+	}
+	want := []float64{1, 2, 3, 4}
+	if len(gw.values) != len(want) {
+		t.Fatalf("gateway took %v, want %v", gw.values, want)
+	}
+	for i := range want {
+		if gw.values[i] != want[i] {
+			t.Fatalf("gateway took %v, want %v", gw.values, want)
+		}
+	}
+}
+
+func TestSpoolPartialReplay(t *testing.T) {
+	gw := &flakyGateway{FakeGateway: NewFakeGateway(), down: true, allow: -1}
+	dir := t.TempDir()
+	s, err := NewSpool(gw, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for height := 1; height <= 3; height++ {
+		heightPush(s, height)
+	}
+
+	// the gateway takes one replayed push and fails again
+	gw.down, gw.allow = false, 1
+	if err := heightPush(s, 4); err == nil {
+		t.Fatal("push succeeded while the replay failed")
+	}
+	if n := spooled(t, s); n != 3 {
+		t.Errorf("%d pushes spooled, want the two left and the new one", n)
+	}
+
+	// a restart picks the spool up again
+	s, err = NewSpool(gw, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw.allow = -1
+	if err := heightPush(s, 5); err != nil {
+		t.Fatalf("push after recovery: %v", err)
+	}
+	want := []float64{1, 2, 3, 4, 5}
+	if len(gw.values) != len(want) {
+		t.Fatalf("gateway took %v, want %v", gw.values, want)
+	}
+	for i := range want {
+		if gw.values[i] != want[i] {
+			t.Fatalf("gateway took %v, want %v", gw.values, want)
+		}
+	}
+}
+
+func TestSpoolEvictsOldest(t *testing.T) {
+	gw := &flakyGateway{FakeGateway: NewFakeGateway(), down: true, allow: -1}
+	dir := t.TempDir()
+	s, err := NewSpool(gw, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	heightPush(s, 1)
+	files, _ := s.files()
+	info, err := os.Stat(filepath.Join(dir, files[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// room for two pushes
+	s.MaxBytes = 2*info.Size() + info.Size()/2
+	for height := 2; height <= 4; height++ {
+		heightPush(s, height)
+	}
+	if n := spooled(t, s); n != 2 {
+		t.Fatalf("%d pushes spooled, want 2", n)
+	}
+
+	gw.down = false
+	if err := heightPush(s, 5); err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{3, 4, 5}
+	if len(gw.values) != len(want) {
+		t.Fatalf("gateway took %v, want %v with the oldest dropped", gw.values, want)
+	}
+	for i := range want {
+		if gw.values[i] != want[i] {
+			t.Fatalf("gateway took %v, want %v with the oldest dropped", gw.values, want)
+		}
+	}
+}
+
+func TestSpoolDropsUnreadable(t *testing.T) {
+	gw := &flakyGateway{FakeGateway: NewFakeGateway(), allow: -1}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000001-000001.push"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSpool(gw, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := heightPush(s, 1); err != nil {
+		t.Fatal(err)
+	}
+	if n := spooled(t, s); n != 0 || len(gw.values) != 1 {
+		t.Errorf("%d spooled, gateway took %v, want the unreadable push dropped", n, gw.values)
+	}
+}
@@ -0,0 +1,115 @@
+package prometh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// statsdPacket is the largest datagram sent, small enough not to fragment
+// on common networks.
+const statsdPacket = 1432
+
+// StatsD sends every pushed sample as a StatsD gauge over UDP before handing
+// the push to Next, which may be nil to only send. With Tags the labels go
+// out as DogStatsD tags, otherwise their values are appended to the metric
+// name sorted by label name, e.g. aleo_prover_speed.aleo1xyz.15.cluster.
+type StatsD struct {
+	Conn   net.Conn
+	Prefix string
+	Tags   bool
+	Next   Gateway
+}
+
+func NewStatsD(addr string, prefix string, tags bool, next Gateway) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsD{Conn: conn, Prefix: prefix, Tags: tags, Next: next}, nil
+}
+
+func (s *StatsD) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	var packet bytes.Buffer
+	var sendErr error
+	send := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		if _, err := s.Conn.Write(packet.Bytes()); err != nil && sendErr == nil {
+			sendErr = err
+		}
+		packet.Reset()
+	}
+	for _, sample := range Samples(grouping, families) {
+		line := s.line(sample)
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdPacket {
+			send()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	send()
+	if sendErr != nil {
+		sendErr = fmt.Errorf("send %s to statsd: %v", job, sendErr)
+	}
+
+	// a refused datagram of a stopped daemon must not hold back the other
+	// sinks
+	if s.Next == nil {
+		return sendErr
+	}
+	return errors.Join(sendErr, s.Next.Push(job, grouping, collectors...))
+}
+
+func (s *StatsD) line(sample Sample) string {
+	names := make([]string, 0, len(sample.Labels))
+	for name, value := range sample.Labels {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(s.Prefix)
+	b.WriteString(sample.Name)
+	if !s.Tags {
+		for _, name := range names {
+			b.WriteByte('.')
+			b.WriteString(statsdEscaper.Replace(sample.Labels[name]))
+		}
+	}
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatFloat(sample.Value, 'g', -1, 64))
+	b.WriteString("|g")
+	if s.Tags && len(names) > 0 {
+		b.WriteString("|#")
+		for i, name := range names {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(name)
+			b.WriteByte(':')
+			b.WriteString(statsdEscaper.Replace(sample.Labels[name]))
+		}
+	}
+	return b.String()
+}
+
+// statsdEscaper replaces the characters that delimit StatsD names, values
+// and tags.
+var statsdEscaper = strings.NewReplacer(":", "_", "|", "_", ",", "_", "#", "_", "@", "_", "\n", "_", " ", "_")
@@ -0,0 +1,57 @@
+package prometh
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestStatsDLines(t *testing.T) {
+	tests := []struct {
+		tags bool
+		want string
+	}{
+		{false, "aleo.aleo_prover_speed.aleo1abc.15.cluster:12.5|g"},
+		{true, "aleo.aleo_prover_speed:12.5|g|#addr:aleo1abc,duration:15,module:cluster"},
+	}
+	for _, tt := range tests {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := NewFakeGateway()
+		s, err := NewStatsD(pc.LocalAddr().String(), "aleo.", tt.tags, next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Push("aleo_prover_speed", cluster, speedSample()); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]byte, statsdPacket)
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != tt.want {
+			t.Errorf("tags %v: packet = %q, want %q", tt.tags, got, tt.want)
+		}
+		checkForwarded(t, next)
+		s.Conn.Close()
+		pc.Close()
+	}
+}
+
+func TestStatsDForwardsOnFailure(t *testing.T) {
+	closed, peer := net.Pipe()
+	closed.Close()
+	peer.Close()
+
+	next := NewFakeGateway()
+	s := &StatsD{Conn: closed, Next: next}
+	if err := s.Push("aleo_prover_speed", cluster, speedSample()); err == nil {
+		t.Error("failed send not reported")
+	}
+	checkForwarded(t, next)
+}
@@ -0,0 +1,114 @@
+package prometh
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type Group struct {
+	Job      string
+	Grouping map[string]string
+	Families []*dto.MetricFamily
+}
+
+// groupStore keeps the last push of every job and grouping, the same
+// replace-on-push semantics as a Pushgateway.
+type groupStore struct {
+	mu     sync.Mutex
+	groups map[string]Group
+}
+
+func newGroupStore() groupStore {
+	return groupStore{groups: make(map[string]Group)}
+}
+
+func (s *groupStore) put(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	copied := make(map[string]string, len(grouping))
+	for k, v := range grouping {
+		copied[k] = v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups[groupKey(job, grouping)] = Group{Job: job, Grouping: copied, Families: families}
+	return nil
+}
+
+func (s *groupStore) get(job string, grouping map[string]string) (Group, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	group, ok := s.groups[groupKey(job, grouping)]
+	return group, ok
+}
+
+func (s *groupStore) list() []Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.groups))
+	for k := range s.groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	groups := make([]Group, 0, len(keys))
+	for _, k := range keys {
+		groups = append(groups, s.groups[k])
+	}
+	return groups
+}
+
+func (s *groupStore) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups = make(map[string]Group)
+}
+
+func gather(collectors ...prometheus.Collector) ([]*dto.MetricFamily, error) {
+	reg := prometheus.NewRegistry()
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return reg.Gather()
+}
+
+func groupKey(job string, grouping map[string]string) string {
+	names := make([]string, 0, len(grouping))
+	for k := range grouping {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(job)
+	for _, k := range names {
+		b.WriteString("/")
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(grouping[k])
+	}
+	return b.String()
+}
+
+func metricValue(m *dto.Metric) float64 {
+	switch {
+	case m.Gauge != nil:
+		return m.Gauge.GetValue()
+	case m.Counter != nil:
+		return m.Counter.GetValue()
+	case m.Untyped != nil:
+		return m.Untyped.GetValue()
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package prometh
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// stream PUTs the families encoded straight into the request body, sent with
+// chunked encoding. The families are gathered already, streaming only avoids
+// holding their encoded payload in memory as well.
+func (g *PushGateway) stream(job string, grouping map[string]string, mfs []*dto.MetricFamily) error {
+	format := expfmt.NewFormat(expfmt.TypeProtoDelim)
+	pr, pw := io.Pipe()
+	go func() {
+		enc := expfmt.NewEncoder(pw, format)
+		for _, mf := range mfs {
+			if err := enc.Encode(mf); err != nil {
+				pw.CloseWithError(fmt.Errorf("encode metric family %s: %v", mf.GetName(), err))
+				return
+			}
+		}
+		pw.Close()
+	}()
+	defer pr.Close()
+
+	target := pushURL(g.URL, job, grouping)
+	req, err := http.NewRequestWithContext(g.context(), http.MethodPut, target, pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", string(format))
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d while pushing to %s: %s", resp.StatusCode, target, body)
+	}
+	return nil
+}
+
+func seriesCount(mfs []*dto.MetricFamily) int {
+	n := 0
+	for _, mf := range mfs {
+		n += len(mf.Metric)
+	}
+	return n
+}
+
+// pushURL encodes job and grouping the way the push package does.
+func pushURL(base, job string, grouping map[string]string) string {
+	parts := []string{"job", encodeComponent(job)}
+	if strings.Contains(job, "/") {
+		parts[0] += "@base64"
+	}
+	for name, value := range grouping {
+		if value == "" || strings.Contains(value, "/") {
+			name += "@base64"
+		}
+		parts = append(parts, name, encodeComponent(value))
+	}
+	return fmt.Sprintf("%s/metrics/%s", strings.TrimRight(base, "/"), strings.Join(parts, "/"))
+}
+
+func encodeComponent(s string) string {
+	if s == "" {
+		return "="
+	}
+	if strings.Contains(s, "/") {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	return url.QueryEscape(s)
+}
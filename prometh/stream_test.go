@@ -0,0 +1,82 @@
+package prometh
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestStreamPush(t *testing.T) {
+	var got []*dto.MetricFamily
+	var path, encoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		encoding = strings.Join(r.TransferEncoding, ",")
+		dec := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+		for {
+			mf := &dto.MetricFamily{}
+			if err := dec.Decode(mf); err != nil {
+				break
+			}
+			got = append(got, mf)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	gw := NewPushGateway(srv.URL, srv.Client())
+	gw.StreamSeries = 1
+	b := NewBatch()
+	HeightPush(b, "aleo1abc", 1)
+	HeightPush(b, "aleo1def", 2)
+	if failed := b.Flush(gw); failed != 0 {
+		t.Fatal("push failed")
+	}
+	if path != "/metrics/job/aleo_prover_latest_height/module/cluster" {
+		t.Errorf("path = %s", path)
+	}
+	if encoding != "chunked" {
+		t.Errorf("transfer encoding = %q, want chunked", encoding)
+	}
+	if len(got) != 1 || len(got[0].Metric) != 2 {
+		t.Errorf("received %v, want both series", got)
+	}
+}
+
+func TestStreamPushCancelled(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gw := NewPushGateway(srv.URL, srv.Client())
+	gw.StreamSeries = 1
+	gw.Context = ctx
+	b := NewBatch()
+	HeightPush(b, "aleo1abc", 1)
+	HeightPush(b, "aleo1def", 2)
+
+	done := make(chan int)
+	go func() { done <- b.Flush(gw) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case failed := <-done:
+		if failed != 1 {
+			t.Errorf("%d pushes failed, want the cancelled one", failed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("push not cancelled")
+	}
+}
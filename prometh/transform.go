@@ -0,0 +1,43 @@
+package prometh
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Transform rewrites the values of the named gauges and counters before
+// handing the push to Next, e.g. to convert units or clamp outliers.
+type Transform struct {
+	Next  Gateway
+	Funcs map[string]func(float64) float64
+}
+
+func (t *Transform) Push(job string, grouping map[string]string, collectors ...prometheus.Collector) error {
+	families, err := gather(collectors...)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, mf := range families {
+		fn, ok := t.Funcs[mf.GetName()]
+		if !ok {
+			continue
+		}
+		for _, m := range mf.Metric {
+			switch {
+			case m.Gauge != nil:
+				v := fn(m.Gauge.GetValue())
+				m.Gauge.Value = &v
+			case m.Counter != nil:
+				v := fn(m.Counter.GetValue())
+				m.Counter.Value = &v
+			case m.Untyped != nil:
+				v := fn(m.Untyped.GetValue())
+				m.Untyped.Value = &v
+			}
+		}
+		changed = true
+	}
+	if !changed {
+		return t.Next.Push(job, grouping, collectors...)
+	}
+	return t.Next.Push(job, grouping, familyCollector(families))
+}
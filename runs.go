@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"aleo-prover-monitor/store"
+)
+
+func runRuns(args []string) int {
+	fs := flag.NewFlagSet("runs", flag.ExitOnError)
+	n := fs.Int("n", 20, "number of recent runs to list, 0 lists all")
+	failed := fs.Bool("failed", false, "only list runs that failed")
+	parseConfig(fs, args)
+
+	if cfg.HistoryFile == "" {
+		fmt.Fprintln(os.Stderr, "runs: no history file configured, set -historyFile or history_file")
+		return 2
+	}
+	runs, err := store.ReadRuns(cfg.HistoryFile, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "runs: %v\n", err)
+		return 1
+	}
+	if *failed {
+		var failedRuns []store.Run
+		for _, run := range runs {
+			if run.Error != "" {
+				failedRuns = append(failedRuns, run)
+			}
+		}
+		runs = failedRuns
+	}
+	if *n > 0 && *n < len(runs) {
+		runs = runs[len(runs)-*n:]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "START\tCOLLECTOR\tDURATION\tITEMS\tBYTES\tERROR")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", run.Start.Local().Format(time.RFC3339), run.Collector,
+			run.End.Sub(run.Start).Round(time.Millisecond), run.Items, run.Bytes, run.Error)
+	}
+	w.Flush()
+	return 0
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// version and releaseKey are set at build time, see the Makefile.
+var (
+	version    = "dev"
+	releaseKey = ""
+)
+
+// releaseManifest is served at the release URL, assets are keyed by
+// GOOS/GOARCH and signed with the ed25519 release key over the binary.
+type releaseManifest struct {
+	Version string                  `json:"version"`
+	Assets  map[string]releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature"`
+}
+
+func runSelfUpdate(args []string) int {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	url := fs.String("url", "", "URL of the release manifest")
+	pubKey := fs.String("pubkey", releaseKey, "base64 ed25519 public key the release is signed with")
+	unsigned := fs.Bool("allow-unsigned", false, "accept a release verified by checksum only when no public key is set")
+	check := fs.Bool("check", false, "only report whether an update is available")
+	force := fs.Bool("force", false, "install even when the release has the running version")
+	timeout := fs.Duration("timeout", 5*time.Minute, "timeout of the download")
+	fs.Parse(args)
+
+	if *url == "" {
+		fmt.Fprintln(os.Stderr, "self-update: -url is required")
+		return 2
+	}
+	if *pubKey == "" && !*unsigned {
+		fmt.Fprintln(os.Stderr, "self-update: no release public key, pass -pubkey or -allow-unsigned")
+		return 2
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	var manifest releaseManifest
+	data, err := download(client, *url)
+	if err == nil {
+		err = json.Unmarshal(data, &manifest)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "self-update: read manifest: %v\n", err)
+		return 1
+	}
+
+	if manifest.Version == version && !*force {
+		fmt.Printf("already running %s\n", version)
+		return 0
+	}
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	asset, ok := manifest.Assets[platform]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "self-update: release %s has no %s build\n", manifest.Version, platform)
+		return 1
+	}
+	if *check {
+		fmt.Printf("update available: %s -> %s\n", version, manifest.Version)
+		return 0
+	}
+
+	binary, err := download(client, asset.URL)
+	if err == nil {
+		err = verifyRelease(binary, asset, *pubKey)
+	}
+	if err == nil {
+		err = replaceExecutable(binary)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "self-update: %v\n", err)
+		return 1
+	}
+	fmt.Printf("updated %s -> %s, restart to run it\n", version, manifest.Version)
+	return 0
+}
+
+func download(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func verifyRelease(binary []byte, asset releaseAsset, pubKey string) error {
+	want, err := hex.DecodeString(asset.SHA256)
+	if err != nil || len(want) != sha256.Size {
+		return fmt.Errorf("bad checksum %q in manifest", asset.SHA256)
+	}
+	if sum := sha256.Sum256(binary); !bytes.Equal(sum[:], want) {
+		return fmt.Errorf("checksum mismatch, got %x", sum)
+	}
+	if pubKey == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(pubKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("bad release public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(asset.Signature)
+	if err != nil {
+		return fmt.Errorf("bad signature in manifest: %v", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), binary, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// replaceExecutable writes binary next to the running executable and renames
+// it over, so the file is never seen half written.
+func replaceExecutable(binary []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), "."+filepath.Base(exe)+".new-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), exe)
+}
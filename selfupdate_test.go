@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerifyRelease(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := []byte("release binary")
+	sum := sha256.Sum256(binary)
+	asset := releaseAsset{
+		SHA256:    hex.EncodeToString(sum[:]),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, binary)),
+	}
+	key := base64.StdEncoding.EncodeToString(pub)
+
+	if err := verifyRelease(binary, asset, key); err != nil {
+		t.Errorf("signed release rejected: %v", err)
+	}
+	if err := verifyRelease(binary, releaseAsset{SHA256: asset.SHA256}, ""); err != nil {
+		t.Errorf("release without a key configured rejected: %v", err)
+	}
+
+	tampered := []byte("release binarY")
+	tests := []struct {
+		name   string
+		binary []byte
+		asset  releaseAsset
+		key    string
+	}{
+		{"checksum mismatch", tampered, asset, key},
+		{"checksum mismatch without key", tampered, asset, ""},
+		{"bad checksum", binary, releaseAsset{SHA256: "xyz", Signature: asset.Signature}, key},
+		{"short checksum", binary, releaseAsset{SHA256: asset.SHA256[:32], Signature: asset.Signature}, key},
+		{"missing signature", binary, releaseAsset{SHA256: asset.SHA256}, key},
+		{"bad signature encoding", binary, releaseAsset{SHA256: asset.SHA256, Signature: "!!"}, key},
+		{"signed by another key", binary, asset, base64.StdEncoding.EncodeToString(other)},
+		{"bad public key", binary, asset, base64.StdEncoding.EncodeToString(pub[:16])},
+	}
+	for _, tt := range tests {
+		if err := verifyRelease(tt.binary, tt.asset, tt.key); err == nil {
+			t.Errorf("%s: release accepted", tt.name)
+		}
+	}
+}
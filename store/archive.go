@@ -0,0 +1,209 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Archive writes history rows to files in Dir, one per Rotate window named
+// by its start, e.g. snapshots-20260514T1800Z.csv. CSV files are appended
+// to and readable at any time, Parquet files are written as .tmp and
+// renamed once their window is over. Finished files are handed to Upload,
+// if set, and removed after a successful upload when Remove is set.
+type Archive struct {
+	Dir    string
+	Format string
+	Rotate time.Duration
+	Upload func(ctx context.Context, path string) error
+	Remove bool
+
+	mu      sync.Mutex
+	uploads sync.WaitGroup
+	window  time.Time
+	path    string
+	f       *os.File
+	csv     *csv.Writer
+	buf     *bufio.Writer
+	parquet *parquet.GenericWriter[parquetRow]
+}
+
+type parquetRow struct {
+	Time   time.Time `parquet:"time,timestamp(millisecond)"`
+	Addr   string    `parquet:"addr,dict"`
+	Speed  *float64  `parquet:"speed,optional"`
+	Reward *float64  `parquet:"reward,optional"`
+	Height *int64    `parquet:"height,optional"`
+}
+
+var csvHeader = []string{"time", "addr", "speed", "reward", "height"}
+
+func NewArchive(dir string, format string, rotate time.Duration) (*Archive, error) {
+	if format != "csv" && format != "parquet" {
+		return nil, fmt.Errorf("unknown archive format %q, want csv or parquet", format)
+	}
+	if rotate <= 0 {
+		return nil, fmt.Errorf("archive rotation must be positive")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Archive{Dir: dir, Format: format, Rotate: rotate}, nil
+}
+
+func (a *Archive) Write(rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	window := rows[0].Time.UTC().Truncate(a.Rotate)
+	if a.f == nil || !window.Equal(a.window) {
+		if path := a.finish(); path != "" && a.Upload != nil {
+			a.uploads.Add(1)
+			go func() {
+				defer a.uploads.Done()
+				a.upload(path)
+			}()
+		}
+		if err := a.open(window); err != nil {
+			return err
+		}
+	}
+
+	if a.parquet != nil {
+		prows := make([]parquetRow, len(rows))
+		for i, row := range rows {
+			prows[i] = parquetRow{Time: row.Time, Addr: row.Addr, Speed: row.Speed, Reward: row.Reward}
+			if row.Height != nil {
+				h := int64(*row.Height)
+				prows[i].Height = &h
+			}
+		}
+		if _, err := a.parquet.Write(prows); err != nil {
+			return err
+		}
+		return a.parquet.Flush()
+	}
+
+	for _, row := range rows {
+		a.csv.Write([]string{row.Time.UTC().Format(time.RFC3339Nano), row.Addr, optFloat(row.Speed), optFloat(row.Reward), optInt(row.Height)})
+	}
+	a.csv.Flush()
+	if err := a.csv.Error(); err != nil {
+		return err
+	}
+	return a.buf.Flush()
+}
+
+// Close finishes the current file and waits for its upload and the ones of
+// earlier files still running.
+func (a *Archive) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if path := a.finish(); path != "" && a.Upload != nil {
+		a.upload(path)
+	}
+	a.uploads.Wait()
+}
+
+func (a *Archive) open(window time.Time) error {
+	base := filepath.Join(a.Dir, "snapshots-"+window.Format("20060102T1504Z"))
+	path := base + "." + a.Format
+	if a.Format == "parquet" {
+		// a restart within the window can't append to the finished file
+		for i := 1; fileExists(path) || fileExists(path+".tmp"); i++ {
+			path = fmt.Sprintf("%s-%d.parquet", base, i)
+		}
+	}
+
+	name := path
+	if a.Format == "parquet" {
+		name += ".tmp"
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	a.f, a.path, a.window = f, path, window
+	a.buf = bufio.NewWriter(f)
+	if a.Format == "parquet" {
+		a.parquet = parquet.NewGenericWriter[parquetRow](a.buf)
+		return nil
+	}
+	a.csv = csv.NewWriter(a.buf)
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		a.csv.Write(csvHeader)
+	}
+	return nil
+}
+
+// finish closes the current file and returns its path, empty when there
+// was none or it failed.
+func (a *Archive) finish() string {
+	if a.f == nil {
+		return ""
+	}
+	var err error
+	if a.parquet != nil {
+		err = a.parquet.Close()
+		a.parquet = nil
+	}
+	if ferr := a.buf.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := a.f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && a.Format == "parquet" {
+		err = os.Rename(a.path+".tmp", a.path)
+	}
+	a.f, a.csv, a.buf = nil, nil, nil
+	if err != nil {
+		log.Printf("finish archive %s failed:%s", a.path, err)
+		return ""
+	}
+	return a.path
+}
+
+func (a *Archive) upload(path string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := a.Upload(ctx, path); err != nil {
+		log.Printf("upload archive %s failed:%s", path, err)
+		return
+	}
+	log.Printf("uploaded archive %s", path)
+	if a.Remove {
+		os.Remove(path)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func optFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func optInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func archived(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestArchiveCSV(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewArchive(dir, "csv", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var uploaded []string
+	a.Upload = func(ctx context.Context, path string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		uploaded = append(uploaded, filepath.Base(path))
+		return nil
+	}
+
+	at := time.Date(2026, 10, 14, 12, 5, 0, 0, time.UTC)
+	if err := a.Write([]Row{{Time: at, Addr: "aleo1abc", Speed: float(12.5), Reward: float(3), Height: integer(7)}, {Time: at, Addr: "aleo1def"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Write([]Row{{Time: at.Add(10 * time.Minute), Addr: "aleo1abc", Speed: float(0)}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "snapshots-20261014T1200Z.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "time,addr,speed,reward,height\n" +
+		"2026-10-14T12:05:00Z,aleo1abc,12.5,3,7\n" +
+		"2026-10-14T12:05:00Z,aleo1def,,,\n" +
+		"2026-10-14T12:15:00Z,aleo1abc,0,,\n"
+	if string(data) != want {
+		t.Errorf("archive %q, want %q", data, want)
+	}
+
+	// the next window rotates, a restart appends without a second header
+	a.Write([]Row{{Time: at.Add(time.Hour), Addr: "aleo1abc"}})
+	a.Close()
+	a, _ = NewArchive(dir, "csv", time.Hour)
+	a.Write([]Row{{Time: at.Add(time.Hour + time.Minute), Addr: "aleo1def"}})
+	a.Close()
+
+	if got := archived(t, dir); len(got) != 2 || got[1] != "snapshots-20261014T1300Z.csv" {
+		t.Errorf("files %v", got)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "snapshots-20261014T1300Z.csv"))
+	if want := "time,addr,speed,reward,height\n2026-10-14T13:05:00Z,aleo1abc,,,\n2026-10-14T13:06:00Z,aleo1def,,,\n"; string(data) != want {
+		t.Errorf("rotated archive %q, want %q", data, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(uploaded)
+	if len(uploaded) != 2 || uploaded[0] != "snapshots-20261014T1200Z.csv" || uploaded[1] != "snapshots-20261014T1300Z.csv" {
+		t.Errorf("uploaded %v, want both windows", uploaded)
+	}
+}
+
+func TestArchiveParquet(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewArchive(dir, "parquet", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Remove = true
+	a.Upload = func(ctx context.Context, path string) error { return nil }
+
+	at := time.Date(2026, 10, 14, 12, 5, 0, 0, time.UTC)
+	a.Write([]Row{{Time: at, Addr: "aleo1abc", Speed: float(12.5), Height: integer(7)}})
+	a.Write([]Row{{Time: at.Add(time.Minute), Addr: "aleo1def", Reward: float(3)}})
+	if got := archived(t, dir); len(got) != 1 || got[0] != "snapshots-20261014T1200Z.parquet.tmp" {
+		t.Fatalf("files %v, want the open window as .tmp", got)
+	}
+
+	a.Upload = nil
+	a.Close()
+	rows, err := parquet.ReadFile[parquetRow](filepath.Join(dir, "snapshots-20261014T1200Z.parquet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || !rows[0].Time.Equal(at) || rows[0].Addr != "aleo1abc" || *rows[0].Speed != 12.5 || rows[0].Reward != nil || *rows[0].Height != 7 ||
+		rows[1].Addr != "aleo1def" || rows[1].Speed != nil || *rows[1].Reward != 3 || rows[1].Height != nil {
+		t.Errorf("rows %+v", rows)
+	}
+
+	// a restart within the window writes a second file
+	a, _ = NewArchive(dir, "parquet", time.Hour)
+	a.Write([]Row{{Time: at.Add(2 * time.Minute), Addr: "aleo1abc"}})
+	a.Close()
+	if got := archived(t, dir); len(got) != 2 || got[1] != "snapshots-20261014T1200Z.parquet" || got[0] != "snapshots-20261014T1200Z-1.parquet" {
+		t.Errorf("files %v", got)
+	}
+}
+
+func TestArchiveRemovesUploaded(t *testing.T) {
+	dir := t.TempDir()
+	a, _ := NewArchive(dir, "csv", time.Hour)
+	a.Remove = true
+	fail := true
+	a.Upload = func(ctx context.Context, path string) error {
+		if fail {
+			return os.ErrPermission
+		}
+		return nil
+	}
+	at := time.Date(2026, 10, 14, 12, 5, 0, 0, time.UTC)
+	a.Write([]Row{{Time: at, Addr: "aleo1abc"}})
+	a.Close()
+	if got := archived(t, dir); len(got) != 1 {
+		t.Errorf("files %v, want the failed upload kept", got)
+	}
+	fail = false
+	a.Write([]Row{{Time: at.Add(time.Hour), Addr: "aleo1abc"}})
+	a.Close()
+	if got := archived(t, dir); len(got) != 1 || got[0] != "snapshots-20261014T1200Z.csv" {
+		t.Errorf("files %v, want the uploaded one removed", got)
+	}
+}
+
+func TestNewArchiveRejects(t *testing.T) {
+	if _, err := NewArchive(t.TempDir(), "json", time.Hour); err == nil {
+		t.Error("unknown format accepted")
+	}
+	if _, err := NewArchive(t.TempDir(), "csv", 0); err == nil {
+		t.Error("zero rotation accepted")
+	}
+}
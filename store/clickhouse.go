@@ -0,0 +1,112 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// clickhouseBatch bounds the rows per INSERT, large enough that a fleet of
+// tens of thousands of addresses is one insert per cycle or few.
+const clickhouseBatch = 50000
+
+// ClickHouse inserts history rows into a MergeTree table over the HTTP
+// interface.
+type ClickHouse struct {
+	URL      string
+	Database string
+	Table    string
+	Username string
+	Password string
+	// TTL, if positive, drops rows older than this, rounded to days.
+	TTL    time.Duration
+	Client *http.Client
+}
+
+type clickhouseRow struct {
+	Time   string   `json:"time"`
+	Addr   string   `json:"addr"`
+	Speed  *float64 `json:"speed"`
+	Reward *float64 `json:"reward"`
+	Height *int     `json:"height"`
+}
+
+// Open creates the table if it doesn't exist.
+func (c *ClickHouse) Open(ctx context.Context) error {
+	if !tableName.MatchString(c.Database) || !tableName.MatchString(c.Table) {
+		return fmt.Errorf("invalid table name %q.%q", c.Database, c.Table)
+	}
+	stmt := `CREATE TABLE IF NOT EXISTS ` + c.table() + ` (
+		time DateTime64(3, 'UTC'),
+		addr String,
+		speed Nullable(Float64),
+		reward Nullable(Float64),
+		height Nullable(UInt64)
+	) ENGINE = MergeTree PARTITION BY toYYYYMM(time) ORDER BY (addr, time)`
+	if days := int(c.TTL / (24 * time.Hour)); days > 0 {
+		stmt += fmt.Sprintf(" TTL toDateTime(time) + INTERVAL %d DAY", days)
+	}
+	return c.exec(ctx, stmt, nil)
+}
+
+func (c *ClickHouse) Write(ctx context.Context, rows []Row) error {
+	for len(rows) > 0 {
+		n := min(len(rows), clickhouseBatch)
+		var body bytes.Buffer
+		enc := json.NewEncoder(&body)
+		for _, row := range rows[:n] {
+			if err := enc.Encode(clickhouseRow{
+				Time:   row.Time.UTC().Format("2006-01-02 15:04:05.000"),
+				Addr:   row.Addr,
+				Speed:  row.Speed,
+				Reward: row.Reward,
+				Height: row.Height,
+			}); err != nil {
+				return err
+			}
+		}
+		if err := c.exec(ctx, "INSERT INTO "+c.table()+" FORMAT JSONEachRow", &body); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+func (c *ClickHouse) table() string {
+	return "`" + c.Database + "`.`" + c.Table + "`"
+}
+
+// exec runs query, with body as the data of an INSERT.
+func (c *ClickHouse) exec(ctx context.Context, query string, body io.Reader) error {
+	target := strings.TrimRight(c.URL, "/") + "/?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, "POST", target, body)
+	if err != nil {
+		return err
+	}
+	if c.Username != "" {
+		req.Header.Set("X-ClickHouse-User", c.Username)
+		req.Header.Set("X-ClickHouse-Key", c.Password)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type clickhouseRequest struct {
+	query      string
+	user, key  string
+	body       string
+	rowsInBody int
+}
+
+func clickhouseServer(t *testing.T, status int) (*httptest.Server, func() []clickhouseRequest) {
+	var mu sync.Mutex
+	var requests []clickhouseRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, clickhouseRequest{
+			query:      r.URL.Query().Get("query"),
+			user:       r.Header.Get("X-ClickHouse-User"),
+			key:        r.Header.Get("X-ClickHouse-Key"),
+			body:       string(body),
+			rowsInBody: strings.Count(string(body), "\n"),
+		})
+		mu.Unlock()
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			io.WriteString(w, "Code: 60. DB::Exception: Table history.samples does not exist.")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []clickhouseRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]clickhouseRequest{}, requests...)
+	}
+}
+
+func TestClickHouseOpen(t *testing.T) {
+	srv, requests := clickhouseServer(t, http.StatusOK)
+	c := &ClickHouse{URL: srv.URL + "/", Database: "history", Table: "samples", Username: "monitor", Password: "pw", TTL: 30 * 24 * time.Hour}
+	if err := c.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	r := requests()[0]
+	if !strings.HasPrefix(r.query, "CREATE TABLE IF NOT EXISTS `history`.`samples` (") ||
+		!strings.Contains(r.query, "ENGINE = MergeTree PARTITION BY toYYYYMM(time) ORDER BY (addr, time) TTL toDateTime(time) + INTERVAL 30 DAY") {
+		t.Errorf("query %s", r.query)
+	}
+	if r.user != "monitor" || r.key != "pw" {
+		t.Errorf("credentials %q %q", r.user, r.key)
+	}
+
+	for _, bad := range []ClickHouse{{Database: "history", Table: "samples; DROP"}, {Database: "a.b", Table: "samples"}} {
+		if err := bad.Open(context.Background()); err == nil {
+			t.Errorf("table %s.%s accepted", bad.Database, bad.Table)
+		}
+	}
+}
+
+func TestClickHouseWrite(t *testing.T) {
+	srv, requests := clickhouseServer(t, http.StatusOK)
+	c := &ClickHouse{URL: srv.URL, Database: "history", Table: "samples"}
+	at := time.Date(2026, 10, 14, 12, 0, 0, 123e6, time.FixedZone("CST", 8*3600))
+	rows := []Row{
+		{Time: at, Addr: "aleo1abc", Speed: float(12.5), Reward: float(3), Height: integer(7)},
+		{Time: at, Addr: "aleo1def"},
+	}
+	if err := c.Write(context.Background(), rows); err != nil {
+		t.Fatal(err)
+	}
+
+	r := requests()[0]
+	if r.query != "INSERT INTO `history`.`samples` FORMAT JSONEachRow" {
+		t.Errorf("query %s", r.query)
+	}
+	if r.user != "" {
+		t.Errorf("credentials sent without a user: %q", r.user)
+	}
+	want := `{"time":"2026-10-14 04:00:00.123","addr":"aleo1abc","speed":12.5,"reward":3,"height":7}` + "\n" +
+		`{"time":"2026-10-14 04:00:00.123","addr":"aleo1def","speed":null,"reward":null,"height":null}` + "\n"
+	if r.body != want {
+		t.Errorf("body %s, want %s", r.body, want)
+	}
+}
+
+func TestClickHouseWriteBatches(t *testing.T) {
+	srv, requests := clickhouseServer(t, http.StatusOK)
+	c := &ClickHouse{URL: srv.URL, Database: "history", Table: "samples"}
+	rows := make([]Row, clickhouseBatch+1)
+	for i := range rows {
+		rows[i] = Row{Time: time.Unix(0, 0), Addr: "aleo1abc"}
+	}
+	if err := c.Write(context.Background(), rows); err != nil {
+		t.Fatal(err)
+	}
+	got := requests()
+	if len(got) != 2 || got[0].rowsInBody != clickhouseBatch || got[1].rowsInBody != 1 {
+		t.Errorf("%d inserts, want a full batch and the rest", len(got))
+	}
+}
+
+func TestClickHouseError(t *testing.T) {
+	srv, _ := clickhouseServer(t, http.StatusNotFound)
+	c := &ClickHouse{URL: srv.URL, Database: "history", Table: "samples"}
+	err := c.Write(context.Background(), []Row{{Time: time.Now(), Addr: "aleo1abc"}})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("error = %v, want the server's message", err)
+	}
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Row is one cycle's values of an address in a SQL history table, nil where
+// the API left the address out.
+type Row struct {
+	Time   time.Time
+	Addr   string
+	Speed  *float64
+	Reward *float64
+	Height *int
+}
+
+// postgresBatch bounds the rows per INSERT, 5 parameters each stay far
+// below the 65535 parameter limit.
+const postgresBatch = 1000
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Postgres writes history rows to a PostgreSQL table, created on open if it
+// doesn't exist and turned into a TimescaleDB hypertable on request.
+type Postgres struct {
+	db    *sql.DB
+	table string
+}
+
+func OpenPostgres(ctx context.Context, dsn string, table string, timescale bool) (*Postgres, error) {
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	p := &Postgres{db: db, table: pq.QuoteIdentifier(table)}
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS ` + p.table + ` (
+			time TIMESTAMPTZ NOT NULL,
+			addr TEXT NOT NULL,
+			speed DOUBLE PRECISION,
+			reward DOUBLE PRECISION,
+			height BIGINT
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + pq.QuoteIdentifier(table+"_addr_time") + ` ON ` + p.table + ` (addr, time DESC)`,
+	}
+	if timescale {
+		stmts = append(stmts, `SELECT create_hypertable(`+pq.QuoteLiteral(table)+`, 'time', if_not_exists => TRUE)`)
+	}
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *Postgres) Write(ctx context.Context, rows []Row) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for len(rows) > 0 {
+		n := min(len(rows), postgresBatch)
+		var values []string
+		args := make([]any, 0, 5*n)
+		for i, row := range rows[:n] {
+			values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", 5*i+1, 5*i+2, 5*i+3, 5*i+4, 5*i+5))
+			args = append(args, row.Time, row.Addr, row.Speed, row.Reward, row.Height)
+		}
+		stmt := `INSERT INTO ` + p.table + ` (time, addr, speed, reward, height) VALUES ` + strings.Join(values, ", ")
+		if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOpenPostgresRejectsTableNames(t *testing.T) {
+	for _, table := range []string{"", "1samples", "samples; DROP TABLE users", "history.samples", `sam"ples`} {
+		if _, err := OpenPostgres(context.Background(), "postgres://localhost:1/none", table, false); err == nil {
+			t.Errorf("table %q accepted", table)
+		}
+	}
+}
+
+// TestPostgresWrite needs a database, e.g.
+// ALEO_MONITOR_TEST_POSTGRES=postgres://postgres@localhost/postgres?sslmode=disable
+func TestPostgresWrite(t *testing.T) {
+	dsn := os.Getenv("ALEO_MONITOR_TEST_POSTGRES")
+	if dsn == "" {
+		t.Skip("ALEO_MONITOR_TEST_POSTGRES not set")
+	}
+	ctx := context.Background()
+	table := fmt.Sprintf("history_test_%d", time.Now().UnixNano())
+	p, err := OpenPostgres(ctx, dsn, table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		p.db.Exec(`DROP TABLE ` + p.table)
+		p.Close()
+	}()
+
+	at := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	rows := make([]Row, postgresBatch+1)
+	for i := range rows {
+		rows[i] = Row{Time: at, Addr: fmt.Sprintf("aleo%d", i)}
+	}
+	rows[0].Speed, rows[0].Reward, rows[0].Height = float(12.5), float(3), integer(7)
+	if err := p.Write(ctx, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	var n, nulls int
+	if err := p.db.QueryRow(`SELECT COUNT(*), COUNT(*) - COUNT(speed) FROM `+p.table).Scan(&n, &nulls); err != nil {
+		t.Fatal(err)
+	}
+	if n != len(rows) || nulls != len(rows)-1 {
+		t.Errorf("%d rows, %d without speed, want %d and %d", n, nulls, len(rows), len(rows)-1)
+	}
+	var speed, reward float64
+	var height int
+	var got time.Time
+	if err := p.db.QueryRow(`SELECT time, speed, reward, height FROM `+p.table+` WHERE addr = 'aleo0'`).Scan(&got, &speed, &reward, &height); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(at) || speed != 12.5 || reward != 3 || height != 7 {
+		t.Errorf("aleo0 = %s %g %g %d", got, speed, reward, height)
+	}
+}
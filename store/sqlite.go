@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Cycle is the outcome of one collection cycle kept with its rows.
+type Cycle struct {
+	Started   time.Time
+	Finished  time.Time
+	Addresses int
+	// Errors are the failed queries as "collector: error".
+	Errors []string
+}
+
+// AddrStats summarizes the rows of an address over a time range.
+type AddrStats struct {
+	Addr      string
+	Samples   int
+	AvgSpeed  float64
+	MinSpeed  float64
+	MaxSpeed  float64
+	ZeroSpeed int
+	Reward    float64
+	Height    int
+	LastSeen  time.Time
+}
+
+// SQLite records every cycle and its rows in a local SQLite database,
+// pruning what is older than the retention on every write.
+type SQLite struct {
+	db        *sql.DB
+	retention time.Duration
+}
+
+func OpenSQLite(path string, retention time.Duration) (*SQLite, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS cycles (
+			id INTEGER PRIMARY KEY,
+			started INTEGER NOT NULL,
+			finished INTEGER NOT NULL,
+			addresses INTEGER NOT NULL,
+			errors TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS cycles_started ON cycles (started)`,
+		`CREATE TABLE IF NOT EXISTS samples (
+			cycle INTEGER NOT NULL REFERENCES cycles (id) ON DELETE CASCADE,
+			time INTEGER NOT NULL,
+			addr TEXT NOT NULL,
+			speed REAL,
+			reward REAL,
+			height INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS samples_addr_time ON samples (addr, time)`,
+		`CREATE INDEX IF NOT EXISTS samples_cycle ON samples (cycle)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &SQLite{db: db, retention: retention}, nil
+}
+
+// Write stores the cycle with its rows in one transaction.
+func (s *SQLite) Write(ctx context.Context, c Cycle, rows []Row) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO cycles (started, finished, addresses, errors) VALUES (?, ?, ?, ?)`,
+		c.Started.UnixMilli(), c.Finished.UnixMilli(), c.Addresses, strings.Join(c.Errors, "; "))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	insert, err := tx.PrepareContext(ctx, `INSERT INTO samples (cycle, time, addr, speed, reward, height) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, row := range rows {
+		if _, err := insert.ExecContext(ctx, id, row.Time.UnixMilli(), row.Addr, row.Speed, row.Reward, row.Height); err != nil {
+			return err
+		}
+	}
+
+	if s.retention > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM cycles WHERE started < ?`, c.Started.Add(-s.retention).UnixMilli()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Stats summarizes the rows since the given time per address, addr limits
+// it to one address when set. Reward is the increase of the cumulative
+// reward over the range.
+func (s *SQLite) Stats(ctx context.Context, since time.Time, addr string) ([]AddrStats, error) {
+	query := `SELECT addr, COUNT(*), IFNULL(AVG(speed), 0), IFNULL(MIN(speed), 0), IFNULL(MAX(speed), 0),
+			IFNULL(SUM(speed = 0), 0), IFNULL(MAX(reward) - MIN(reward), 0), IFNULL(MAX(height), 0), MAX(time)
+		FROM samples WHERE time >= ?`
+	args := []any{since.UnixMilli()}
+	if addr != "" {
+		query += ` AND addr = ?`
+		args = append(args, addr)
+	}
+	query += ` GROUP BY addr ORDER BY addr`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []AddrStats
+	for rows.Next() {
+		var st AddrStats
+		var last int64
+		if err := rows.Scan(&st.Addr, &st.Samples, &st.AvgSpeed, &st.MinSpeed, &st.MaxSpeed,
+			&st.ZeroSpeed, &st.Reward, &st.Height, &last); err != nil {
+			return nil, err
+		}
+		st.LastSeen = time.UnixMilli(last)
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// Cycles counts the cycles since the given time and those with failed
+// queries.
+func (s *SQLite) Cycles(ctx context.Context, since time.Time) (total int, failed int, err error) {
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*), IFNULL(SUM(errors != ''), 0) FROM cycles WHERE started >= ?`,
+		since.UnixMilli()).Scan(&total, &failed)
+	return total, failed, err
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func float(v float64) *float64 { return &v }
+
+func integer(v int) *int { return &v }
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cycles.db")
+	s, err := OpenSQLite(path, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	cycle := func(at time.Time, errors []string, rows ...Row) {
+		t.Helper()
+		if err := s.Write(ctx, Cycle{Started: at, Finished: at.Add(time.Second), Addresses: 2, Errors: errors}, rows); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cycle(start, nil,
+		Row{Time: start, Addr: "aleo1abc", Speed: float(10), Reward: float(100), Height: integer(5)},
+		Row{Time: start, Addr: "aleo1def", Speed: float(0), Reward: float(7)})
+	cycle(start.Add(5*time.Minute), []string{"reward: timeout"},
+		Row{Time: start.Add(5 * time.Minute), Addr: "aleo1abc", Speed: float(20), Height: integer(6)},
+		Row{Time: start.Add(5 * time.Minute), Addr: "aleo1def"})
+	cycle(start.Add(10*time.Minute), nil,
+		Row{Time: start.Add(10 * time.Minute), Addr: "aleo1abc", Speed: float(30), Reward: float(103), Height: integer(7)})
+
+	stats, err := s.Stats(ctx, start, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AddrStats{
+		{Addr: "aleo1abc", Samples: 3, AvgSpeed: 20, MinSpeed: 10, MaxSpeed: 30, Reward: 3, Height: 7, LastSeen: start.Add(10 * time.Minute)},
+		{Addr: "aleo1def", Samples: 2, ZeroSpeed: 1, LastSeen: start.Add(5 * time.Minute)},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("stats %+v, want %+v", stats, want)
+	}
+	for i := range want {
+		if got := stats[i]; got.Addr != want[i].Addr || got.Samples != want[i].Samples || got.AvgSpeed != want[i].AvgSpeed ||
+			got.MinSpeed != want[i].MinSpeed || got.MaxSpeed != want[i].MaxSpeed || got.ZeroSpeed != want[i].ZeroSpeed ||
+			got.Reward != want[i].Reward || got.Height != want[i].Height || !got.LastSeen.Equal(want[i].LastSeen) {
+			t.Errorf("stats %+v, want %+v", got, want[i])
+		}
+	}
+
+	stats, err = s.Stats(ctx, start.Add(time.Minute), "aleo1abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Samples != 2 || stats[0].Reward != 0 || stats[0].MinSpeed != 20 {
+		t.Errorf("aleo1abc since the second cycle = %+v", stats)
+	}
+
+	total, failed, err := s.Cycles(ctx, start)
+	if err != nil || total != 3 || failed != 1 {
+		t.Errorf("cycles = %d, %d, %v, want 3 and 1 failed", total, failed, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopened, a cycle a day later prunes all but the last one and its rows
+	s, err = OpenSQLite(path, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	later := start.Add(24*time.Hour + 7*time.Minute)
+	cycle(later, nil, Row{Time: later, Addr: "aleo1abc", Speed: float(40)})
+
+	total, _, err = s.Cycles(ctx, time.Time{})
+	if err != nil || total != 2 {
+		t.Errorf("cycles after pruning = %d, %v, want the last two", total, err)
+	}
+	stats, err = s.Stats(ctx, time.Time{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Addr != "aleo1abc" || stats[0].Samples != 2 {
+		t.Errorf("stats after pruning %+v, want the rows of the kept cycles", stats)
+	}
+}
@@ -0,0 +1,281 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Point is one cycle's values of an address.
+type Point struct {
+	Time   time.Time `json:"time"`
+	Speed  float64   `json:"speed"`
+	Reward float64   `json:"reward"`
+}
+
+// Run is one collector execution kept for auditing.
+type Run struct {
+	Collector string    `json:"collector"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Items     int       `json:"items"`
+	Bytes     int64     `json:"bytes"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// record is one line of the file, either a point of Addr or a Run.
+type record struct {
+	Addr string `json:"addr,omitempty"`
+	*Point
+	Run *Run `json:"run,omitempty"`
+}
+
+// Store keeps the points of every address for Retention, optionally appended
+// to a JSON lines file so they survive restarts.
+type Store struct {
+	mu        sync.Mutex
+	retention time.Duration
+	series    map[string][]Point
+	runs      []Run
+	path      string
+	f         *os.File
+	written   int
+}
+
+// Open loads path, dropping points older than retention, and keeps appending
+// to it. An empty path keeps the points in memory only.
+func Open(path string, retention time.Duration) (*Store, error) {
+	s := &Store{retention: retention, series: make(map[string][]Point), path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				continue
+			}
+			if rec.Run != nil {
+				s.runs = append(s.runs, *rec.Run)
+			} else if rec.Point != nil {
+				s.series[rec.Addr] = append(s.series[rec.Addr], *rec.Point)
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.expire(time.Now())
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Add(addr string, p Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.series[addr] = append(s.series[addr], p)
+	return s.append(record{Addr: addr, Point: &p})
+}
+
+func (s *Store) AddRun(run Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runs = append(s.runs, run)
+	return s.append(record{Run: &run})
+}
+
+// Runs returns the last n runs, oldest first, n <= 0 returns all.
+func (s *Store) Runs(n int) []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return lastRuns(s.runs, n)
+}
+
+func lastRuns(runs []Run, n int) []Run {
+	if n > 0 && n < len(runs) {
+		runs = runs[len(runs)-n:]
+	}
+	return append([]Run{}, runs...)
+}
+
+// ReadRuns reads the last n runs of the file at path without opening it as a
+// store, so it is safe while a monitor keeps appending to it.
+func ReadRuns(path string, n int) ([]Run, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err == nil && rec.Run != nil {
+			runs = append(runs, *rec.Run)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lastRuns(runs, n), nil
+}
+
+func (s *Store) append(rec record) error {
+	if s.f == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.written++
+	if s.written > 2*s.size() {
+		return s.compact()
+	}
+	return nil
+}
+
+// Query returns the points of addr within window before now, averaged into
+// at most points buckets.
+func (s *Store) Query(addr string, window time.Duration, points int, now time.Time) []Point {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := now.Add(-window)
+	series := s.series[addr]
+	start := sort.Search(len(series), func(i int) bool { return !series[i].Time.Before(from) })
+	series = series[start:]
+	if points <= 0 || len(series) <= points {
+		return append([]Point{}, series...)
+	}
+
+	width := window / time.Duration(points)
+	downsampled := []Point{}
+	var sum Point
+	n := 0
+	bucket := -1
+	for _, p := range series {
+		b := int(p.Time.Sub(from) / width)
+		if b != bucket && n > 0 {
+			downsampled = append(downsampled, average(sum, n))
+			sum, n = Point{}, 0
+		}
+		bucket = b
+		sum.Time = p.Time
+		sum.Speed += p.Speed
+		sum.Reward += p.Reward
+		n++
+	}
+	if n > 0 {
+		downsampled = append(downsampled, average(sum, n))
+	}
+	return downsampled
+}
+
+func average(sum Point, n int) Point {
+	return Point{Time: sum.Time, Speed: sum.Speed / float64(n), Reward: sum.Reward / float64(n)}
+}
+
+// Expire drops the points and runs older than the retention before now,
+// once per cycle after its points were added.
+func (s *Store) Expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(now)
+}
+
+func (s *Store) expire(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+	from := now.Add(-s.retention)
+	// runs are added as they end, so they aren't ordered by start
+	runs := s.runs[:0]
+	for _, run := range s.runs {
+		if !run.Start.Before(from) {
+			runs = append(runs, run)
+		}
+	}
+	s.runs = runs
+	for addr, series := range s.series {
+		start := sort.Search(len(series), func(i int) bool { return !series[i].Time.Before(from) })
+		if start == len(series) {
+			delete(s.series, addr)
+		} else if start > 0 {
+			s.series[addr] = series[start:]
+		}
+	}
+}
+
+func (s *Store) size() int {
+	n := len(s.runs)
+	for _, series := range s.series {
+		n += len(series)
+	}
+	return n
+}
+
+// compact rewrites the file with the retained points and reopens it for appending.
+func (s *Store) compact() error {
+	if s.f != nil {
+		s.f.Close()
+		s.f = nil
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for addr, series := range s.series {
+		for i := range series {
+			if err := enc.Encode(record{Addr: addr, Point: &series[i]}); err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	for i := range s.runs {
+		if err := enc.Encode(record{Run: &s.runs[i]}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.f, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644)
+	s.written = s.size()
+	return err
+}
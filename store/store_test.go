@@ -0,0 +1,48 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpire(t *testing.T) {
+	// Open expires against the clock
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	s, err := Open(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Add("aleo1", Point{Time: now.Add(-2 * time.Hour), Speed: 1})
+	s.Add("aleo1", Point{Time: now.Add(-time.Minute), Speed: 2})
+	s.Add("aleo2", Point{Time: now.Add(-90 * time.Minute), Speed: 3})
+	// runs are added as they end, a long one started before a short one
+	s.AddRun(Run{Collector: "speed/15", Start: now.Add(-time.Minute)})
+	s.AddRun(Run{Collector: "reward", Start: now.Add(-2 * time.Hour)})
+	s.AddRun(Run{Collector: "height", Start: now.Add(-30 * time.Second)})
+	if got := s.Query("aleo1", 3*time.Hour, 0, now); len(got) != 2 {
+		t.Fatalf("points = %v, want both before Expire", got)
+	}
+
+	s.Expire(now)
+	if got := s.Query("aleo1", 3*time.Hour, 0, now); len(got) != 1 || got[0].Speed != 2 {
+		t.Errorf("aleo1 = %v, want the recent point", got)
+	}
+	if got := s.Query("aleo2", 3*time.Hour, 0, now); len(got) != 0 {
+		t.Errorf("aleo2 = %v, want it expired", got)
+	}
+	runs := s.Runs(0)
+	if len(runs) != 2 || runs[0].Collector != "speed/15" || runs[1].Collector != "height" {
+		t.Errorf("runs = %+v, want speed/15 and height", runs)
+	}
+
+	s, err = Open(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Query("aleo1", 3*time.Hour, 0, now); len(got) != 1 {
+		t.Errorf("reopened aleo1 = %v, want the recent point", got)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"aleo-prover-monitor/alert"
+)
+
+// runDailySummary sends a summary of the last 24h every day at the local
+// time of day at, given as HH:MM.
+func runDailySummary(ctx context.Context, at string, send func(context.Context, alert.Summary) error, m *monitor, history *alert.History) {
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		log.Fatalf("Wrong daily summary time %q, want HH:MM", at)
+	}
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		if !sleep(ctx, next.Sub(now)) {
+			return
+		}
+
+		s := m.summary(history, 24*time.Hour, time.Now())
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		if err := send(sendCtx, s); err != nil {
+			log.Printf("send daily summary failed:%s", err)
+		}
+		cancel()
+	}
+}
+
+// summary builds the alert.Summary of the window before now from the
+// history store and the alert engine.
+func (m *monitor) summary(history *alert.History, window time.Duration, now time.Time) alert.Summary {
+	addresses := m.addressList()
+	s := alert.Summary{From: now.Add(-window), To: now, Addresses: len(addresses), Active: m.alerts.Active()}
+	s.Count(history.Events())
+	interval := time.Duration(cfg.Interval) * time.Minute
+	for _, addr := range addresses {
+		points := m.points.Query(addr, window, 0, now)
+		if len(points) == 0 {
+			s.PerAddress = append(s.PerAddress, alert.AddressSummary{Addr: addr, Downtime: window.Minutes()})
+			continue
+		}
+		a := alert.AddressSummary{Addr: addr, Reward: points[len(points)-1].Reward - points[0].Reward}
+		var speed float64
+		var down time.Duration
+		for i, p := range points {
+			speed += p.Speed
+			// A sample at zero speed counts as down until the next one, a
+			// gap of more than two intervals as down beyond the first.
+			gap := now.Sub(p.Time)
+			if i+1 < len(points) {
+				gap = points[i+1].Time.Sub(p.Time)
+			}
+			if p.Speed == 0 {
+				down += gap
+			} else if gap > 2*interval {
+				down += gap - interval
+			}
+		}
+		a.Speed = speed / float64(len(points))
+		a.Downtime = down.Minutes()
+		s.Speed += a.Speed
+		s.Reward += a.Reward
+		s.PerAddress = append(s.PerAddress, a)
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchAddresses reloads the address files after one changed and they
+// settled for debounce, the directories are watched since editors often
+// replace a file by rename.
+func watchAddresses(ctx context.Context, m *monitor, debounce time.Duration) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	paths := make(map[string]bool, len(cfg.AddrFiles))
+	dirs := make(map[string]bool, len(cfg.AddrFiles))
+	for _, f := range cfg.AddrFiles {
+		path, err := filepath.Abs(f.Path)
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+		paths[path] = true
+		if dirs[filepath.Dir(path)] {
+			continue
+		}
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+			return err
+		}
+		dirs[filepath.Dir(path)] = true
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !paths[filepath.Clean(ev.Name)] || ev.Op == fsnotify.Chmod {
+					continue
+				}
+				timer.Reset(debounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("watch %s failed:%s", cfg.AddrFiles.String(), err)
+			case <-timer.C:
+				reloadAddresses(m)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+
+	"aleo-prover-monitor/prometh"
+)
+
+// watchdog samples the process' own goroutine count and heap, pushes them and
+// asks for a restart of the collection subsystem once a limit is exceeded.
+type watchdog struct {
+	interval      time.Duration
+	maxGoroutines int
+	maxHeapBytes  uint64
+	gw            prometh.Gateway
+	restart       func(reason string)
+	// cooldown keeps a persisting leak from restarting every interval.
+	cooldown    time.Duration
+	lastRestart time.Time
+}
+
+func (w *watchdog) run(ctx context.Context) {
+	for sleep(ctx, w.interval) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		goroutines := runtime.NumGoroutine()
+
+		b := prometh.NewBatch()
+		prometh.RuntimePush(b, goroutines, mem.HeapAlloc)
+		b.Flush(w.gw)
+
+		var reason string
+		switch {
+		case w.maxGoroutines > 0 && goroutines > w.maxGoroutines:
+			reason = fmt.Sprintf("%d goroutines exceed limit %d", goroutines, w.maxGoroutines)
+		case w.maxHeapBytes > 0 && mem.HeapAlloc > w.maxHeapBytes:
+			reason = fmt.Sprintf("heap %d bytes exceeds limit %d", mem.HeapAlloc, w.maxHeapBytes)
+		default:
+			continue
+		}
+
+		log.Printf("watchdog: %s", reason)
+		if w.restart != nil && time.Since(w.lastRestart) >= w.cooldown {
+			w.lastRestart = time.Now()
+			w.restart(reason)
+		}
+	}
+}